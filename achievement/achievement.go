@@ -0,0 +1,138 @@
+package achievement
+
+import (
+	"strings"
+	"time"
+)
+
+type Metric string
+
+const (
+	TotalCompleted  Metric = "total_completed"
+	TaskCompleted   Metric = "task_completed"
+	WeeklyStreak    Metric = "weekly_streak"
+	LeaderboardRank Metric = "leaderboard_rank"
+)
+
+type Achievement struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	EarnedAt    time.Time `json:"earnedAt"`
+}
+
+// Rule descreve uma conquista: o indicador observado e o valor a ser atingido.
+// Para LeaderboardRank o limite é a posição máxima no ranking da residência,
+// para TaskCompleted o campo Task é comparado ao nome da tarefa, sem diferenciar maiúsculas.
+type Rule struct {
+	ID          string
+	Name        string
+	Description string
+	Metric      Metric
+	Task        string
+	Threshold   int64
+}
+
+var Rules = []Rule{
+	{
+		ID:          "first-task",
+		Name:        "Primeira tarefa",
+		Description: "Concluiu a primeira tarefa",
+		Metric:      TotalCompleted,
+		Threshold:   1,
+	},
+	{
+		ID:          "ten-bathrooms",
+		Name:        "Banheiro brilhando",
+		Description: "Limpou o banheiro 10 vezes",
+		Metric:      TaskCompleted,
+		Task:        "banheiro",
+		Threshold:   10,
+	},
+	{
+		ID:          "four-week-streak",
+		Name:        "Constância",
+		Description: "Concluiu tarefas por 4 semanas seguidas",
+		Metric:      WeeklyStreak,
+		Threshold:   4,
+	},
+	{
+		ID:          "leaderboard-top",
+		Name:        "Topo do ranking",
+		Description: "Alcançou o primeiro lugar no ranking da residência",
+		Metric:      LeaderboardRank,
+		Threshold:   1,
+	},
+}
+
+type Completion struct {
+	Task string
+	At   time.Time
+}
+
+type Stats struct {
+	Total        int64
+	ByTask       map[string]int64
+	WeeklyStreak int64
+	Rank         int64
+}
+
+func NewStats(completions []Completion, rank int64, now time.Time) Stats {
+	stats := Stats{
+		ByTask: map[string]int64{},
+		Rank:   rank,
+	}
+
+	weeks := map[time.Time]bool{}
+	for _, completion := range completions {
+		stats.Total++
+		stats.ByTask[strings.ToLower(completion.Task)]++
+		weeks[startOfWeek(completion.At)] = true
+	}
+
+	// Conta as semanas consecutivas com ao menos uma conclusão, a partir da semana atual
+	for week := startOfWeek(now); weeks[week]; week = week.AddDate(0, 0, -7) {
+		stats.WeeklyStreak++
+	}
+
+	return stats
+}
+
+func (r Rule) Satisfied(stats Stats) bool {
+	switch r.Metric {
+	case TotalCompleted:
+		return stats.Total >= r.Threshold
+	case TaskCompleted:
+		var count int64
+		for name, completed := range stats.ByTask {
+			if strings.Contains(name, strings.ToLower(r.Task)) {
+				count += completed
+			}
+		}
+		return count >= r.Threshold
+	case WeeklyStreak:
+		return stats.WeeklyStreak >= r.Threshold
+	case LeaderboardRank:
+		return stats.Total > 0 && stats.Rank > 0 && stats.Rank <= r.Threshold
+	default:
+		return false
+	}
+}
+
+// Evaluate retorna as regras satisfeitas que ainda não foram conquistadas
+func Evaluate(stats Stats, earned map[string]bool) []Rule {
+	var unlocked []Rule
+	for _, rule := range Rules {
+		if !earned[rule.ID] && rule.Satisfied(stats) {
+			unlocked = append(unlocked, rule)
+		}
+	}
+	return unlocked
+}
+
+func startOfWeek(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,43 @@
+package achievement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+)
+
+// Check avalia as regras para o usuário e persiste as conquistas desbloqueadas
+func Check(ctx context.Context, achievements AchievementRepository, email string) ([]Achievement, error) {
+	completions, rank, earned, err := achievements.Progress(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao obter histórico de conclusões: %v", err)
+	}
+
+	now := time.Now()
+	unlocked := Evaluate(NewStats(completions, rank, now), earned)
+	if len(unlocked) == 0 {
+		return nil, nil
+	}
+
+	var newAchievements []Achievement
+	for _, rule := range unlocked {
+		newAchievements = append(newAchievements, Achievement{
+			ID:          rule.ID,
+			Name:        rule.Name,
+			Description: rule.Description,
+			EarnedAt:    now,
+		})
+	}
+
+	if err := achievements.Save(ctx, email, newAchievements); err != nil {
+		return nil, fmt.Errorf("Erro ao registrar conquistas: %v", err)
+	}
+
+	return newAchievements, nil
+}
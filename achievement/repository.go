@@ -0,0 +1,10 @@
+package achievement
+
+import "context"
+
+type AchievementRepository interface {
+	// Progress retorna as conclusões do usuário, sua posição no ranking da casa e as conquistas já obtidas
+	Progress(ctx context.Context, email string) ([]Completion, int64, map[string]bool, error)
+	Save(ctx context.Context, email string, achievements []Achievement) error
+	FindByUser(ctx context.Context, email string) ([]Achievement, error)
+}
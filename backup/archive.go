@@ -0,0 +1,152 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/achievement"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/reward"
+	"github.com/nsbnroque/go-to-do-list/score"
+	"github.com/nsbnroque/go-to-do-list/task"
+)
+
+// Version é a versão do formato gravado por Write. Read aceita arquivos desta
+// versão ou anteriores; a versão 2 acrescentou os webhooks, que faltam nos
+// arquivos da versão 1.
+const Version = 2
+
+// ErrUnsupportedVersion indica um arquivo gravado por uma versão mais nova da aplicação
+var ErrUnsupportedVersion = errors.New("Versão do backup não suportada")
+
+// Archive é a cópia completa dos dados da aplicação, independente do backend.
+// Inclui os hashes de senha e os segredos dos webhooks, então o arquivo deve ser
+// guardado com cuidado. O histórico de entregas dos webhooks fica de fora.
+type Archive struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Users        []User        `json:"users"`
+	Homes        []Home        `json:"homes"`
+	Rewards      []Reward      `json:"rewards"`
+	Redemptions  []Redemption  `json:"redemptions"`
+	Completions  []Completion  `json:"completions"`
+	Achievements []Achievement `json:"achievements"`
+	ScoreEntries []ScoreEntry  `json:"scoreEntries"`
+	Webhooks     []Webhook     `json:"webhooks"`
+}
+
+type User struct {
+	Email           string     `json:"email"`
+	Name            string     `json:"name"`
+	Password        string     `json:"password"`
+	Score           int64      `json:"score"`
+	DailyStreak     int64      `json:"dailyStreak"`
+	WeeklyStreak    int64      `json:"weeklyStreak"`
+	LastCompletedAt *time.Time `json:"lastCompletedAt,omitempty"`
+}
+
+type Home struct {
+	ID        uuid.UUID     `json:"id"`
+	Name      string        `json:"name"`
+	Settings  home.Settings `json:"settings"`
+	Residents []Resident    `json:"residents"`
+	Tasks     []Task        `json:"tasks"`
+	DeletedAt *time.Time    `json:"deletedAt,omitempty"`
+	DeletedBy string        `json:"deletedBy,omitempty"`
+}
+
+type Resident struct {
+	Email string    `json:"email"`
+	Role  home.Role `json:"role"`
+}
+
+// Task traz o estado da tarefa na casa, inclusive das que estão na lixeira
+type Task struct {
+	Name             string          `json:"name"`
+	Reward           int64           `json:"reward"`
+	Recurrence       task.Recurrence `json:"recurrence,omitempty"`
+	Status           task.Status     `json:"status,omitempty"`
+	Streak           int64           `json:"streak"`
+	LastCompletedAt  *time.Time      `json:"lastCompletedAt,omitempty"`
+	PendingSince     *time.Time      `json:"pendingSince,omitempty"`
+	Assignee         string          `json:"assignee,omitempty"`
+	DueAt            *time.Time      `json:"dueAt,omitempty"`
+	OverduePenalized bool            `json:"overduePenalized"`
+	DeletedAt        *time.Time      `json:"deletedAt,omitempty"`
+	DeletedBy        string          `json:"deletedBy,omitempty"`
+}
+
+type Reward struct {
+	reward.Reward
+	Home string `json:"home"`
+}
+
+type Redemption struct {
+	ID          uuid.UUID  `json:"id"`
+	Reward      string     `json:"reward"`
+	User        string     `json:"user"`
+	Cost        int64      `json:"cost"`
+	RedeemedAt  time.Time  `json:"redeemedAt"`
+	Fulfilled   bool       `json:"fulfilled"`
+	FulfilledAt *time.Time `json:"fulfilledAt,omitempty"`
+}
+
+// Completion é uma conclusão de tarefa. Home fica vazio quando o backend de
+// origem não sabe em qual casa a tarefa foi concluída.
+type Completion struct {
+	User   string    `json:"user"`
+	Home   string    `json:"home,omitempty"`
+	Task   string    `json:"task"`
+	At     time.Time `json:"at"`
+	Reward int64     `json:"reward"`
+	Bonus  int64     `json:"bonus"`
+}
+
+type Achievement struct {
+	achievement.Achievement
+	User string `json:"user"`
+}
+
+type ScoreEntry struct {
+	score.Entry
+	User     string     `json:"user"`
+	Home     string     `json:"home"`
+	WaivedAt *time.Time `json:"waivedAt,omitempty"`
+}
+
+type Webhook struct {
+	ID        uuid.UUID     `json:"id"`
+	Home      string        `json:"home"`
+	URL       string        `json:"url"`
+	Events    []events.Type `json:"events"`
+	Secret    string        `json:"secret"`
+	CreatedBy string        `json:"createdBy"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// Write grava o arquivo em JSON
+func Write(w io.Writer, archive Archive) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(archive)
+}
+
+// Read lê um arquivo gravado por Write, recusando versões desconhecidas
+func Read(r io.Reader) (Archive, error) {
+	var archive Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return Archive{}, fmt.Errorf("Arquivo de backup inválido: %v", err)
+	}
+
+	if archive.Version < 1 || archive.Version > Version {
+		return Archive{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, archive.Version)
+	}
+
+	return archive, nil
+}
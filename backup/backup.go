@@ -0,0 +1,18 @@
+package backup
+
+import (
+	"context"
+	"time"
+)
+
+// Create gera o arquivo com todos os dados do backend
+func Create(ctx context.Context, backups BackupRepository) (Archive, error) {
+	archive, err := backups.Export(ctx)
+	if err != nil {
+		return Archive{}, err
+	}
+
+	archive.Version = Version
+	archive.CreatedAt = time.Now().UTC()
+	return archive, nil
+}
@@ -0,0 +1,73 @@
+package backup
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+)
+
+// RequireToken protege as rotas administrativas com o token de BACKUP_TOKEN,
+// enviado como "Authorization: Bearer <token>". Sem token configurado as rotas ficam desativadas.
+func RequireToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			apierror.Respond(c, apierror.New(apierror.AdminRoutesDisabled))
+			return
+		}
+
+		received := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(received), []byte(token)) != 1 {
+			apierror.Respond(c, apierror.New(apierror.InvalidAdminToken))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ExportHandler(backups BackupRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		archive, err := Create(c.Request.Context(), backups)
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao gerar o backup: %w", err))
+			return
+		}
+
+		c.Header("Content-Type", "application/json")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="go-to-do-list-%s.json"`,
+			archive.CreatedAt.Format("20060102-150405")))
+		c.Status(http.StatusOK)
+
+		if err := Write(c.Writer, archive); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+func RestoreHandler(backups BackupRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		archive, err := Read(c.Request.Body)
+		if err != nil {
+			if errors.Is(err, ErrUnsupportedVersion) {
+				apierror.Respond(c, apierror.New(apierror.InvalidBackup, apierror.Field("version", apierror.NotAllowed)))
+				return
+			}
+			apierror.Respond(c, apierror.New(apierror.InvalidBackup))
+			return
+		}
+
+		if err := backups.Import(c.Request.Context(), archive); err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao restaurar o backup: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Backup restaurado: %d usuários e %d casas", len(archive.Users), len(archive.Homes)),
+		})
+	}
+}
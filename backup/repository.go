@@ -0,0 +1,11 @@
+package backup
+
+import "context"
+
+type BackupRepository interface {
+	// Export lê todos os dados; versão e data do arquivo ficam a cargo de Create
+	Export(ctx context.Context) (Archive, error)
+	// Import grava o arquivo atualizando o que já existir, então repetir a
+	// restauração do mesmo arquivo não duplica dados
+	Import(ctx context.Context, archive Archive) error
+}
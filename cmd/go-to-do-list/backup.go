@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+
+	"github.com/nsbnroque/go-to-do-list/backup"
+	"github.com/nsbnroque/go-to-do-list/pkg/routes"
+)
+
+const (
+	backupUsage  = "Uso: go-to-do-list backup [arquivo]"
+	restoreUsage = "Uso: go-to-do-list restore [arquivo]"
+)
+
+// exportBackup grava todos os dados do backend de STORAGE_BACKEND no arquivo
+// informado, ou na saída padrão
+func exportBackup(args []string) {
+	if len(args) > 1 {
+		log.Fatal(backupUsage)
+	}
+
+	ctx := context.Background()
+
+	backups, closeStorage, err := routes.OpenBackups(ctx)
+	if err != nil {
+		log.Fatalf("Falha ao abrir o armazenamento: %v", err)
+	}
+	defer closeStorage(ctx)
+
+	archive, err := backup.Create(ctx, backups)
+	if err != nil {
+		log.Fatalf("Falha ao gerar o backup: %v", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if len(args) == 1 {
+		file, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			log.Fatalf("Falha ao criar o arquivo: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := backup.Write(out, archive); err != nil {
+		log.Fatalf("Falha ao gravar o backup: %v", err)
+	}
+	log.Printf("Backup gerado: %d usuários e %d casas", len(archive.Users), len(archive.Homes))
+}
+
+// restoreBackup importa o arquivo informado, ou a entrada padrão, no backend
+// de STORAGE_BACKEND. Registros já existentes são sobrescritos
+func restoreBackup(args []string) {
+	if len(args) > 1 {
+		log.Fatal(restoreUsage)
+	}
+
+	var in io.Reader = os.Stdin
+	if len(args) == 1 {
+		file, err := os.Open(args[0])
+		if err != nil {
+			log.Fatalf("Falha ao abrir o arquivo: %v", err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	archive, err := backup.Read(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	backups, closeStorage, err := routes.OpenBackups(ctx)
+	if err != nil {
+		log.Fatalf("Falha ao abrir o armazenamento: %v", err)
+	}
+	defer closeStorage(ctx)
+
+	if err := backups.Import(ctx, archive); err != nil {
+		log.Fatalf("Falha ao restaurar o backup: %v", err)
+	}
+	log.Printf("Backup restaurado: %d usuários e %d casas", len(archive.Users), len(archive.Homes))
+}
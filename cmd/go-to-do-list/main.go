@@ -1,10 +1,25 @@
 package main
 
 import (
+	"os"
+
 	"github.com/nsbnroque/go-to-do-list/pkg/routes"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			migrate(os.Args[2:])
+			return
+		case "backup":
+			exportBackup(os.Args[2:])
+			return
+		case "restore":
+			restoreBackup(os.Args[2:])
+			return
+		}
+	}
 
 	routes.HandleRequests()
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository/graph"
+)
+
+const migrateUsage = "Uso: go-to-do-list migrate [up | status | force <versão>]"
+
+// migrate executa as migrações do Neo4j sem iniciar o servidor
+func migrate(args []string) {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	ctx := context.Background()
+
+	dbHandler, err := database.NewDatabaseHandler(ctx)
+	if err != nil {
+		log.Fatalf("Falha ao obter o handler do banco de dados: %v", err)
+	}
+	defer dbHandler.Close(ctx)
+
+	migrator := graph.NewMigrator(dbHandler)
+
+	switch command {
+	case "up":
+		if err := migrator.Up(ctx); err != nil {
+			log.Fatalf("Falha ao aplicar migrações: %v", err)
+		}
+		log.Println("Banco de dados atualizado")
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("Falha ao obter a versão do banco de dados: %v", err)
+		}
+
+		fmt.Printf("Versão atual: %d\nÚltima versão: %d\n", status.Version, status.Latest)
+		if status.Dirty != 0 {
+			fmt.Printf("Migração incompleta: %d\n", status.Dirty)
+		}
+		for _, migration := range status.Pending {
+			fmt.Printf("Pendente: %d %s\n", migration.Version, migration.Description)
+		}
+
+	case "force":
+		if len(args) < 2 {
+			log.Fatal(migrateUsage)
+		}
+
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			log.Fatalf("Versão inválida: %s", args[1])
+		}
+
+		if err := migrator.Force(ctx, version); err != nil {
+			log.Fatalf("Falha ao registrar a versão %d: %v", version, err)
+		}
+		log.Printf("Banco de dados marcado na versão %d", version)
+
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
@@ -0,0 +1,79 @@
+// Package events avisa em tempo real os moradores conectados do que acontece na
+// casa, para que os aplicativos não precisem consultar a API repetidamente. Os
+// handlers publicam os eventos no canal do sync_channel, que os entrega ao Hub, e
+// o Hub os distribui às conexões abertas por Server-Sent Events ou WebSocket.
+package events
+
+import (
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
+type Type string
+
+const (
+	TaskCreated   Type = "task_created"
+	TaskUpdated   Type = "task_updated"
+	TaskCompleted Type = "task_completed"
+	TaskDeleted   Type = "task_deleted"
+
+	ResidentJoined Type = "resident_joined"
+	// ResidentLeft ainda não é publicado, pois a API não permite sair de uma casa
+	ResidentLeft Type = "resident_left"
+
+	LeaderboardChanged Type = "leaderboard_changed"
+)
+
+// Types lista os tipos de evento que os clientes podem acompanhar
+var Types = []Type{
+	TaskCreated,
+	TaskUpdated,
+	TaskCompleted,
+	TaskDeleted,
+	ResidentJoined,
+	ResidentLeft,
+	LeaderboardChanged,
+}
+
+// Event é algo que aconteceu na casa Home
+type Event struct {
+	Type  Type   `json:"type"`
+	Home  string `json:"home"`
+	Actor string `json:"actor"`
+	// Resident é o morador afetado, como quem entrou na casa ou teve a pontuação alterada
+	Resident string      `json:"resident,omitempty"`
+	At       time.Time   `json:"at"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// From monta o evento da requisição: a casa vem do caminho, nas rotas de
+// /api/v1, e o autor é o usuário que a faz
+func From(c *gin.Context, eventType Type, data interface{}) Event {
+	return Event{
+		Type:  eventType,
+		Home:  c.Param("id"),
+		Actor: request.User(c),
+		At:    time.Now(),
+		Data:  data,
+	}
+}
+
+// Publish envia o evento sem bloquear a requisição. Com a fila cheia o evento é
+// descartado, pois a alteração já foi gravada e os clientes a veem ao recarregar.
+// Eventos sem casa, das rotas antigas, também são descartados: a operação pode
+// ter valido para qualquer das casas do autor, e entregá-los a todas elas
+// avisaria moradores de casas em que nada mudou.
+func Publish(events chan<- Event, event Event) {
+	if event.Home == "" {
+		return
+	}
+
+	select {
+	case events <- event:
+	default:
+		log.Printf("Fila de eventos cheia, evento %s de %s descartado", event.Type, event.Actor)
+	}
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"log"
+	"sync"
+)
+
+// bufferSize é quantos eventos uma conexão lenta pode acumular antes de ser encerrada
+const bufferSize = 32
+
+// Subscription é uma conexão aberta para os eventos de uma casa
+type Subscription struct {
+	Home   string
+	events chan Event
+}
+
+// Events fecha quando a conexão deve ser encerrada, seja pelo servidor ou por
+// não acompanhar os eventos
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Hub distribui os eventos às conexões abertas de cada casa
+type Hub struct {
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	closed        bool
+}
+
+func NewHub() *Hub {
+	return &Hub{subscriptions: map[*Subscription]struct{}{}}
+}
+
+// Subscribe abre uma conexão para os eventos da casa
+func (h *Hub) Subscribe(home string) *Subscription {
+	s := &Subscription{
+		Home:   home,
+		events: make(chan Event, bufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(s.events)
+		return s
+	}
+	h.subscriptions[s] = struct{}{}
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(s)
+}
+
+// Deliver entrega o evento às conexões da casa. Uma conexão com a fila cheia é
+// encerrada, e o cliente recarrega os dados ao reconectar.
+func (h *Hub) Deliver(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subscriptions {
+		if event.Home != s.Home {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		default:
+			log.Printf("Conexão de eventos da casa %s não acompanhou os eventos e foi encerrada", s.Home)
+			h.remove(s)
+		}
+	}
+}
+
+// Close encerra todas as conexões, como no desligamento do servidor
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for s := range h.subscriptions {
+		h.remove(s)
+	}
+}
+
+func (h *Hub) remove(s *Subscription) {
+	if _, ok := h.subscriptions[s]; ok {
+		delete(h.subscriptions, s)
+		close(s.events)
+	}
+}
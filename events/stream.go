@@ -0,0 +1,110 @@
+package events
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// keepAlive é o intervalo das mensagens que mantêm a conexão aberta em proxies
+	keepAlive = 30 * time.Second
+	writeWait = 10 * time.Second
+)
+
+// upgrader aceita qualquer origem, como o CORS das demais rotas
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// Stream envia os eventos da conexão por WebSocket, se o cliente pedir, ou por
+// Server-Sent Events, até que o cliente desconecte ou a conexão seja encerrada
+func Stream(c *gin.Context, s *Subscription) {
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		streamWebSocket(c, s)
+		return
+	}
+	streamSSE(c, s)
+}
+
+// IsStream indica se a requisição abre uma conexão de eventos, cuja resposta
+// não tem fim e não pode ser guardada por middlewares
+func IsStream(r *http.Request) bool {
+	return websocket.IsWebSocketUpgrade(r) || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+func streamSSE(c *gin.Context, s *Subscription) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	// desativa o buffer de proxies como o nginx
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ping := time.NewTicker(keepAlive)
+	defer ping.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-s.Events():
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(event.Type), event)
+			return true
+		case <-ping.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
+
+func streamWebSocket(c *gin.Context, s *Subscription) {
+	// Upgrade já responde ao cliente em caso de erro
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// As mensagens do cliente são ignoradas; a leitura só detecta a desconexão
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(keepAlive)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-disconnected:
+			return
+		case event, ok := <-s.Events():
+			deadline := time.Now().Add(writeWait)
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), deadline)
+				return
+			}
+			conn.SetWriteDeadline(deadline)
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package home
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+	"github.com/nsbnroque/go-to-do-list/task"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+// HomeRequest é o corpo aceito na criação da casa
+type HomeRequest struct {
+	Name string `json:"name" validate:"nonzero,maxlen=100"`
+}
+
+func (r *HomeRequest) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+}
+
+// Home cria a casa com um novo ID
+func (r HomeRequest) Home() Home {
+	return Home{ID: uuid.New(), Name: r.Name}
+}
+
+// HomePatch é o documento de PATCH da casa, em JSON Merge Patch
+type HomePatch struct {
+	Name string `json:"name" validate:"nonzero,maxlen=100"`
+}
+
+func (p *HomePatch) Normalize() {
+	p.Name = strings.TrimSpace(p.Name)
+}
+
+// NewResident identifica o usuário a ser adicionado como morador da casa
+type NewResident struct {
+	Email string `json:"email" validate:"nonzero,email"`
+}
+
+func (r *NewResident) Normalize() {
+	r.Email = request.NormalizeEmail(r.Email)
+}
+
+// HomeResponse é a casa como enviada aos clientes, com moradores e tarefas
+// também no formato de resposta
+type HomeResponse struct {
+	ID        uuid.UUID           `json:"id"`
+	Name      string              `json:"name"`
+	Version   int64               `json:"version,omitempty"`
+	Residents []user.UserResponse `json:"residents"`
+	Tasks     []task.TaskResponse `json:"tasks"`
+}
+
+func NewHomeResponse(h Home) HomeResponse {
+	return HomeResponse{
+		ID:        h.ID,
+		Name:      h.Name,
+		Version:   h.Version,
+		Residents: user.NewUserResponses(h.Residents),
+		Tasks:     task.NewTaskResponses(h.Tasks),
+	}
+}
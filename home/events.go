@@ -0,0 +1,20 @@
+package home
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
+// StreamEventsHandler mantém aberta uma conexão com os eventos da casa em :id,
+// por Server-Sent Events ou WebSocket. Fica sob RequireResident, que autentica a
+// conexão ao abri-la; como EventSource e WebSocket dos navegadores não enviam
+// cabeçalhos, o usuário também pode vir do parâmetro ?user=.
+func StreamEventsHandler(hub *events.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subscription := hub.Subscribe(request.Param(c, "id"))
+		defer hub.Unsubscribe(subscription)
+
+		events.Stream(c, subscription)
+	}
+}
@@ -0,0 +1,44 @@
+package home
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/etag"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
+type FeedItem struct {
+	Type    string    `json:"type"`
+	User    string    `json:"user"`
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+func GetHomeFeedHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := request.Param(c, "id")
+
+		limit, err := database.ParseLimit(c.Request)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest))
+			return
+		}
+
+		// Conquistas desbloqueadas pelos moradores, das mais recentes para as mais antigas
+		feed, err := homes.Feed(c.Request.Context(), id, limit)
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao obter o feed da casa: %w", err))
+			return
+		}
+
+		if feed == nil {
+			feed = []FeedItem{}
+		}
+
+		etag.JSON(c, feed)
+	}
+}
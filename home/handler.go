@@ -1,291 +1,259 @@
 package home
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
-	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/etag"
+	"github.com/nsbnroque/go-to-do-list/internal/mergepatch"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
 	"github.com/nsbnroque/go-to-do-list/user"
 )
 
-func CreateHomeHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
+func CreateHomeHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
 
-	if dbHandler == nil || err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
-		return
-	}
+		var homeRequest HomeRequest
 
-	ctx, driver := dbHandler.Ctx, dbHandler.Driver
+		if !request.Bind(c, &homeRequest) {
+			return
+		}
 
-	userEmail := c.Query("user")
+		// Gera um novo UUID para a casa
+		homeData := homeRequest.Home()
 
-	var homeData Home
+		err := homes.Create(c.Request.Context(), userEmail, homeData)
 
-	if err := c.ShouldBindJSON(&homeData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Erro ao decodificar dados da requisição",
-		})
-		return
-	}
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.UserNotFound))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao criar residência: %w", err))
+			return
+		}
 
-	// Gera um novo UUID para a casa
-	homeData.ID = uuid.New()
-
-	// Execute query
-	result, err := neo4j.ExecuteQuery(ctx, driver,
-		`MATCH (u:User {email: $email})
-		MERGE (h:Home {id: $id, name: $name})
-		MERGE (u)-[r:LIVES_IN]->(h)
-		RETURN u.name as userName, u.email as userEmail, h.name as homeName;`,
-		map[string]interface{}{
-			"id":    homeData.ID.String(), // Converte UUID para string
-			"name":  homeData.Name,
-			"email": userEmail,
-		},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Erro ao criar usuário",
+		// Envie uma resposta de sucesso
+		c.JSON(http.StatusCreated, gin.H{
+			"id":      homeData.ID,
+			"message": fmt.Sprintf("Residência %s criada com sucesso!", homeData.ID),
 		})
-		return
 	}
-
-	// Envie uma resposta de sucesso
-	c.JSON(http.StatusCreated, gin.H{
-		"message": fmt.Sprintf("Residência criada com sucesso! %v nodes criados em %+v.\n",
-			result.Summary.Counters().NodesCreated(),
-			result.Summary.ResultAvailableAfter(),
-		),
-	})
 }
 
-func AddResidentToHomeHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
+func AddResidentToHomeHandler(homes HomeRepository, published chan<- events.Event) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+		var newResident NewResident
+		if !request.Bind(c, &newResident) {
+			return
+		}
+
+		home, err := homes.AddResident(c.Request.Context(), userEmail, request.Param(c, "id"), user.User{Email: newResident.Email})
 
-	if dbHandler == nil || err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
-		return
-	}
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.NoHome))
+			return
+		}
 
-	ctx, driver := dbHandler.Ctx, dbHandler.Driver
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao adicionar usuário: %w", err))
+			return
+		}
 
-	userEmail := c.Query("user")
-	var newResident user.User
-	if err := c.ShouldBindJSON(&newResident); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Erro ao decodificar dados da requisição",
-		})
-		return
+		joined := events.From(c, events.ResidentJoined, nil)
+		joined.Resident = newResident.Email
+		for _, resident := range home.Residents {
+			if resident.Email == newResident.Email {
+				joined.Data = user.NewUserResponse(resident)
+			}
+		}
+		events.Publish(published, joined)
+
+		// Envie uma resposta de sucesso
+		etag.Set(c, home.Version)
+		c.JSON(http.StatusCreated, NewHomeResponse(home))
 	}
+}
 
-	// Execute a consulta Cypher para associar o usuário à casa e obter os residentes
-	result, err := neo4j.ExecuteQuery(ctx, driver,
-		`MATCH (u:User {email: $email})-[:LIVES_IN]->(home:Home)
-		MERGE (newResident:User {email: $newResident})
-		MERGE (newResident)-[:LIVES_IN]->(home)
-		RETURN home, [(home)<-[:LIVES_IN]-(resident:User) | resident] as residents;
-		`,
-		map[string]interface{}{
-			"email":       userEmail,
-			"newResident": newResident.Email,
-		},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Erro ao adicionar usuário",
-		})
-		return
+func GetHomeHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obter o ID da casa da URL
+		id := request.Param(c, "id")
+
+		home, err := homes.FindByID(c.Request.Context(), id)
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.HomeNotFound))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao encontrar casa: %w", err))
+			return
+		}
+
+		// Enviar a casa e os residentes como resposta
+		etag.Set(c, home.Version)
+		c.JSON(http.StatusOK, NewHomeResponse(home))
 	}
+}
 
-	var home Home
-	for _, record := range result.Records {
-		homeNode, ok := record.Values[0].(neo4j.Node)
-		if !ok {
-			handleInternalError(c, "Erro ao processar resultados da consulta")
+// PatchHomeHandler renomeia a casa em :id
+func PatchHomeHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+
+		version, err := etag.IfMatch(c)
+		if err != nil {
+			apierror.Respond(c, err)
 			return
 		}
 
-		homeIDString, ok := homeNode.Props["id"].(string)
-		if !ok {
-			handleInternalError(c, "Erro ao processar resultados da consulta")
+		current, err := homes.FindByID(c.Request.Context(), request.Param(c, "id"))
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.HomeNotFound))
 			return
 		}
-		_, err := uuid.Parse(homeIDString)
+
 		if err != nil {
-			handleInternalError(c, "Erro ao processar resultados da consulta")
+			apierror.Respond(c, fmt.Errorf("Erro ao encontrar casa: %w", err))
 			return
 		}
 
-		home.Name, ok = homeNode.Props["name"].(string)
-		if !ok {
-			handleInternalError(c, "Erro ao processar resultados da consulta")
+		var patched HomePatch
+		if !mergepatch.Bind(c, HomePatch{Name: current.Name}, &patched) {
 			return
 		}
 
-		// Extrair os residentes
-		if value, found := record.Get("residents"); found {
-			residentNodes := value.([]interface{})
-			for _, residentNodeInterface := range residentNodes {
-				residentNode, ok := residentNodeInterface.(neo4j.Node)
-				if !ok {
-					handleInternalError(c, "Erro ao processar resultados da consulta")
-					return
-				}
-
-				resident := user.User{
-					Name:  residentNode.Props["name"].(string),
-					Email: residentNode.Props["email"].(string),
-				}
-				home.Residents = append(home.Residents, resident)
-			}
+		changes := Home{ID: current.ID, Name: patched.Name, Version: current.Version}
+		if version != 0 {
+			changes.Version = version
 		}
-	}
 
-	// Envie uma resposta de sucesso
-	c.JSON(http.StatusOK, home)
+		// Apenas administradores da residência podem renomeá-la
+		home, err := homes.Update(c.Request.Context(), userEmail, changes)
 
-}
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.HomeNotFound))
+			return
+		}
 
-func GetHomeHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
+		if errors.Is(err, repository.ErrForbidden) {
+			apierror.Respond(c, apierror.New(apierror.AdminRequired))
+			return
+		}
 
-	if dbHandler == nil || err != nil {
-		handleDatabaseError(c, err)
-		return
-	}
+		if errors.Is(err, repository.ErrStaleVersion) {
+			apierror.Respond(c, apierror.New(apierror.PreconditionFailed))
+			return
+		}
 
-	ctx, driver := dbHandler.Ctx, dbHandler.Driver
-
-	// Obter o ID da casa da URL
-	id := c.Query("id")
-
-	// Execute a consulta Cypher para associar o usuário à casa e obter os residentes
-	result, err := neo4j.ExecuteQuery(ctx, driver,
-		`MATCH (home:Home {id: $id})
-		MATCH (resident:User)-[:LIVES_IN]->(home)
-		RETURN home, [(home)<-[:LIVES_IN]-(resident:User) | resident] as residents;
-		`,
-		map[string]interface{}{
-			"id": id,
-		},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Erro ao encontrar casa: %v", err),
-		})
-		return
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao alterar casa: %w", err))
+			return
+		}
+
+		etag.Set(c, home.Version)
+		c.JSON(http.StatusOK, NewHomeResponse(home))
 	}
+}
 
-	var home Home
+func DeleteHomeHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Obter o ID da casa a ser excluída da URL
+		id := c.Param("id")
+		userEmail := request.User(c)
 
-	for _, record := range result.Records {
-		// Certifique-se de que record.Values[0] é um *neo4j.Node
-		homeNode, ok := record.Values[0].(neo4j.Node)
-		if !ok {
-			handleInternalError(c, "Erro ao processar os dados")
+		version, err := etag.IfMatch(c)
+		if err != nil {
+			apierror.Respond(c, err)
 			return
 		}
 
-		homeIDString, ok := homeNode.Props["id"].(string)
-		if !ok {
-			handleInternalError(c, "Erro ao processar os dados")
+		// A casa vai para a lixeira e pode ser restaurada até o expurgo
+		err = homes.Delete(c.Request.Context(), id, userEmail, version, time.Now())
+
+		// Verifique se alguma casa foi excluída
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.HomeNotFound))
 			return
 		}
-		home.ID, err = uuid.Parse(homeIDString)
-		if err != nil {
-			handleInternalError(c, "Erro ao processar os dados")
+
+		if errors.Is(err, repository.ErrForbidden) {
+			apierror.Respond(c, apierror.New(apierror.AdminRequired))
 			return
 		}
 
-		home.Name, ok = homeNode.Props["name"].(string)
-		if !ok {
-			handleInternalError(c, "Erro ao processar os dados")
+		if errors.Is(err, repository.ErrStaleVersion) {
+			apierror.Respond(c, apierror.New(apierror.PreconditionFailed))
 			return
 		}
 
-		// Extrair os residentes
-		if value, found := record.Get("residents"); found {
-			residentNodes := value.([]interface{})
-			for _, residentNodeInterface := range residentNodes {
-				residentNode, ok := residentNodeInterface.(neo4j.Node)
-				if !ok {
-					handleInternalError(c, "Erro ao processar os dados")
-					return
-				}
-
-				resident := user.User{
-					Name:  residentNode.Props["name"].(string),
-					Email: residentNode.Props["email"].(string),
-				}
-				home.Residents = append(home.Residents, resident)
-			}
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao excluir casa: %w", err))
+			return
 		}
-	}
 
-	// Enviar a casa e os residentes como resposta
-	c.JSON(http.StatusOK, home)
+		// Envie uma resposta de sucesso
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Casa com ID %s movida para a lixeira!", id),
+		})
+	}
 }
 
-func DeleteHomeHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
+func GetResidentsHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		home, err := homes.FindByID(c.Request.Context(), request.Param(c, "id"))
 
-	if dbHandler == nil || err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
-		return
-	}
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.HomeNotFound))
+			return
+		}
 
-	ctx, driver := dbHandler.Ctx, dbHandler.Driver
-
-	// Obter o ID da casa a ser excluída da URL
-	id := c.Param("id")
-
-	result, err := neo4j.ExecuteQuery(ctx, driver,
-		`MATCH (home:Home {id: $id})
-		DETACH DELETE home;
-		`,
-		map[string]interface{}{
-			"id": id,
-		},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Erro ao excluir casa: %v", err),
-		})
-		return
-	}
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao encontrar casa: %w", err))
+			return
+		}
 
-	// Verifique se alguma casa foi excluída
-	if result.Summary.Counters().NodesDeleted() == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Casa não encontrada ou já foi excluída",
-		})
-		return
+		etag.JSON(c, user.NewUserResponses(home.Residents))
 	}
+}
+
+// RequireResident só deixa passar moradores da casa em :id. As rotas aninhadas de
+// /api/v1/homes/:id passam essa casa aos repositórios, que atuam apenas nela.
+func RequireResident(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+
+		home, err := homes.FindByID(c.Request.Context(), c.Param("id"))
 
-	// Envie uma resposta de sucesso
-	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Casa com ID %s excluída com sucesso!", id),
-	})
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.HomeNotFound))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao encontrar casa: %w", err))
+			return
+		}
+
+		for _, resident := range home.Residents {
+			if resident.Email == userEmail {
+				c.Next()
+				return
+			}
+		}
+
+		apierror.Respond(c, apierror.New(apierror.NotResident))
+	}
 }
@@ -6,9 +6,21 @@ import (
 	"github.com/nsbnroque/go-to-do-list/user"
 )
 
+type Role string
+
+const (
+	Admin    Role = "admin"
+	Resident Role = "resident"
+)
+
+// Home é a casa como guardada nos repositórios. As requisições usam HomeRequest
+// e as respostas HomeResponse.
 type Home struct {
-	ID        uuid.UUID   `json:"id"`
-	Name      string      `json:"name"`
-	Residents []user.User `json:"residents"`
-	Tasks     []task.Task `json:"tasks"`
+	ID        uuid.UUID
+	Name      string
+	Residents []user.User
+	// Version aumenta a cada gravação da casa, de seu nome, configurações ou
+	// moradores, e é enviada como ETag
+	Version int64
+	Tasks   []task.Task
 }
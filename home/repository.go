@@ -0,0 +1,35 @@
+package home
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+type HomeRepository interface {
+	// Create grava a casa tendo ownerEmail como administrador
+	Create(ctx context.Context, ownerEmail string, home Home) error
+	// AddResident adiciona o morador à casa id de email, ou a todas as casas de
+	// email com id vazio, criando o usuário se necessário, e retorna a casa atualizada
+	AddResident(ctx context.Context, email string, id string, resident user.User) (Home, error)
+	FindByID(ctx context.Context, id string) (Home, error)
+	// Update altera o nome da casa home.ID, retornando repository.ErrForbidden se
+	// adminEmail não a administrar. Com home.Version diferente de zero, só grava se
+	// a casa ainda estiver nessa versão, retornando repository.ErrStaleVersion caso contrário.
+	Update(ctx context.Context, adminEmail string, home Home) (Home, error)
+	// Delete move a casa para a lixeira, registrando quem a excluiu e quando, e
+	// retorna repository.ErrForbidden se deletedBy não a administrar; com version
+	// diferente de zero, apenas se ainda estiver nessa versão
+	Delete(ctx context.Context, id string, deletedBy string, version int64, at time.Time) error
+	// Trash lista a lixeira da casa, retornando repository.ErrNotFound se o usuário não morar nela
+	Trash(ctx context.Context, email string, id string) ([]TrashItem, error)
+	// Restore tira a casa ou uma de suas tarefas da lixeira; tarefas só são restauradas
+	// em casas ativas, e a casa retorna repository.ErrForbidden se email não a administrar
+	Restore(ctx context.Context, email string, id string, restoration Restoration) error
+	Feed(ctx context.Context, id string, limit int) ([]FeedItem, error)
+	Settings(ctx context.Context, id string) (Settings, error)
+	// UpdateSettings altera as configurações da casa id, ou de todas as casas com
+	// id vazio, retornando repository.ErrForbidden se adminEmail não a administrar
+	UpdateSettings(ctx context.Context, adminEmail string, id string, settings Settings) error
+}
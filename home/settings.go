@@ -0,0 +1,144 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/mergepatch"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+	"github.com/nsbnroque/go-to-do-list/pricing"
+)
+
+// PenaltyRules define quantos pontos são descontados do morador em cada situação, 0 desativa a regra
+type PenaltyRules struct {
+	Overdue  int64 `json:"overdue" validate:"nonnegative"`
+	Rejected int64 `json:"rejected" validate:"nonnegative"`
+}
+
+type Settings struct {
+	GraceDays      int64 `json:"graceDays" validate:"nonnegative"`
+	StreakBonus    int64 `json:"streakBonus" validate:"nonnegative"`
+	StreakBonusCap int64 `json:"streakBonusCap" validate:"nonnegative"`
+
+	Pricing   pricing.Pricing `json:"pricing"`
+	Penalties PenaltyRules    `json:"penalties"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		GraceDays:      1,
+		StreakBonus:    1,
+		StreakBonusCap: 10,
+		Pricing:        pricing.Default(),
+	}
+}
+
+// SettingsFromProps lê as configurações das propriedades do nó Home, usando o padrão para as ausentes
+func SettingsFromProps(props map[string]interface{}) Settings {
+	settings := DefaultSettings()
+	if value, ok := props["grace_days"].(int64); ok {
+		settings.GraceDays = value
+	}
+	if value, ok := props["streak_bonus"].(int64); ok {
+		settings.StreakBonus = value
+	}
+	if value, ok := props["streak_bonus_cap"].(int64); ok {
+		settings.StreakBonusCap = value
+	}
+	if value, ok := props["penalty_overdue"].(int64); ok {
+		settings.Penalties.Overdue = value
+	}
+	if value, ok := props["penalty_rejected"].(int64); ok {
+		settings.Penalties.Rejected = value
+	}
+	settings.Pricing = pricing.FromProps(props)
+	return settings
+}
+
+func GetHomeSettingsHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings, err := homes.Settings(c.Request.Context(), request.Param(c, "id"))
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.HomeNotFound))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao encontrar casa: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
+
+func UpdateHomeSettingsHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+
+		settings := DefaultSettings()
+		if !request.Bind(c, &settings) {
+			return
+		}
+
+		// Apenas administradores da residência podem alterar as configurações
+		err := homes.UpdateSettings(c.Request.Context(), userEmail, request.Param(c, "id"), settings)
+
+		if errors.Is(err, repository.ErrForbidden) {
+			apierror.Respond(c, apierror.New(apierror.AdminRequired))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao atualizar configurações: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
+
+// PatchHomeSettingsHandler altera apenas as configurações enviadas num JSON Merge
+// Patch; as removidas com null voltam ao padrão
+func PatchHomeSettingsHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+
+		current, err := homes.Settings(c.Request.Context(), request.Param(c, "id"))
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.HomeNotFound))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao encontrar casa: %w", err))
+			return
+		}
+
+		settings := DefaultSettings()
+		if !mergepatch.Bind(c, current, &settings) {
+			return
+		}
+
+		// Apenas administradores da residência podem alterar as configurações
+		err = homes.UpdateSettings(c.Request.Context(), userEmail, request.Param(c, "id"), settings)
+
+		if errors.Is(err, repository.ErrForbidden) {
+			apierror.Respond(c, apierror.New(apierror.AdminRequired))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao atualizar configurações: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
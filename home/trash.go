@@ -0,0 +1,99 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/etag"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
+type TrashKind string
+
+const (
+	TrashHome TrashKind = "home"
+	TrashTask TrashKind = "task"
+)
+
+// TrashItem é a casa ou uma de suas tarefas excluída, que pode ser restaurada até o expurgo
+type TrashItem struct {
+	Kind      TrashKind `json:"kind"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deletedAt"`
+	DeletedBy string    `json:"deletedBy,omitempty"`
+}
+
+// Restoration indica o que restaurar da lixeira; Name só é usado para tarefas
+type Restoration struct {
+	Kind TrashKind `json:"kind" validate:"nonzero,oneof=home|task"`
+	Name string    `json:"name"`
+}
+
+func GetTrashHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+		id := c.Param("id")
+
+		// A casa excluída e as tarefas excluídas dela, das mais recentes para as mais antigas
+		trash, err := homes.Trash(c.Request.Context(), userEmail, id)
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.NotResident))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao obter a lixeira da casa: %w", err))
+			return
+		}
+
+		if trash == nil {
+			trash = []TrashItem{}
+		}
+
+		etag.JSON(c, trash)
+	}
+}
+
+func RestoreHandler(homes HomeRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+		id := c.Param("id")
+
+		var restoration Restoration
+		if !request.Bind(c, &restoration) {
+			return
+		}
+
+		if restoration.Kind == TrashTask && restoration.Name == "" {
+			apierror.Respond(c, apierror.Invalid(apierror.Field("name", apierror.Required)))
+			return
+		}
+
+		err := homes.Restore(c.Request.Context(), userEmail, id, restoration)
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.TrashItemNotFound))
+			return
+		}
+
+		if errors.Is(err, repository.ErrForbidden) {
+			apierror.Respond(c, apierror.New(apierror.AdminRequired))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao restaurar item da lixeira: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Item restaurado com sucesso!",
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Package idempotency evita que uma requisição POST repetida pelo cliente, como
+// acontece em conexões instáveis, seja aplicada duas vezes. A primeira requisição
+// com um cabeçalho Idempotency-Key tem a resposta guardada, e as repetições com a
+// mesma chave recebem essa resposta sem passar de novo pelo handler.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
+const (
+	// KeyHeader traz a chave escolhida pelo cliente, em geral um UUID
+	KeyHeader = "Idempotency-Key"
+	// ReplayedHeader marca as respostas repetidas a partir da guardada
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// replayedHeaders são os cabeçalhos da resposta guardados junto com o corpo
+var replayedHeaders = []string{"Content-Type", "Content-Language", "ETag", "Location"}
+
+// Require guarda por window a resposta das requisições POST com Idempotency-Key.
+// A chave vale apenas para o usuário que a enviou; repeti-la com outro caminho ou
+// outro corpo é um erro, assim como repeti-la antes que a primeira termine.
+// Respostas 5xx não são guardadas, e a requisição pode ser repetida com a mesma chave.
+func Require(keys IdempotencyRepository, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(KeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			apierror.Respond(c, apierror.Invalid(apierror.Field(KeyHeader, apierror.TooLong)))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidBody))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		user := request.User(c)
+		now := time.Now()
+		reserved := Key{
+			Key:         user + " " + key,
+			Fingerprint: fingerprint(c.Request, user, body),
+			ExpiresAt:   now.Add(window),
+		}
+
+		existing, ok, err := keys.Reserve(c.Request.Context(), reserved, now)
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao reservar a chave de idempotência: %w", err))
+			return
+		}
+		if !ok {
+			switch {
+			case existing.Fingerprint != reserved.Fingerprint:
+				apierror.Respond(c, apierror.New(apierror.IdempotencyKeyReused))
+			case existing.Response == nil:
+				apierror.Respond(c, apierror.New(apierror.IdempotencyKeyInUse))
+			default:
+				replay(c, *existing.Response)
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// A requisição do cliente pode ter sido cancelada, mas a reserva precisa
+		// ser resolvida de qualquer forma
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			err = keys.Release(ctx, reserved.Key)
+		} else {
+			err = keys.Complete(ctx, reserved.Key, Response{
+				Status: status,
+				Header: savedHeader(recorder.Header()),
+				Body:   recorder.body.Bytes(),
+			})
+		}
+		if err != nil {
+			log.Printf("Erro ao guardar a resposta da chave de idempotência %q: %v", key, err)
+		}
+	}
+}
+
+// fingerprint resume o que identifica a requisição, para que a mesma chave não
+// seja usada em outra operação
+func fingerprint(r *http.Request, user string, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s %s\n", r.Method, r.URL.RequestURI(), user)
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replay(c *gin.Context, response Response) {
+	for name, values := range response.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Header(ReplayedHeader, "true")
+	c.Status(response.Status)
+	c.Writer.Write(response.Body)
+	c.Abort()
+}
+
+func savedHeader(header http.Header) http.Header {
+	saved := http.Header{}
+	for _, name := range replayedHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			saved[name] = values
+		}
+	}
+	return saved
+}
+
+// responseRecorder copia o corpo da resposta enquanto ele é enviado ao cliente
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,37 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Response é a resposta guardada da primeira requisição com uma chave
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Key é uma chave de idempotência reservada por uma requisição
+type Key struct {
+	Key string
+	// Fingerprint resume método, caminho e corpo da requisição que reservou a chave
+	Fingerprint string
+	ExpiresAt   time.Time
+	// Response fica nula enquanto a primeira requisição não termina
+	Response *Response
+}
+
+type IdempotencyRepository interface {
+	// Reserve reserva a chave até key.ExpiresAt e retorna true. Se outra
+	// requisição já a reservou e a reserva não expirou até now, retorna essa
+	// reserva e false, sem alterá-la.
+	Reserve(ctx context.Context, key Key, now time.Time) (Key, bool, error)
+	// Complete guarda a resposta da requisição que reservou a chave
+	Complete(ctx context.Context, key string, response Response) error
+	// Release desfaz a reserva de uma requisição que falhou, para que possa ser repetida
+	Release(ctx context.Context, key string) error
+	// Purge remove as chaves que expiraram antes de before e retorna quantas eram
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
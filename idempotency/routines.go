@@ -0,0 +1,29 @@
+package idempotency
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// PurgeExpired remove periodicamente as chaves cuja janela já terminou
+func PurgeExpired(ctx context.Context, keys IdempotencyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purged, err := keys.Purge(ctx, now)
+			if err != nil {
+				log.Println(err.Error())
+				continue
+			}
+			if purged > 0 {
+				log.Printf("%d chaves de idempotência expiradas removidas", purged)
+			}
+		}
+	}
+}
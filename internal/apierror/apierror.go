@@ -0,0 +1,109 @@
+// Package apierror padroniza as respostas de erro da API. Cada erro tem um código
+// estável, que os clientes podem comparar, e uma mensagem no idioma pedido em
+// Accept-Language:
+//
+//	{"error": "Tarefa não encontrada", "code": "task_not_found"}
+//
+// Erros de validação listam também os campos rejeitados, em "fields".
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code identifica o erro, ou o problema de um campo, independente do idioma
+type Code string
+
+// Erro retornado ao cliente; a mensagem e o status HTTP vêm do catálogo
+type Error struct {
+	Code   Code
+	Fields []FieldError
+}
+
+// FieldError aponta um campo da requisição e o que há de errado com ele. Field
+// usa a notação com pontos para campos aninhados, como pricing.cap.
+type FieldError struct {
+	Field string
+	Code  Code
+}
+
+// New cria o erro com o código informado
+func New(code Code, fields ...FieldError) *Error {
+	return &Error{Code: code, Fields: fields}
+}
+
+// Invalid cria o erro de validação com os campos rejeitados
+func Invalid(fields ...FieldError) *Error {
+	return New(ValidationFailed, fields...)
+}
+
+// Field cria o problema de um campo, para uso com Invalid
+func Field(field string, code Code) FieldError {
+	return FieldError{Field: field, Code: code}
+}
+
+// Binding converte a falha de c.ShouldBindJSON. Um valor de tipo errado aponta o
+// campo; JSON malformado não tem campo a apontar e vira invalid_body.
+func Binding(err error) *Error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return Invalid(Field(typeErr.Field, InvalidType))
+	}
+	return New(InvalidBody)
+}
+
+func (e *Error) Error() string {
+	return message(defaultLanguage, e.Code)
+}
+
+// Status retorna o status HTTP do erro
+func (e *Error) Status() int {
+	if status, ok := statuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+type response struct {
+	Error  string          `json:"error"`
+	Code   Code            `json:"code"`
+	Fields []fieldResponse `json:"fields,omitempty"`
+}
+
+type fieldResponse struct {
+	Field   string `json:"field"`
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+}
+
+// Respond interrompe a requisição com o erro. Erros que não são *Error são
+// falhas internas: vão para o log e o cliente recebe apenas internal_error,
+// sem os detalhes do banco de dados.
+func Respond(c *gin.Context, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		log.Printf("Erro em %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		apiErr = New(Internal)
+	}
+
+	lang := Language(c)
+	body := response{
+		Error: message(lang, apiErr.Code),
+		Code:  apiErr.Code,
+	}
+	for _, field := range apiErr.Fields {
+		body.Fields = append(body.Fields, fieldResponse{
+			Field:   field.Field,
+			Code:    field.Code,
+			Message: message(lang, field.Code),
+		})
+	}
+
+	c.Header("Content-Language", lang)
+	c.AbortWithStatusJSON(apiErr.Status(), body)
+}
@@ -0,0 +1,197 @@
+package apierror
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// Códigos dos erros. Uma vez publicados não devem mudar, os clientes dependem deles.
+const (
+	InvalidBody          Code = "invalid_body"
+	InvalidRequest       Code = "invalid_request"
+	ValidationFailed     Code = "validation_failed"
+	InvalidBackup        Code = "invalid_backup"
+	InvalidAdminToken    Code = "invalid_admin_token"
+	AdminRoutesDisabled  Code = "admin_routes_disabled"
+	AdminRequired        Code = "admin_required"
+	UserNotFound         Code = "user_not_found"
+	HomeNotFound         Code = "home_not_found"
+	NotResident          Code = "not_resident"
+	NoHome               Code = "no_home"
+	TaskNotFound         Code = "task_not_found"
+	TaskNotPending       Code = "task_not_pending"
+	TaskAlreadyCompleted Code = "task_already_completed"
+	AssignmentNotAllowed Code = "assignment_not_allowed"
+	RejectionNotAllowed  Code = "rejection_not_allowed"
+	RewardUnavailable    Code = "reward_unavailable"
+	RedemptionNotFound   Code = "redemption_not_found"
+	PenaltyNotFound      Code = "penalty_not_found"
+	TrashItemNotFound    Code = "trash_item_not_found"
+	PreconditionFailed   Code = "precondition_failed"
+	IdempotencyKeyReused Code = "idempotency_key_reused"
+	IdempotencyKeyInUse  Code = "idempotency_key_in_use"
+	WebhookNotFound      Code = "webhook_not_found"
+	DatabaseUnavailable  Code = "database_unavailable"
+	Internal             Code = "internal_error"
+)
+
+// Códigos dos problemas de um campo, usados em FieldError
+const (
+	Required     Code = "required"
+	InvalidType  Code = "invalid_type"
+	InvalidValue Code = "invalid_value"
+	NotAllowed   Code = "not_allowed"
+	Negative     Code = "negative"
+	NotPositive  Code = "not_positive"
+	InvalidEmail Code = "invalid_email"
+	InvalidURL   Code = "invalid_url"
+	TooShort     Code = "too_short"
+	TooLong      Code = "too_long"
+	BelowMinimum Code = "below_minimum"
+	AboveMaximum Code = "above_maximum"
+)
+
+var statuses = map[Code]int{
+	InvalidBody:          http.StatusBadRequest,
+	InvalidRequest:       http.StatusBadRequest,
+	ValidationFailed:     http.StatusBadRequest,
+	InvalidBackup:        http.StatusBadRequest,
+	InvalidAdminToken:    http.StatusUnauthorized,
+	AdminRoutesDisabled:  http.StatusForbidden,
+	AdminRequired:        http.StatusForbidden,
+	UserNotFound:         http.StatusNotFound,
+	HomeNotFound:         http.StatusNotFound,
+	NotResident:          http.StatusNotFound,
+	NoHome:               http.StatusNotFound,
+	TaskNotFound:         http.StatusNotFound,
+	TaskNotPending:       http.StatusNotFound,
+	TaskAlreadyCompleted: http.StatusConflict,
+	AssignmentNotAllowed: http.StatusNotFound,
+	RejectionNotAllowed:  http.StatusNotFound,
+	RewardUnavailable:    http.StatusConflict,
+	RedemptionNotFound:   http.StatusNotFound,
+	PenaltyNotFound:      http.StatusNotFound,
+	TrashItemNotFound:    http.StatusNotFound,
+	PreconditionFailed:   http.StatusPreconditionFailed,
+	IdempotencyKeyReused: http.StatusUnprocessableEntity,
+	IdempotencyKeyInUse:  http.StatusConflict,
+	WebhookNotFound:      http.StatusNotFound,
+	DatabaseUnavailable:  http.StatusServiceUnavailable,
+	Internal:             http.StatusInternalServerError,
+}
+
+const defaultLanguage = "pt-BR"
+
+// catalogs guarda as mensagens de cada idioma. Um código ausente de um idioma
+// usa a mensagem em português.
+var catalogs = map[string]map[Code]string{
+	"pt-BR": {
+		InvalidBody:          "Erro ao decodificar dados da requisição",
+		InvalidRequest:       "Requisição fora da especificação da API",
+		ValidationFailed:     "Dados inválidos",
+		InvalidBackup:        "Arquivo de backup inválido",
+		InvalidAdminToken:    "Token de administração inválido",
+		AdminRoutesDisabled:  "Rotas administrativas desativadas",
+		AdminRequired:        "Apenas administradores da residência podem realizar esta operação",
+		UserNotFound:         "Usuário não encontrado",
+		HomeNotFound:         "Casa não encontrada",
+		NotResident:          "Casa não encontrada ou usuário não mora nela",
+		NoHome:               "Usuário não mora em nenhuma residência",
+		TaskNotFound:         "Tarefa não encontrada",
+		TaskNotPending:       "Tarefa não encontrada ou já concluída",
+		TaskAlreadyCompleted: "Tarefa já foi concluída",
+		AssignmentNotAllowed: "Tarefa ou morador não encontrado, ou usuário não é administrador da residência",
+		RejectionNotAllowed:  "Tarefa concluída não encontrada ou usuário não é administrador da residência",
+		RewardUnavailable:    "Recompensa não encontrada, esgotada ou saldo insuficiente",
+		RedemptionNotFound:   "Resgate não encontrado ou usuário não é administrador da residência",
+		PenaltyNotFound:      "Penalidade não encontrada, já perdoada ou usuário não é administrador da residência",
+		TrashItemNotFound:    "Item não encontrado na lixeira da casa",
+		PreconditionFailed:   "O registro foi alterado por outra pessoa; leia-o novamente antes de alterar",
+		IdempotencyKeyReused: "Chave de idempotência já usada em outra requisição",
+		IdempotencyKeyInUse:  "Uma requisição com a mesma chave de idempotência ainda está em andamento",
+		WebhookNotFound:      "Webhook não encontrado",
+		DatabaseUnavailable:  "Falha de conexão com o banco de dados",
+		Internal:             "Erro interno do servidor",
+
+		Required:     "Campo obrigatório",
+		InvalidType:  "Tipo de valor inválido",
+		InvalidValue: "Valor inválido",
+		NotAllowed:   "Valor fora das opções permitidas",
+		Negative:     "Não pode ser negativo",
+		NotPositive:  "Deve ser maior que zero",
+		InvalidEmail: "Email inválido",
+		InvalidURL:   "URL http ou https inválida",
+		TooShort:     "Texto curto demais",
+		TooLong:      "Texto longo demais",
+		BelowMinimum: "Abaixo do mínimo permitido",
+		AboveMaximum: "Acima do máximo permitido",
+	},
+	"en": {
+		InvalidBody:          "Could not decode the request body",
+		InvalidRequest:       "Request does not match the API specification",
+		ValidationFailed:     "Invalid data",
+		InvalidBackup:        "Invalid backup file",
+		InvalidAdminToken:    "Invalid admin token",
+		AdminRoutesDisabled:  "Admin routes are disabled",
+		AdminRequired:        "Only home admins can perform this operation",
+		UserNotFound:         "User not found",
+		HomeNotFound:         "Home not found",
+		NotResident:          "Home not found or user does not live in it",
+		NoHome:               "User does not live in any home",
+		TaskNotFound:         "Task not found",
+		TaskNotPending:       "Task not found or already completed",
+		TaskAlreadyCompleted: "Task was already completed",
+		AssignmentNotAllowed: "Task or resident not found, or user is not a home admin",
+		RejectionNotAllowed:  "Completed task not found or user is not a home admin",
+		RewardUnavailable:    "Reward not found, out of stock or insufficient score",
+		RedemptionNotFound:   "Redemption not found or user is not a home admin",
+		PenaltyNotFound:      "Penalty not found, already waived or user is not a home admin",
+		TrashItemNotFound:    "Item not found in the home's trash",
+		PreconditionFailed:   "The record was changed by someone else; read it again before changing it",
+		IdempotencyKeyReused: "Idempotency key was already used for a different request",
+		IdempotencyKeyInUse:  "A request with the same idempotency key is still in progress",
+		WebhookNotFound:      "Webhook not found",
+		DatabaseUnavailable:  "Could not connect to the database",
+		Internal:             "Internal server error",
+
+		Required:     "This field is required",
+		InvalidType:  "Invalid value type",
+		InvalidValue: "Invalid value",
+		NotAllowed:   "Value is not one of the allowed options",
+		Negative:     "Must not be negative",
+		NotPositive:  "Must be greater than zero",
+		InvalidEmail: "Invalid email address",
+		InvalidURL:   "Invalid http or https URL",
+		TooShort:     "Text is too short",
+		TooLong:      "Text is too long",
+		BelowMinimum: "Below the allowed minimum",
+		AboveMaximum: "Above the allowed maximum",
+	},
+}
+
+var matcher = language.NewMatcher([]language.Tag{
+	language.BrazilianPortuguese,
+	language.English,
+})
+
+// Language escolhe o idioma das mensagens pelo cabeçalho Accept-Language,
+// português quando o cabeçalho está ausente ou não pede nenhum dos suportados
+func Language(c *gin.Context) string {
+	tag, _ := language.MatchStrings(matcher, c.GetHeader("Accept-Language"))
+	if base, _ := tag.Base(); base.String() == "en" {
+		return "en"
+	}
+	return defaultLanguage
+}
+
+func message(lang string, code Code) string {
+	if msg, ok := catalogs[lang][code]; ok {
+		return msg
+	}
+	if msg, ok := catalogs[defaultLanguage][code]; ok {
+		return msg
+	}
+	return string(code)
+}
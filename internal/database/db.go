@@ -2,43 +2,72 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
+// DatabaseHandler guarda o driver compartilhado por toda a aplicação.
+// É criado uma única vez na inicialização e fechado no encerramento do servidor.
 type DatabaseHandler struct {
 	Driver neo4j.DriverWithContext
-	Ctx    context.Context
 	Config *Neo4jConfiguration
-}
 
-var instance *DatabaseHandler
-var errConnectivity error
+	sessions sessions
+}
 
-func NewDatabaseHandler() (*DatabaseHandler, error) {
+func NewDatabaseHandler(ctx context.Context) (*DatabaseHandler, error) {
 	config := ParseConfiguration()
 
-	// Tentar estabelecer a conexão até 60 segundos
-	for i := 0; i < 30; i++ {
-		ctx := context.Background()
-		driver, err := config.NewDriver()
+	driver, err := config.NewDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	// O banco pode ainda estar subindo (ex.: docker-compose), então tentamos algumas vezes
+	for i := 1; ; i++ {
+		verifyCtx, cancel := context.WithTimeout(ctx, config.ConnectTimeout)
+		err = driver.VerifyConnectivity(verifyCtx)
+		cancel()
 
 		if err == nil {
-			return &DatabaseHandler{
-				Driver: driver,
-				Ctx:    ctx,
-				Config: config,
-			}, nil
+			break
+		}
+
+		if i >= config.ConnectRetries {
+			driver.Close(ctx)
+			return nil, fmt.Errorf("Falha ao conectar ao banco de dados após %d tentativas: %v", i, err)
 		}
 
-		errConnectivity = err // Atualiza o erro de conectividade
-		time.Sleep(time.Second)
+		log.Printf("Banco de dados indisponível (tentativa %d de %d): %v", i, config.ConnectRetries, err)
+
+		select {
+		case <-ctx.Done():
+			driver.Close(context.Background())
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
 	}
 
-	return nil, errConnectivity
+	return &DatabaseHandler{
+		Driver:   driver,
+		Config:   config,
+		sessions: newSessions(),
+	}, nil
+}
+
+// Ping verifica se o banco de dados continua acessível
+func (dh *DatabaseHandler) Ping(ctx context.Context) error {
+	return dh.Driver.VerifyConnectivity(ctx)
+}
+
+func (dh *DatabaseHandler) Close(ctx context.Context) error {
+	return dh.Driver.Close(ctx)
 }
 
 type Neo4jConfiguration struct {
@@ -46,10 +75,28 @@ type Neo4jConfiguration struct {
 	Username string
 	Password string
 	Database string
+
+	// Cluster envia as leituras aos seguidores, com bookmarks por sessão
+	Cluster bool
+
+	MaxConnectionPoolSize        int
+	ConnectionAcquisitionTimeout time.Duration
+	ConnectTimeout               time.Duration
+	MaxConnectionLifetime        time.Duration
+	MaxTransactionRetryTime      time.Duration
+	ConnectRetries               int
 }
 
 func (nc *Neo4jConfiguration) NewDriver() (neo4j.DriverWithContext, error) {
-	return neo4j.NewDriverWithContext(nc.Url, neo4j.BasicAuth(nc.Username, nc.Password, ""))
+	return neo4j.NewDriverWithContext(nc.Url, neo4j.BasicAuth(nc.Username, nc.Password, ""),
+		func(config *neo4j.Config) {
+			config.MaxConnectionPoolSize = nc.MaxConnectionPoolSize
+			config.ConnectionAcquisitionTimeout = nc.ConnectionAcquisitionTimeout
+			config.SocketConnectTimeout = nc.ConnectTimeout
+			config.MaxConnectionLifetime = nc.MaxConnectionLifetime
+			config.MaxTransactionRetryTime = nc.MaxTransactionRetryTime
+		},
+	)
 }
 
 func ParseConfiguration() *Neo4jConfiguration {
@@ -62,6 +109,14 @@ func ParseConfiguration() *Neo4jConfiguration {
 		Username: lookupEnvOrGetDefault("NEO4J_USER", "neo4j"),
 		Password: lookupEnvOrGetDefault("NEO4J_PASSWORD", "supersecret"),
 		Database: database,
+		Cluster:  lookupEnvOrGetDefault("NEO4J_CLUSTER", "false") == "true",
+
+		MaxConnectionPoolSize:        lookupIntOrGetDefault("NEO4J_MAX_POOL_SIZE", 100),
+		ConnectionAcquisitionTimeout: lookupDurationOrGetDefault("NEO4J_ACQUISITION_TIMEOUT", time.Minute),
+		ConnectTimeout:               lookupDurationOrGetDefault("NEO4J_CONNECT_TIMEOUT", 5*time.Second),
+		MaxConnectionLifetime:        lookupDurationOrGetDefault("NEO4J_MAX_CONNECTION_LIFETIME", time.Hour),
+		MaxTransactionRetryTime:      lookupDurationOrGetDefault("NEO4J_MAX_TRANSACTION_RETRY_TIME", 30*time.Second),
+		ConnectRetries:               lookupIntOrGetDefault("NEO4J_CONNECT_RETRIES", 30),
 	}
 }
 
@@ -72,3 +127,19 @@ func lookupEnvOrGetDefault(key string, defaultValue string) string {
 		return env
 	}
 }
+
+func lookupIntOrGetDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(lookupEnvOrGetDefault(key, ""))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func lookupDurationOrGetDefault(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(lookupEnvOrGetDefault(key, ""))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -1,18 +1,35 @@
 package database
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 )
 
-func parseLimit(req *http.Request) int {
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+var ErrInvalidLimit = errors.New("limite inválido")
+
+// ParseLimit lê o parâmetro limit da consulta, 50 por padrão, limitado ao
+// intervalo de 1 a 200. Valores que não são números retornam ErrInvalidLimit.
+func ParseLimit(req *http.Request) (int, error) {
 	limits := req.URL.Query()["limit"]
-	limit := 50
-	if len(limits) > 0 {
-		var err error
-		if limit, err = strconv.Atoi(limits[0]); err != nil {
-			limit = 50
-		}
+	if len(limits) == 0 {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limits[0])
+	if err != nil {
+		return 0, ErrInvalidLimit
+	}
+	if limit < 1 {
+		return 1, nil
+	}
+	if limit > maxLimit {
+		return maxLimit, nil
 	}
-	return limit
+	return limit, nil
 }
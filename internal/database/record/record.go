@@ -0,0 +1,220 @@
+// Package record converte registros e nós do Neo4j em structs, guiado pela tag
+// neo4j de cada campo, em vez de asserções de tipo espalhadas pelos repositórios.
+//
+// A tag traz o nome da coluna (ou da propriedade, para nós) e opções:
+//
+//	Name  string     `neo4j:"name,optional"` // nulo ou ausente vira ""
+//	Email string     `neo4j:"email"`         // nulo é erro
+//	DueAt *time.Time `neo4j:"dueAt"`         // ponteiros aceitam nulo
+//
+// Campos sem tag são ignorados. Valores nulos só são aceitos em campos
+// opcionais, ponteiros, slices e maps; nos demais o erro indica a coluna e o tipo.
+package record
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+var (
+	ErrNull    = errors.New("valor nulo")
+	ErrMissing = errors.New("coluna ausente")
+)
+
+// Decode preenche um T com as colunas do registro
+func Decode[T any](rec *neo4j.Record) (T, error) {
+	var dst T
+
+	values := make(map[string]interface{}, len(rec.Keys))
+	for i, key := range rec.Keys {
+		values[key] = rec.Values[i]
+	}
+
+	err := decodeStruct(values, reflect.ValueOf(&dst).Elem())
+	return dst, err
+}
+
+// DecodeAll converte todos os registros, parando no primeiro erro
+func DecodeAll[T any](records []*neo4j.Record) ([]T, error) {
+	var result []T
+	for _, rec := range records {
+		dst, err := Decode[T](rec)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, dst)
+	}
+	return result, nil
+}
+
+// DecodeProps preenche um T com as propriedades de um nó ou relacionamento
+func DecodeProps[T any](props map[string]interface{}) (T, error) {
+	var dst T
+	err := decodeStruct(props, reflect.ValueOf(&dst).Elem())
+	return dst, err
+}
+
+func decodeStruct(values map[string]interface{}, dst reflect.Value) error {
+	structType := dst.Type()
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("record: %s não é uma struct", structType)
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		tag, found := field.Tag.Lookup("neo4j")
+		if !found || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		optional := options == "optional"
+
+		value, present := values[name]
+		if !present && !optional {
+			return fieldError(structType, name, ErrMissing)
+		}
+		if value == nil {
+			if optional || nullable(field.Type) {
+				continue
+			}
+			return fieldError(structType, name, ErrNull)
+		}
+
+		if err := assign(value, dst.Field(i)); err != nil {
+			return fieldError(structType, name, err)
+		}
+	}
+
+	return nil
+}
+
+func fieldError(structType reflect.Type, name string, err error) error {
+	return fmt.Errorf("record: coluna %q de %s: %w", name, structType, err)
+}
+
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textUnmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func assign(value interface{}, dst reflect.Value) error {
+	if value == nil {
+		if nullable(dst.Type()) {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		return ErrNull
+	}
+
+	src := reflect.ValueOf(value)
+
+	if dst.Kind() == reflect.Pointer {
+		target := reflect.New(dst.Type().Elem())
+		if err := assign(value, target.Elem()); err != nil {
+			return err
+		}
+		dst.Set(target)
+		return nil
+	}
+
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	// Tipos como uuid.UUID são lidos do texto gravado no grafo
+	if text, ok := value.(string); ok && reflect.PointerTo(dst.Type()).Implements(textUnmarshalType) {
+		return dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+
+	if dst.Type() == timeType {
+		switch v := value.(type) {
+		case neo4j.LocalDateTime:
+			dst.Set(reflect.ValueOf(v.Time()))
+			return nil
+		case neo4j.Date:
+			dst.Set(reflect.ValueOf(v.Time()))
+			return nil
+		}
+		return mismatch(dst, value)
+	}
+
+	switch v := value.(type) {
+	case neo4j.Node:
+		if dst.Kind() == reflect.Struct {
+			return decodeStruct(v.Props, dst)
+		}
+	case neo4j.Relationship:
+		if dst.Kind() == reflect.Struct {
+			return decodeStruct(v.Props, dst)
+		}
+	case map[string]interface{}:
+		if dst.Kind() == reflect.Struct {
+			return decodeStruct(v, dst)
+		}
+	case []interface{}:
+		if dst.Kind() == reflect.Slice {
+			items := reflect.MakeSlice(dst.Type(), len(v), len(v))
+			for i, item := range v {
+				if err := assign(item, items.Index(i)); err != nil {
+					return fmt.Errorf("item %d: %w", i, err)
+				}
+			}
+			dst.Set(items)
+			return nil
+		}
+	}
+
+	// Tipos nomeados, como task.Status, a partir do tipo básico correspondente
+	switch dst.Kind() {
+	case reflect.String:
+		if src.Kind() == reflect.String {
+			dst.SetString(src.String())
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if src.Kind() == reflect.Int64 {
+			if dst.OverflowInt(src.Int()) {
+				return fmt.Errorf("%d não cabe em %s", src.Int(), dst.Type())
+			}
+			dst.SetInt(src.Int())
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch src.Kind() {
+		case reflect.Float64:
+			dst.SetFloat(src.Float())
+			return nil
+		case reflect.Int64:
+			dst.SetFloat(float64(src.Int()))
+			return nil
+		}
+	case reflect.Bool:
+		if src.Kind() == reflect.Bool {
+			dst.SetBool(src.Bool())
+			return nil
+		}
+	}
+
+	return mismatch(dst, value)
+}
+
+func mismatch(dst reflect.Value, value interface{}) error {
+	return fmt.Errorf("esperado %s, obtido %T", dst.Type(), value)
+}
@@ -0,0 +1,295 @@
+package record
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type status string
+
+type person struct {
+	Name     string     `neo4j:"name"`
+	Nickname string     `neo4j:"nickname,optional"`
+	Age      int        `neo4j:"age,optional"`
+	Score    float64    `neo4j:"score,optional"`
+	Active   bool       `neo4j:"active,optional"`
+	Status   status     `neo4j:"status,optional"`
+	DueAt    *time.Time `neo4j:"dueAt"`
+	Ignored  string
+}
+
+type address struct {
+	Street string `neo4j:"street"`
+	Number int    `neo4j:"number,optional"`
+}
+
+type resident struct {
+	ID       uuid.UUID `neo4j:"id"`
+	Home     address   `neo4j:"home"`
+	Tags     []string  `neo4j:"tags"`
+	Previous []address `neo4j:"previous,optional"`
+	JoinedAt time.Time `neo4j:"joinedAt,optional"`
+}
+
+func newRecord(values map[string]interface{}) *neo4j.Record {
+	rec := &neo4j.Record{}
+	for key, value := range values {
+		rec.Keys = append(rec.Keys, key)
+		rec.Values = append(rec.Values, value)
+	}
+	return rec
+}
+
+func TestDecode(t *testing.T) {
+	due := time.Date(2024, 5, 10, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		values  map[string]interface{}
+		want    person
+		wantErr error
+		errText string
+	}{
+		{
+			name: "todas as colunas",
+			values: map[string]interface{}{
+				"name": "Ana", "nickname": "Aninha", "age": int64(30), "score": int64(7),
+				"active": true, "status": "pending", "dueAt": neo4j.LocalDateTimeOf(due),
+			},
+			want: person{Name: "Ana", Nickname: "Aninha", Age: 30, Score: 7, Active: true, Status: "pending", DueAt: &due},
+		},
+		{
+			name:   "opcionais ausentes",
+			values: map[string]interface{}{"name": "Ana", "dueAt": nil},
+			want:   person{Name: "Ana"},
+		},
+		{
+			name:   "opcionais nulos",
+			values: map[string]interface{}{"name": "Ana", "nickname": nil, "age": nil, "dueAt": nil},
+			want:   person{Name: "Ana"},
+		},
+		{
+			name:    "coluna obrigatória ausente",
+			values:  map[string]interface{}{"dueAt": nil},
+			wantErr: ErrMissing,
+			errText: `"name"`,
+		},
+		{
+			name:    "coluna obrigatória nula",
+			values:  map[string]interface{}{"name": nil, "dueAt": nil},
+			wantErr: ErrNull,
+			errText: `"name"`,
+		},
+		{
+			name:    "ponteiro obrigatório ausente",
+			values:  map[string]interface{}{"name": "Ana"},
+			wantErr: ErrMissing,
+			errText: `"dueAt"`,
+		},
+		{
+			name:    "texto em campo inteiro",
+			values:  map[string]interface{}{"name": "Ana", "age": "trinta", "dueAt": nil},
+			errText: "esperado int, obtido string",
+		},
+		{
+			name:    "inteiro em campo de texto",
+			values:  map[string]interface{}{"name": int64(1), "dueAt": nil},
+			errText: "esperado string, obtido int64",
+		},
+		{
+			name:    "inteiro em campo de data",
+			values:  map[string]interface{}{"name": "Ana", "dueAt": int64(1)},
+			errText: "esperado time.Time, obtido int64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode[person](newRecord(tt.values))
+
+			if tt.wantErr != nil || tt.errText != "" {
+				if err == nil {
+					t.Fatalf("esperava erro, obteve %+v", got)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("erro = %v, esperava %v", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.errText) {
+					t.Errorf("erro = %q, esperava conter %q", err, tt.errText)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if got.Name != tt.want.Name || got.Nickname != tt.want.Nickname || got.Age != tt.want.Age ||
+				got.Score != tt.want.Score || got.Active != tt.want.Active || got.Status != tt.want.Status {
+				t.Errorf("obteve %+v, esperava %+v", got, tt.want)
+			}
+			if (got.DueAt == nil) != (tt.want.DueAt == nil) || (got.DueAt != nil && !got.DueAt.Equal(*tt.want.DueAt)) {
+				t.Errorf("dueAt = %v, esperava %v", got.DueAt, tt.want.DueAt)
+			}
+		})
+	}
+}
+
+func TestDecodeNested(t *testing.T) {
+	id := uuid.New()
+	joined := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		values  map[string]interface{}
+		want    resident
+		errText string
+	}{
+		{
+			name: "nó, lista e mapas",
+			values: map[string]interface{}{
+				"id":   id.String(),
+				"home": neo4j.Node{Props: map[string]interface{}{"street": "Rua A", "number": int64(10)}},
+				"tags": []interface{}{"admin", "morador"},
+				"previous": []interface{}{
+					map[string]interface{}{"street": "Rua B"},
+					map[string]interface{}{"street": "Rua C", "number": int64(3)},
+				},
+				"joinedAt": neo4j.DateOf(joined),
+			},
+			want: resident{
+				ID:       id,
+				Home:     address{Street: "Rua A", Number: 10},
+				Tags:     []string{"admin", "morador"},
+				Previous: []address{{Street: "Rua B"}, {Street: "Rua C", Number: 3}},
+				JoinedAt: joined,
+			},
+		},
+		{
+			name: "relacionamento e lista nula",
+			values: map[string]interface{}{
+				"id":   id.String(),
+				"home": neo4j.Relationship{Props: map[string]interface{}{"street": "Rua A"}},
+				"tags": nil,
+			},
+			want: resident{ID: id, Home: address{Street: "Rua A"}},
+		},
+		{
+			name: "propriedade aninhada ausente",
+			values: map[string]interface{}{
+				"id":   id.String(),
+				"home": neo4j.Node{Props: map[string]interface{}{"number": int64(10)}},
+				"tags": nil,
+			},
+			errText: `coluna "street" de record.address: coluna ausente`,
+		},
+		{
+			name: "item da lista com tipo errado",
+			values: map[string]interface{}{
+				"id":   id.String(),
+				"home": map[string]interface{}{"street": "Rua A"},
+				"tags": []interface{}{"admin", int64(2)},
+			},
+			errText: "item 1: esperado string, obtido int64",
+		},
+		{
+			name: "uuid inválido",
+			values: map[string]interface{}{
+				"id":   "não é uuid",
+				"home": map[string]interface{}{"street": "Rua A"},
+				"tags": nil,
+			},
+			errText: `coluna "id"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode[resident](newRecord(tt.values))
+
+			if tt.errText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Fatalf("erro = %v, esperava conter %q", err, tt.errText)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if got.ID != tt.want.ID || got.Home != tt.want.Home || !got.JoinedAt.Equal(tt.want.JoinedAt) {
+				t.Errorf("obteve %+v, esperava %+v", got, tt.want)
+			}
+			if strings.Join(got.Tags, ",") != strings.Join(tt.want.Tags, ",") {
+				t.Errorf("tags = %v, esperava %v", got.Tags, tt.want.Tags)
+			}
+			if len(got.Previous) != len(tt.want.Previous) {
+				t.Fatalf("previous = %v, esperava %v", got.Previous, tt.want.Previous)
+			}
+			for i := range got.Previous {
+				if got.Previous[i] != tt.want.Previous[i] {
+					t.Errorf("previous[%d] = %+v, esperava %+v", i, got.Previous[i], tt.want.Previous[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeOverflow(t *testing.T) {
+	type small struct {
+		Value int8 `neo4j:"value"`
+	}
+
+	_, err := Decode[small](newRecord(map[string]interface{}{"value": int64(300)}))
+	if err == nil || !strings.Contains(err.Error(), "300 não cabe em int8") {
+		t.Fatalf("erro = %v, esperava estouro de int8", err)
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	records := []*neo4j.Record{
+		newRecord(map[string]interface{}{"name": "Ana", "dueAt": nil}),
+		newRecord(map[string]interface{}{"name": "Bia", "dueAt": nil}),
+	}
+
+	people, err := DecodeAll[person](records)
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if len(people) != 2 || people[0].Name != "Ana" || people[1].Name != "Bia" {
+		t.Errorf("obteve %+v", people)
+	}
+
+	records = append(records, newRecord(map[string]interface{}{"dueAt": nil}))
+	people, err = DecodeAll[person](records)
+	if !errors.Is(err, ErrMissing) || people != nil {
+		t.Errorf("obteve %+v, %v; esperava nil e ErrMissing", people, err)
+	}
+
+	people, err = DecodeAll[person](nil)
+	if err != nil || people != nil {
+		t.Errorf("obteve %+v, %v; esperava nil sem erro", people, err)
+	}
+}
+
+func TestDecodeProps(t *testing.T) {
+	got, err := DecodeProps[address](map[string]interface{}{"street": "Rua A", "number": int64(5), "extra": true})
+	if err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+	if got != (address{Street: "Rua A", Number: 5}) {
+		t.Errorf("obteve %+v", got)
+	}
+
+	if _, err := DecodeProps[address](map[string]interface{}{"street": "Rua A", "number": 1.5}); err == nil {
+		t.Error("esperava erro para float em campo inteiro")
+	}
+
+	if _, err := DecodeProps[string](map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "não é uma struct") {
+		t.Errorf("erro = %v, esperava struct obrigatória", err)
+	}
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	// maxSessions limita quantas sessões causais ficam em memória; passado o
+	// limite, a usada há mais tempo dá lugar à nova
+	maxSessions = 10000
+	// sessionTTL é o tempo sem uso depois do qual a sessão é descartada
+	sessionTTL = 30 * time.Minute
+)
+
+type sessionKey struct{}
+
+// WithSession associa o contexto à sessão causal identificada por key, em geral
+// o email do usuário da requisição. Em cluster, as leituras da sessão esperam
+// até que o seguidor consultado tenha aplicado as escritas anteriores dela.
+func WithSession(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, key)
+}
+
+type session struct {
+	key     string
+	manager neo4j.BookmarkManager
+	usedAt  time.Time
+}
+
+// sessions guarda um gerenciador de bookmarks por sessão causal, da usada mais
+// recentemente, na frente de order, à mais antiga
+type sessions struct {
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newSessions() sessions {
+	return sessions{order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// bookmarkManager escolhe os bookmarks usados pela consulta. Numa instância
+// única todas as consultas vão para o mesmo servidor e compartilham os bookmarks
+// do driver, como antes.
+func (dh *DatabaseHandler) bookmarkManager(ctx context.Context) neo4j.BookmarkManager {
+	shared := dh.Driver.ExecuteQueryBookmarkManager()
+
+	key, _ := ctx.Value(sessionKey{}).(string)
+	if !dh.Config.Cluster || key == "" {
+		return shared
+	}
+
+	dh.sessions.mu.Lock()
+	defer dh.sessions.mu.Unlock()
+
+	now := time.Now()
+	if element, found := dh.sessions.entries[key]; found {
+		s := element.Value.(*session)
+		s.usedAt = now
+		dh.sessions.order.MoveToFront(element)
+		return s.manager
+	}
+
+	// Os bookmarks das sessões descartadas passam aos compartilhados, de onde a
+	// nova sessão parte, para que ninguém deixe de ler as próprias escritas
+	for oldest := dh.sessions.order.Back(); oldest != nil; oldest = dh.sessions.order.Back() {
+		s := oldest.Value.(*session)
+		if dh.sessions.order.Len() < maxSessions && now.Sub(s.usedAt) < sessionTTL {
+			break
+		}
+		if bookmarks, err := s.manager.GetBookmarks(ctx); err == nil {
+			shared.UpdateBookmarks(ctx, nil, bookmarks)
+		}
+		dh.sessions.order.Remove(oldest)
+		delete(dh.sessions.entries, s.key)
+	}
+
+	initial, err := shared.GetBookmarks(ctx)
+	if err != nil {
+		return shared
+	}
+
+	s := &session{
+		key:     key,
+		manager: neo4j.NewBookmarkManager(neo4j.BookmarkManagerConfig{InitialBookmarks: initial}),
+		usedAt:  now,
+	}
+	dh.sessions.entries[key] = dh.sessions.order.PushFront(s)
+	return s.manager
+}
+
+// Write executa uma consulta avulsa no líder do cluster
+func (dh *DatabaseHandler) Write(ctx context.Context, query string, params map[string]interface{}) (*neo4j.EagerResult, error) {
+	return dh.executeQuery(ctx, query, params, neo4j.ExecuteQueryWithWritersRouting())
+}
+
+// Read executa uma consulta somente de leitura. Em cluster ela vai para um
+// seguidor; numa instância única, para o mesmo servidor das escritas.
+func (dh *DatabaseHandler) Read(ctx context.Context, query string, params map[string]interface{}) (*neo4j.EagerResult, error) {
+	routing := neo4j.ExecuteQueryWithWritersRouting()
+	if dh.Config.Cluster {
+		routing = neo4j.ExecuteQueryWithReadersRouting()
+	}
+	return dh.executeQuery(ctx, query, params, routing)
+}
+
+func (dh *DatabaseHandler) executeQuery(ctx context.Context, query string, params map[string]interface{},
+	routing neo4j.ExecuteQueryConfigurationOption) (*neo4j.EagerResult, error) {
+	return neo4j.ExecuteQuery(ctx, dh.Driver, query, params,
+		neo4j.EagerResultTransformer,
+		neo4j.ExecuteQueryWithDatabase(dh.Config.Database),
+		neo4j.ExecuteQueryWithBookmarkManager(dh.bookmarkManager(ctx)),
+		routing,
+	)
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteHandler guarda a conexão com o arquivo SQLite usado pelo backend sql.
+// O SQLite aceita apenas um escritor por vez, então as operações são serializadas
+// numa única conexão em vez de disputarem o lock do arquivo.
+type SQLiteHandler struct {
+	DB   *sql.DB
+	Path string
+}
+
+func NewSQLiteHandler(ctx context.Context) (*SQLiteHandler, error) {
+	path := lookupEnvOrGetDefault("SQLITE_PATH", "go-to-do-list.db")
+
+	// Horários no formato do SQLite para que comparações e ordenações funcionem no próprio banco
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite", path))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Falha ao abrir o banco de dados %s: %v", path, err)
+	}
+
+	return &SQLiteHandler{
+		DB:   db,
+		Path: path,
+	}, nil
+}
+
+func (sh *SQLiteHandler) Ping(ctx context.Context) error {
+	return sh.DB.PingContext(ctx)
+}
+
+func (sh *SQLiteHandler) Close(ctx context.Context) error {
+	return sh.DB.Close()
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Tx executa consultas dentro de uma unidade de trabalho
+type Tx struct {
+	tx neo4j.ManagedTransaction
+}
+
+// Run executa a consulta e já lê todos os registros, como o neo4j.ExecuteQuery
+func (t Tx) Run(ctx context.Context, query string, params map[string]interface{}) (*neo4j.EagerResult, error) {
+	result, err := t.tx.Run(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := result.Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := result.Consume(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := result.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	return &neo4j.EagerResult{
+		Keys:    keys,
+		Records: records,
+		Summary: summary,
+	}, nil
+}
+
+// UnitOfWork executa work numa única transação de escrita: ou todas as consultas
+// são confirmadas ou nenhuma. Em erros transitórios, como deadlocks ou troca de
+// líder do cluster, o driver repete work por até MaxTransactionRetryTime, então
+// work não deve ter efeitos fora da transação. Um erro retornado por work desfaz
+// a transação e é devolvido sem novas tentativas.
+func (dh *DatabaseHandler) UnitOfWork(ctx context.Context, work func(tx Tx) error) error {
+	session := dh.Driver.NewSession(ctx, neo4j.SessionConfig{
+		AccessMode:      neo4j.AccessModeWrite,
+		DatabaseName:    dh.Config.Database,
+		BookmarkManager: dh.bookmarkManager(ctx),
+	})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return nil, work(Tx{tx})
+	})
+	return err
+}
+
+// ReadUnitOfWork executa work numa única transação de leitura, para consultas
+// que precisam ver o mesmo estado do banco. Em cluster ela vai para um seguidor.
+func (dh *DatabaseHandler) ReadUnitOfWork(ctx context.Context, work func(tx Tx) error) error {
+	accessMode := neo4j.AccessModeWrite
+	if dh.Config.Cluster {
+		accessMode = neo4j.AccessModeRead
+	}
+
+	session := dh.Driver.NewSession(ctx, neo4j.SessionConfig{
+		AccessMode:      accessMode,
+		DatabaseName:    dh.Config.Database,
+		BookmarkManager: dh.bookmarkManager(ctx),
+	})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return nil, work(Tx{tx})
+	})
+	return err
+}
@@ -0,0 +1,81 @@
+// Package etag implementa o controle de concorrência otimista da API.
+//
+// Tarefas, casas e usuários guardam uma versão, incrementada a cada gravação, e
+// a enviam como ETag forte ("3"). O cliente a devolve em If-Match ao alterar ou
+// excluir o registro, e recebe 412 se outra pessoa o alterou nesse meio tempo.
+//
+// Listas recebem uma ETag fraca calculada sobre o corpo da resposta, e uma
+// requisição com a mesma ETag em If-None-Match recebe 304 sem corpo.
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+)
+
+// Format retorna a ETag da versão de um registro
+func Format(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// Set envia a versão do registro no cabeçalho ETag
+func Set(c *gin.Context, version int64) {
+	if version > 0 {
+		c.Header("ETag", Format(version))
+	}
+}
+
+// IfMatch retorna a versão exigida pelo cliente em If-Match, ou 0 quando o
+// cabeçalho está ausente ou é "*", casos em que a gravação não é condicional.
+// Uma ETag que não é de versão, como a fraca das listas, nunca coincide.
+func IfMatch(c *gin.Context) (int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) {
+		return 0, apierror.New(apierror.PreconditionFailed)
+	}
+	return version, nil
+}
+
+// JSON responde com a lista em body e sua ETag, ou com 304 se o cliente já tem
+// essa mesma lista
+func JSON(c *gin.Context, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	tag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", tag)
+
+	if noneMatch(c.GetHeader("If-None-Match"), tag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// noneMatch compara as ETags de If-None-Match com a comparação fraca da RFC 9110,
+// que ignora o prefixo W/
+func noneMatch(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
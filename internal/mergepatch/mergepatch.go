@@ -0,0 +1,103 @@
+// Package mergepatch aplica documentos JSON Merge Patch (RFC 7396), com que as
+// rotas PATCH recebem apenas os campos alterados:
+//
+//	{"reward": 5}           altera só a recompensa
+//	{"recurrence": null}    remove o campo, que volta ao valor vazio
+//
+// Objetos aninhados são mesclados campo a campo; qualquer outro valor, inclusive
+// listas, substitui o anterior.
+//
+// O patch é aplicado ao registro lido pela rota, e a gravação leva a versão
+// desse registro. Mesmo sem If-Match, um registro alterado entre a leitura e a
+// gravação faz a requisição falhar com 412 em vez de perder a outra alteração.
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
+// ContentType é o tipo de mídia dos documentos de merge patch
+const ContentType = "application/merge-patch+json"
+
+// Apply aplica patch ao documento target, ambos em JSON
+func Apply(target []byte, patch []byte) ([]byte, error) {
+	targetDoc, err := decode(target)
+	if err != nil {
+		return nil, err
+	}
+	patchDoc, err := decode(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(merge(targetDoc, patchDoc))
+}
+
+// decode preserva os números como escritos, sem passar por float64
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func merge(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// Bind aplica o patch do corpo da requisição a current e grava o resultado em v,
+// normalizado e validado. Como request.Bind, em caso de erro já responde ao
+// cliente e retorna false. Os campos removidos pelo patch ficam com o valor que
+// tinham em v, que deve ser o zero ou o padrão do modelo, nunca current.
+func Bind(c *gin.Context, current interface{}, v interface{}) bool {
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		apierror.Respond(c, apierror.New(apierror.InvalidBody))
+		return false
+	}
+
+	target, err := json.Marshal(current)
+	if err != nil {
+		apierror.Respond(c, err)
+		return false
+	}
+
+	merged, err := Apply(target, patch)
+	if err != nil {
+		apierror.Respond(c, apierror.Binding(err))
+		return false
+	}
+
+	if err := json.Unmarshal(merged, v); err != nil {
+		apierror.Respond(c, apierror.Binding(err))
+		return false
+	}
+	return request.Validate(c, v)
+}
@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/achievement"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+)
+
+type progressRow struct {
+	Completions []struct {
+		Task string     `neo4j:"task,optional"`
+		At   *time.Time `neo4j:"at"`
+	} `neo4j:"completions"`
+	Rank   int64    `neo4j:"rank"`
+	Earned []string `neo4j:"earned"`
+}
+
+type achievementRow struct {
+	ID          string    `neo4j:"id"`
+	Name        string    `neo4j:"name,optional"`
+	Description string    `neo4j:"description,optional"`
+	EarnedAt    time.Time `neo4j:"earnedAt,optional"`
+}
+
+type AchievementRepository struct {
+	base
+}
+
+func NewAchievementRepository(db *database.DatabaseHandler) *AchievementRepository {
+	return &AchievementRepository{base{db}}
+}
+
+func (r *AchievementRepository) Progress(ctx context.Context, email string) ([]achievement.Completion, int64, map[string]bool, error) {
+	result, err := r.read(ctx,
+		`MATCH (u:User {email: $email})
+		OPTIONAL MATCH (u)-[c:COMPLETED]->(t:Task)
+		WITH u, collect({task: t.name, at: c.at}) as completions
+		OPTIONAL MATCH (u)-[:LIVES_IN]->(:Home)<-[:LIVES_IN]-(other:User)
+		WHERE coalesce(other.score, 0) > coalesce(u.score, 0)
+		WITH u, completions, count(DISTINCT other) + 1 as rank
+		RETURN completions, rank, [(u)-[:EARNED]->(a:Achievement) | a.id] as earned;`,
+		map[string]interface{}{"email": email},
+	)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	if len(result.Records) == 0 {
+		return nil, 0, nil, repository.ErrNotFound
+	}
+
+	row, err := record.Decode[progressRow](result.Records[0])
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	// Sem conclusões, o collect do OPTIONAL MATCH traz um único item nulo
+	var completions []achievement.Completion
+	for _, completion := range row.Completions {
+		if completion.At != nil {
+			completions = append(completions, achievement.Completion{Task: completion.Task, At: *completion.At})
+		}
+	}
+
+	earned := map[string]bool{}
+	for _, id := range row.Earned {
+		earned[id] = true
+	}
+
+	return completions, row.Rank, earned, nil
+}
+
+func (r *AchievementRepository) Save(ctx context.Context, email string, achievements []achievement.Achievement) error {
+	var params []interface{}
+	for _, a := range achievements {
+		params = append(params, map[string]interface{}{
+			"id":          a.ID,
+			"name":        a.Name,
+			"description": a.Description,
+			"at":          a.EarnedAt,
+		})
+	}
+
+	_, err := r.execute(ctx,
+		`MATCH (u:User {email: $email})
+		UNWIND $achievements as achievement
+		MERGE (a:Achievement {id: achievement.id})
+		SET a.name = achievement.name,
+			a.description = achievement.description
+		MERGE (u)-[e:EARNED]->(a)
+		ON CREATE SET e.at = achievement.at;`,
+		map[string]interface{}{
+			"email":        email,
+			"achievements": params,
+		},
+	)
+	return err
+}
+
+func (r *AchievementRepository) FindByUser(ctx context.Context, email string) ([]achievement.Achievement, error) {
+	result, err := r.read(ctx,
+		`MATCH (u:User {email: $email})-[e:EARNED]->(a:Achievement)
+		RETURN a.id as id, a.name as name, a.description as description, e.at as earnedAt
+		ORDER BY e.at;`,
+		map[string]interface{}{"email": email},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[achievementRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var achievements []achievement.Achievement
+	for _, row := range rows {
+		achievements = append(achievements, achievement.Achievement(row))
+	}
+
+	return achievements, nil
+}
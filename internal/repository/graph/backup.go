@@ -0,0 +1,712 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/achievement"
+	"github.com/nsbnroque/go-to-do-list/backup"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+	"github.com/nsbnroque/go-to-do-list/reward"
+	"github.com/nsbnroque/go-to-do-list/score"
+	"github.com/nsbnroque/go-to-do-list/task"
+)
+
+type BackupRepository struct {
+	base
+}
+
+func NewBackupRepository(db *database.DatabaseHandler) *BackupRepository {
+	return &BackupRepository{base{db}}
+}
+
+type backupUserRow struct {
+	Email           string     `neo4j:"email"`
+	Name            string     `neo4j:"name,optional"`
+	Password        string     `neo4j:"password,optional"`
+	Score           int64      `neo4j:"score"`
+	DailyStreak     int64      `neo4j:"dailyStreak"`
+	WeeklyStreak    int64      `neo4j:"weeklyStreak"`
+	LastCompletedAt *time.Time `neo4j:"lastCompletedAt"`
+}
+
+type backupHomeRow struct {
+	ID        uuid.UUID              `neo4j:"id"`
+	Name      string                 `neo4j:"name,optional"`
+	Props     map[string]interface{} `neo4j:"props"`
+	DeletedAt *time.Time             `neo4j:"deletedAt"`
+	DeletedBy string                 `neo4j:"deletedBy,optional"`
+	Residents []struct {
+		Email string `neo4j:"email"`
+		Role  string `neo4j:"role,optional"`
+	} `neo4j:"residents"`
+	Tasks []struct {
+		Name             string     `neo4j:"name"`
+		Reward           int64      `neo4j:"reward"`
+		Recurrence       string     `neo4j:"recurrence,optional"`
+		Status           string     `neo4j:"status,optional"`
+		Streak           int64      `neo4j:"streak"`
+		LastCompletedAt  *time.Time `neo4j:"last_completed_at"`
+		PendingSince     *time.Time `neo4j:"pending_since"`
+		Assignee         string     `neo4j:"assignee,optional"`
+		DueAt            *time.Time `neo4j:"due_at"`
+		OverduePenalized bool       `neo4j:"overdue_penalized"`
+		DeletedAt        *time.Time `neo4j:"deleted_at"`
+		DeletedBy        string     `neo4j:"deleted_by,optional"`
+	} `neo4j:"tasks"`
+}
+
+type backupRewardRow struct {
+	ID    uuid.UUID `neo4j:"id"`
+	Home  string    `neo4j:"home"`
+	Name  string    `neo4j:"name"`
+	Cost  int64     `neo4j:"cost"`
+	Stock int64     `neo4j:"stock"`
+}
+
+type backupRedemptionRow struct {
+	ID          uuid.UUID  `neo4j:"id"`
+	Reward      string     `neo4j:"reward"`
+	User        string     `neo4j:"user"`
+	Cost        int64      `neo4j:"cost"`
+	RedeemedAt  time.Time  `neo4j:"redeemedAt"`
+	Fulfilled   bool       `neo4j:"fulfilled"`
+	FulfilledAt *time.Time `neo4j:"fulfilledAt"`
+}
+
+type backupCompletionRow struct {
+	User   string    `neo4j:"user"`
+	Home   string    `neo4j:"home,optional"`
+	Task   string    `neo4j:"task"`
+	At     time.Time `neo4j:"at"`
+	Reward int64     `neo4j:"reward"`
+	Bonus  int64     `neo4j:"bonus"`
+}
+
+type backupAchievementRow struct {
+	User        string    `neo4j:"user"`
+	ID          string    `neo4j:"id"`
+	Name        string    `neo4j:"name,optional"`
+	Description string    `neo4j:"description,optional"`
+	EarnedAt    time.Time `neo4j:"earnedAt"`
+}
+
+type backupEntryRow struct {
+	ID          uuid.UUID  `neo4j:"id"`
+	User        string     `neo4j:"user"`
+	Home        string     `neo4j:"home"`
+	Kind        string     `neo4j:"kind"`
+	Reason      string     `neo4j:"reason"`
+	Task        string     `neo4j:"task,optional"`
+	Amount      int64      `neo4j:"amount"`
+	At          time.Time  `neo4j:"at"`
+	Waived      bool       `neo4j:"waived"`
+	WaivedBy    string     `neo4j:"waivedBy,optional"`
+	WaiveReason string     `neo4j:"waiveReason,optional"`
+	WaivedAt    *time.Time `neo4j:"waivedAt"`
+}
+
+// Export lê tudo numa única transação, para que o arquivo seja consistente
+func (r *BackupRepository) Export(ctx context.Context) (backup.Archive, error) {
+	var archive backup.Archive
+
+	err := r.db.ReadUnitOfWork(ctx, func(tx database.Tx) error {
+		archive = backup.Archive{}
+
+		result, err := tx.Run(ctx,
+			`MATCH (u:User)
+			RETURN u.email as email, u.name as name, u.password as password,
+				coalesce(u.score, 0) as score,
+				coalesce(u.daily_streak, 0) as dailyStreak,
+				coalesce(u.weekly_streak, 0) as weeklyStreak,
+				u.last_completed_at as lastCompletedAt
+			ORDER BY email;`,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		users, err := record.DecodeAll[backupUserRow](result.Records)
+		if err != nil {
+			return err
+		}
+		for _, row := range users {
+			archive.Users = append(archive.Users, backup.User(row))
+		}
+
+		// Tarefas ativas e da lixeira vêm juntas, diferenciadas por deleted_at
+		result, err = tx.Run(ctx,
+			`MATCH (h) WHERE h:Home OR h:DeletedHome
+			RETURN h.id as id, h.name as name, properties(h) as props,
+				h.deleted_at as deletedAt, h.deleted_by as deletedBy,
+				[(u:User)-[l:LIVES_IN]->(h) | {email: u.email, role: l.role}] as residents,
+				[(h)-[d:HAS_TASK|DELETED_TASK]->(t:Task) | {
+					name: t.name,
+					reward: coalesce(t.reward, 0),
+					recurrence: t.recurrence,
+					status: d.status,
+					streak: coalesce(d.streak, 0),
+					last_completed_at: d.last_completed_at,
+					pending_since: d.pending_since,
+					assignee: d.assignee,
+					due_at: d.due_at,
+					overdue_penalized: coalesce(d.overdue_penalized, false),
+					deleted_at: d.deleted_at,
+					deleted_by: d.deleted_by
+				}] as tasks
+			ORDER BY id;`,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		homes, err := record.DecodeAll[backupHomeRow](result.Records)
+		if err != nil {
+			return err
+		}
+		for _, row := range homes {
+			homeData := backup.Home{
+				ID:        row.ID,
+				Name:      row.Name,
+				Settings:  home.SettingsFromProps(row.Props),
+				DeletedAt: row.DeletedAt,
+				DeletedBy: row.DeletedBy,
+			}
+			for _, resident := range row.Residents {
+				homeData.Residents = append(homeData.Residents, backup.Resident{
+					Email: resident.Email,
+					Role:  home.Role(resident.Role),
+				})
+			}
+			for _, t := range row.Tasks {
+				homeData.Tasks = append(homeData.Tasks, backup.Task{
+					Name:             t.Name,
+					Reward:           t.Reward,
+					Recurrence:       task.Recurrence(t.Recurrence),
+					Status:           task.Status(t.Status),
+					Streak:           t.Streak,
+					LastCompletedAt:  t.LastCompletedAt,
+					PendingSince:     t.PendingSince,
+					Assignee:         t.Assignee,
+					DueAt:            t.DueAt,
+					OverduePenalized: t.OverduePenalized,
+					DeletedAt:        t.DeletedAt,
+					DeletedBy:        t.DeletedBy,
+				})
+			}
+			archive.Homes = append(archive.Homes, homeData)
+		}
+
+		result, err = tx.Run(ctx,
+			`MATCH (h)-[:OFFERS]->(r:Reward)
+			RETURN r.id as id, h.id as home, r.name as name, r.cost as cost, r.stock as stock
+			ORDER BY id;`,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		rewards, err := record.DecodeAll[backupRewardRow](result.Records)
+		if err != nil {
+			return err
+		}
+		for _, row := range rewards {
+			archive.Rewards = append(archive.Rewards, backup.Reward{
+				Reward: reward.Reward{ID: row.ID, Name: row.Name, Cost: row.Cost, Stock: row.Stock},
+				Home:   row.Home,
+			})
+		}
+
+		result, err = tx.Run(ctx,
+			`MATCH (u:User)-[:REDEEMED]->(rd:Redemption)-[:OF]->(r:Reward)
+			RETURN rd.id as id, r.id as reward, u.email as user, rd.cost as cost,
+				rd.redeemed_at as redeemedAt, coalesce(rd.fulfilled, false) as fulfilled,
+				rd.fulfilled_at as fulfilledAt
+			ORDER BY redeemedAt, id;`,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		redemptions, err := record.DecodeAll[backupRedemptionRow](result.Records)
+		if err != nil {
+			return err
+		}
+		for _, row := range redemptions {
+			archive.Redemptions = append(archive.Redemptions, backup.Redemption(row))
+		}
+
+		result, err = tx.Run(ctx,
+			`MATCH (u:User)-[c:COMPLETED]->(t:Task)
+			RETURN u.email as user, t.home as home, t.name as task, c.at as at,
+				coalesce(c.reward, 0) as reward, coalesce(c.bonus, 0) as bonus
+			ORDER BY at;`,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		completions, err := record.DecodeAll[backupCompletionRow](result.Records)
+		if err != nil {
+			return err
+		}
+		for _, row := range completions {
+			archive.Completions = append(archive.Completions, backup.Completion(row))
+		}
+
+		result, err = tx.Run(ctx,
+			`MATCH (u:User)-[e:EARNED]->(a:Achievement)
+			RETURN u.email as user, a.id as id, a.name as name, a.description as description, e.at as earnedAt
+			ORDER BY user, earnedAt;`,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		achievements, err := record.DecodeAll[backupAchievementRow](result.Records)
+		if err != nil {
+			return err
+		}
+		for _, row := range achievements {
+			archive.Achievements = append(archive.Achievements, backup.Achievement{
+				Achievement: achievement.Achievement{
+					ID:          row.ID,
+					Name:        row.Name,
+					Description: row.Description,
+					EarnedAt:    row.EarnedAt,
+				},
+				User: row.User,
+			})
+		}
+
+		result, err = tx.Run(ctx,
+			`MATCH (u:User)-[:HAS_SCORE_ENTRY]->(e:ScoreEntry)
+			RETURN e.id as id, u.email as user, e.home as home, e.kind as kind, e.reason as reason,
+				e.task as task, e.amount as amount, e.at as at, coalesce(e.waived, false) as waived,
+				e.waived_by as waivedBy, e.waive_reason as waiveReason, e.waived_at as waivedAt
+			ORDER BY at, id;`,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		entries, err := record.DecodeAll[backupEntryRow](result.Records)
+		if err != nil {
+			return err
+		}
+		for _, row := range entries {
+			archive.ScoreEntries = append(archive.ScoreEntries, backup.ScoreEntry{
+				Entry: score.Entry{
+					ID:          row.ID,
+					Kind:        score.Kind(row.Kind),
+					Reason:      score.Reason(row.Reason),
+					Task:        row.Task,
+					Amount:      row.Amount,
+					At:          row.At,
+					Waived:      row.Waived,
+					WaivedBy:    row.WaivedBy,
+					WaiveReason: row.WaiveReason,
+				},
+				User:     row.User,
+				Home:     row.Home,
+				WaivedAt: row.WaivedAt,
+			})
+		}
+
+		result, err = tx.Run(ctx,
+			`MATCH (home)-[:HAS_WEBHOOK]->(w:Webhook)
+			RETURN `+webhookFields+`
+			ORDER BY id;`,
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		webhooks, err := record.DecodeAll[webhookRow](result.Records)
+		if err != nil {
+			return err
+		}
+		for _, row := range webhooks {
+			archive.Webhooks = append(archive.Webhooks, backup.Webhook(row))
+		}
+
+		return nil
+	})
+
+	return archive, err
+}
+
+// Import regrava cada nó e relação com MERGE pelo identificador, então
+// importar o mesmo arquivo de novo não duplica nada
+func (r *BackupRepository) Import(ctx context.Context, archive backup.Archive) error {
+	var users []map[string]interface{}
+	for _, u := range archive.Users {
+		users = append(users, map[string]interface{}{
+			"email":             u.Email,
+			"name":              u.Name,
+			"password":          u.Password,
+			"score":             u.Score,
+			"daily_streak":      u.DailyStreak,
+			"weekly_streak":     u.WeeklyStreak,
+			"last_completed_at": optionalTime(u.LastCompletedAt),
+		})
+	}
+
+	var activeHomes, deletedHomes, residents, activeTasks, deletedTasks []map[string]interface{}
+	for _, h := range archive.Homes {
+		id := h.ID.String()
+		settings := h.Settings
+
+		props := map[string]interface{}{
+			"id":               id,
+			"name":             h.Name,
+			"grace_days":       settings.GraceDays,
+			"streak_bonus":     settings.StreakBonus,
+			"streak_bonus_cap": settings.StreakBonusCap,
+			"pricing_mode":     settings.Pricing.Mode,
+			"pricing_curve":    settings.Pricing.Curve,
+			"pricing_rate":     settings.Pricing.Rate,
+			"pricing_cap":      settings.Pricing.Cap,
+			"penalty_overdue":  settings.Penalties.Overdue,
+			"penalty_rejected": settings.Penalties.Rejected,
+			"deleted_at":       optionalTime(h.DeletedAt),
+			"deleted_by":       nullable(h.DeletedBy),
+		}
+		if h.DeletedAt == nil {
+			activeHomes = append(activeHomes, props)
+		} else {
+			deletedHomes = append(deletedHomes, props)
+		}
+
+		for _, resident := range h.Residents {
+			residents = append(residents, map[string]interface{}{
+				"home":  id,
+				"email": resident.Email,
+				"role":  string(resident.Role),
+			})
+		}
+
+		for _, t := range h.Tasks {
+			taskData := map[string]interface{}{
+				"home":       id,
+				"name":       t.Name,
+				"reward":     t.Reward,
+				"recurrence": nullableRecurrence(t.Recurrence),
+				"props": map[string]interface{}{
+					"status":            nullable(string(t.Status)),
+					"streak":            t.Streak,
+					"last_completed_at": optionalTime(t.LastCompletedAt),
+					"pending_since":     optionalTime(t.PendingSince),
+					"assignee":          nullable(t.Assignee),
+					"due_at":            optionalTime(t.DueAt),
+					"overdue_penalized": t.OverduePenalized,
+					"deleted_at":        optionalTime(t.DeletedAt),
+					"deleted_by":        nullable(t.DeletedBy),
+				},
+			}
+			if t.DeletedAt == nil {
+				activeTasks = append(activeTasks, taskData)
+			} else {
+				deletedTasks = append(deletedTasks, taskData)
+			}
+		}
+	}
+
+	var rewards []map[string]interface{}
+	for _, rw := range archive.Rewards {
+		rewards = append(rewards, map[string]interface{}{
+			"home": rw.Home,
+			"props": map[string]interface{}{
+				"id":    rw.ID.String(),
+				"name":  rw.Name,
+				"cost":  rw.Cost,
+				"stock": rw.Stock,
+			},
+		})
+	}
+
+	var redemptions []map[string]interface{}
+	for _, rd := range archive.Redemptions {
+		redemptions = append(redemptions, map[string]interface{}{
+			"user":   rd.User,
+			"reward": rd.Reward,
+			"props": map[string]interface{}{
+				"id":           rd.ID.String(),
+				"cost":         rd.Cost,
+				"redeemed_at":  rd.RedeemedAt,
+				"fulfilled":    rd.Fulfilled,
+				"fulfilled_at": optionalTime(rd.FulfilledAt),
+			},
+		})
+	}
+
+	// A casa da conclusão não é gravada no grafo, só a tarefa
+	var completions []map[string]interface{}
+	for _, c := range archive.Completions {
+		completions = append(completions, map[string]interface{}{
+			"user":   c.User,
+			"home":   c.Home,
+			"task":   c.Task,
+			"at":     c.At,
+			"reward": c.Reward,
+			"bonus":  c.Bonus,
+		})
+	}
+
+	var achievements []map[string]interface{}
+	for _, a := range archive.Achievements {
+		achievements = append(achievements, map[string]interface{}{
+			"user":        a.User,
+			"id":          a.ID,
+			"name":        a.Name,
+			"description": a.Description,
+			"at":          a.EarnedAt,
+		})
+	}
+
+	var entries []map[string]interface{}
+	for _, e := range archive.ScoreEntries {
+		entries = append(entries, map[string]interface{}{
+			"user": e.User,
+			"props": map[string]interface{}{
+				"id":           e.ID.String(),
+				"home":         e.Home,
+				"kind":         string(e.Kind),
+				"reason":       string(e.Reason),
+				"task":         e.Task,
+				"amount":       e.Amount,
+				"at":           e.At,
+				"waived":       e.Waived,
+				"waived_by":    nullable(e.WaivedBy),
+				"waive_reason": nullable(e.WaiveReason),
+				"waived_at":    optionalTime(e.WaivedAt),
+			},
+		})
+	}
+
+	var webhooks []map[string]interface{}
+	for _, hook := range archive.Webhooks {
+		eventTypes := make([]string, 0, len(hook.Events))
+		for _, eventType := range hook.Events {
+			eventTypes = append(eventTypes, string(eventType))
+		}
+
+		webhooks = append(webhooks, map[string]interface{}{
+			"home": hook.Home,
+			"props": map[string]interface{}{
+				"id":         hook.ID.String(),
+				"url":        hook.URL,
+				"events":     eventTypes,
+				"secret":     hook.Secret,
+				"created_by": hook.CreatedBy,
+				"created_at": hook.CreatedAt,
+			},
+		})
+	}
+
+	// As versões não vão para o backup; a restauração conta como uma gravação
+	// a mais, para que ETags anteriores a ela deixem de valer
+	return r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		_, err := tx.Run(ctx,
+			`UNWIND $users as user
+			MERGE (u:User {email: user.email})
+			WITH u, user, coalesce(u.version, 1) + 1 as version
+			SET u = user,
+				u.version = version;`,
+			map[string]interface{}{
+				"users": users,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		// Casas podem ter mudado de situação desde o backup, então o rótulo é trocado antes do MERGE
+		for _, homes := range []struct {
+			label, other string
+			props        []map[string]interface{}
+		}{
+			{"Home", "DeletedHome", activeHomes},
+			{"DeletedHome", "Home", deletedHomes},
+		} {
+			_, err = tx.Run(ctx,
+				fmt.Sprintf(`UNWIND $homes as home
+				OPTIONAL MATCH (old:%[2]s {id: home.id})
+				REMOVE old:%[2]s
+				SET old:%[1]s
+				WITH home
+				MERGE (h:%[1]s {id: home.id})
+				WITH h, home, coalesce(h.version, 1) + 1 as version
+				SET h = home,
+					h.version = version;`, homes.label, homes.other),
+				map[string]interface{}{
+					"homes": homes.props,
+				},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Run(ctx,
+			`UNWIND $residents as resident
+			MATCH (h {id: resident.home}) WHERE h:Home OR h:DeletedHome
+			MATCH (u:User {email: resident.email})
+			MERGE (u)-[l:LIVES_IN]->(h)
+			SET l.role = resident.role;`,
+			map[string]interface{}{
+				"residents": residents,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		// Uma tarefa fica ativa ou na lixeira, nunca nas duas
+		for _, tasks := range []struct {
+			relationship, other string
+			tasks               []map[string]interface{}
+		}{
+			{"HAS_TASK", "DELETED_TASK", activeTasks},
+			{"DELETED_TASK", "HAS_TASK", deletedTasks},
+		} {
+			_, err = tx.Run(ctx,
+				fmt.Sprintf(`UNWIND $tasks as task
+				MATCH (h {id: task.home}) WHERE h:Home OR h:DeletedHome
+				MERGE (t:Task {home: task.home, name: task.name})
+				SET t.reward = task.reward,
+					t.recurrence = task.recurrence
+				WITH h, t, task
+				OPTIONAL MATCH (h)-[old:%[2]s]->(t)
+				WITH h, t, task, old, old.version as version
+				DELETE old
+				WITH h, t, task, version
+				MERGE (h)-[r:%[1]s]->(t)
+				WITH r, task, coalesce(r.version, version, 1) + 1 as version
+				SET r = task.props,
+					r.version = version;`, tasks.relationship, tasks.other),
+				map[string]interface{}{
+					"tasks": tasks.tasks,
+				},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Run(ctx,
+			`UNWIND $rewards as reward
+			MATCH (h {id: reward.home}) WHERE h:Home OR h:DeletedHome
+			MERGE (r:Reward {id: reward.props.id})
+			SET r = reward.props
+			MERGE (h)-[:OFFERS]->(r);`,
+			map[string]interface{}{
+				"rewards": rewards,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Run(ctx,
+			`UNWIND $redemptions as redemption
+			MATCH (u:User {email: redemption.user})
+			MATCH (r:Reward {id: redemption.reward})
+			MERGE (rd:Redemption {id: redemption.props.id})
+			SET rd = redemption.props
+			MERGE (u)-[:REDEEMED]->(rd)
+			MERGE (rd)-[:OF]->(r);`,
+			map[string]interface{}{
+				"redemptions": redemptions,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		// Sem a casa da conclusão, vale a tarefa de mesmo nome numa casa do usuário
+		_, err = tx.Run(ctx,
+			`UNWIND $completions as completion
+			MATCH (u:User {email: completion.user})
+			CALL {
+				WITH u, completion
+				MATCH (h)-[:HAS_TASK|DELETED_TASK]->(t:Task {name: completion.task})
+				WHERE h.id = completion.home OR (completion.home = '' AND EXISTS { (u)-[:LIVES_IN]->(h) })
+				RETURN t
+				LIMIT 1
+			}
+			MERGE (u)-[c:COMPLETED {at: completion.at}]->(t)
+			SET c.reward = completion.reward,
+				c.bonus = completion.bonus;`,
+			map[string]interface{}{
+				"completions": completions,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Run(ctx,
+			`UNWIND $achievements as achievement
+			MATCH (u:User {email: achievement.user})
+			MERGE (a:Achievement {id: achievement.id})
+			SET a.name = achievement.name,
+				a.description = achievement.description
+			MERGE (u)-[e:EARNED]->(a)
+			SET e.at = achievement.at;`,
+			map[string]interface{}{
+				"achievements": achievements,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Run(ctx,
+			`UNWIND $entries as entry
+			MATCH (u:User {email: entry.user})
+			MERGE (e:ScoreEntry {id: entry.props.id})
+			SET e = entry.props
+			MERGE (u)-[:HAS_SCORE_ENTRY]->(e);`,
+			map[string]interface{}{
+				"entries": entries,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		// As entregas são nós próprios, e SET w = ... não as desliga do webhook
+		_, err = tx.Run(ctx,
+			`UNWIND $webhooks as webhook
+			MATCH (h {id: webhook.home}) WHERE h:Home OR h:DeletedHome
+			MERGE (w:Webhook {id: webhook.props.id})
+			SET w = webhook.props
+			MERGE (h)-[:HAS_WEBHOOK]->(w);`,
+			map[string]interface{}{
+				"webhooks": webhooks,
+			},
+		)
+		return err
+	})
+}
+
+// optionalTime grava horários ausentes como null
+func optionalTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
@@ -0,0 +1,474 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/score"
+	"github.com/nsbnroque/go-to-do-list/streak"
+	syncchannel "github.com/nsbnroque/go-to-do-list/sync_channel"
+	"github.com/nsbnroque/go-to-do-list/task"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+type stateRow struct {
+	Home         neo4j.Node      `neo4j:"home"`
+	Reward       int64           `neo4j:"reward"`
+	Recurrence   task.Recurrence `neo4j:"recurrence,optional"`
+	ChoreStreak  int64           `neo4j:"choreStreak"`
+	ChoreLastAt  time.Time       `neo4j:"choreLastAt,optional"`
+	PendingSince time.Time       `neo4j:"pendingSince,optional"`
+	DailyStreak  int64           `neo4j:"dailyStreak"`
+	WeeklyStreak int64           `neo4j:"weeklyStreak"`
+	LastAt       time.Time       `neo4j:"lastAt,optional"`
+}
+
+type choreRow struct {
+	Home       neo4j.Node      `neo4j:"home"`
+	Task       string          `neo4j:"task"`
+	Recurrence task.Recurrence `neo4j:"recurrence"`
+	Status     task.Status     `neo4j:"status,optional"`
+	Streak     int64           `neo4j:"streak"`
+	LastAt     time.Time       `neo4j:"lastAt"`
+}
+
+type residentStreakRow struct {
+	Email        string       `neo4j:"email"`
+	DailyStreak  int64        `neo4j:"dailyStreak"`
+	WeeklyStreak int64        `neo4j:"weeklyStreak"`
+	LastAt       time.Time    `neo4j:"lastAt"`
+	Homes        []neo4j.Node `neo4j:"homes"`
+}
+
+// penaltyRow identifica o morador a ser penalizado por uma tarefa e o valor da penalidade
+type penaltyRow struct {
+	Home     string `neo4j:"home"`
+	Task     string `neo4j:"task,optional"`
+	Resident string `neo4j:"resident"`
+	Amount   int64  `neo4j:"amount"`
+}
+
+type CompletionRepository struct {
+	base
+}
+
+func NewCompletionRepository(db *database.DatabaseHandler) *CompletionRepository {
+	return &CompletionRepository{base{db}}
+}
+
+func (r *CompletionRepository) Complete(ctx context.Context, email string, homeID string, taskName string, complete func(syncchannel.CompletionState) syncchannel.Completion) (user.User, error) {
+	completed := user.User{Email: email}
+
+	// A leitura do estado acontece na transação de escrita, que vai sempre ao
+	// líder do cluster, e não numa leitura que poderia cair num seguidor atrasado
+	err := r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		result, err := tx.Run(ctx,
+			`MATCH (u:User {email: $email})-[:LIVES_IN]->(h:Home)-[r:HAS_TASK]->(t:Task {name: $name})
+			WHERE `+inHome("h")+` AND coalesce(r.status, $pending) <> $finished
+			RETURN h as home, coalesce(t.reward, 0) as reward, t.recurrence as recurrence,
+				coalesce(r.streak, 0) as choreStreak, r.last_completed_at as choreLastAt, r.pending_since as pendingSince,
+				coalesce(u.daily_streak, 0) as dailyStreak, coalesce(u.weekly_streak, 0) as weeklyStreak,
+				u.last_completed_at as lastAt
+			LIMIT 1;`,
+			map[string]interface{}{
+				"email":    email,
+				"home":     homeID,
+				"name":     taskName,
+				"pending":  task.Pending,
+				"finished": task.Finished,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Records) == 0 {
+			return repository.ErrNotFound
+		}
+
+		state, err := record.Decode[stateRow](result.Records[0])
+		if err != nil {
+			return err
+		}
+
+		completion := complete(syncchannel.CompletionState{
+			Settings:     home.SettingsFromProps(state.Home.Props),
+			Reward:       state.Reward,
+			Recurrence:   state.Recurrence,
+			PendingSince: state.PendingSince,
+			Chore:        streak.Streak{Current: state.ChoreStreak, LastAt: state.ChoreLastAt},
+			Daily:        streak.Streak{Current: state.DailyStreak, LastAt: state.LastAt},
+			Weekly:       streak.Streak{Current: state.WeeklyStreak, LastAt: state.LastAt},
+		})
+		completed.DailyStreak = completion.Daily
+		completed.WeeklyStreak = completion.Weekly
+
+		var choreStreak interface{}
+		if completion.ChoreStreak != nil {
+			choreStreak = *completion.ChoreStreak
+		}
+
+		// Outra transação pode ter concluído a tarefa depois da leitura do estado
+		result, err = tx.Run(ctx,
+			`MATCH (h:Home {id: $home})-[r:HAS_TASK]->(t:Task {name: $name})
+			WHERE coalesce(r.status, $pending) <> $finished
+			SET r.status = $finished,
+				r.streak = $choreStreak,
+				r.last_completed_at = $at,
+				r.pending_since = null,
+				r.version = coalesce(r.version, 1) + 1
+			RETURN r;`,
+			map[string]interface{}{
+				"home":        state.Home.Props["id"],
+				"name":        taskName,
+				"pending":     task.Pending,
+				"finished":    task.Finished,
+				"choreStreak": choreStreak,
+				"at":          completion.At,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Records) == 0 {
+			return repository.ErrConflict
+		}
+
+		result, err = tx.Run(ctx,
+			`MATCH (u:User {email: $email})
+			SET u.score = coalesce(u.score, 0) + $reward + $bonus,
+				u.daily_streak = $dailyStreak,
+				u.weekly_streak = $weeklyStreak,
+				u.last_completed_at = $at,
+				u.version = coalesce(u.version, 1) + 1
+			RETURN u.name as userName, u.score as score, u.version as version;`,
+			map[string]interface{}{
+				"email":        email,
+				"reward":       completion.Reward,
+				"bonus":        completion.Bonus,
+				"dailyStreak":  completion.Daily,
+				"weeklyStreak": completion.Weekly,
+				"at":           completion.At,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Records) == 0 {
+			return repository.ErrNotFound
+		}
+
+		row, err := record.Decode[struct {
+			Name    string `neo4j:"userName,optional"`
+			Score   int64  `neo4j:"score"`
+			Version int64  `neo4j:"version"`
+		}](result.Records[0])
+		if err != nil {
+			return err
+		}
+		completed.Name = row.Name
+		completed.Score = row.Score
+		completed.Version = row.Version
+
+		_, err = tx.Run(ctx,
+			`MATCH (u:User {email: $email})
+			MATCH (t:Task {home: $home, name: $name})
+			CREATE (u)-[:COMPLETED {at: $at, reward: $reward, bonus: $bonus}]->(t);`,
+			map[string]interface{}{
+				"email":  email,
+				"home":   state.Home.Props["id"],
+				"name":   taskName,
+				"at":     completion.At,
+				"reward": completion.Reward,
+				"bonus":  completion.Bonus,
+			},
+		)
+		return err
+	})
+	if err != nil {
+		return user.User{}, err
+	}
+
+	return completed, nil
+}
+
+func (r *CompletionRepository) Assign(ctx context.Context, adminEmail string, homeID string, taskName string, assignment task.Assignment) (task.Task, error) {
+	result, err := r.execute(ctx,
+		`MATCH (a:User {email: $email})-[:LIVES_IN {role: $role}]->(h:Home)-[r:HAS_TASK]->(t:Task {name: $name})
+		WHERE `+inHome("h")+`
+		MATCH (assignee:User {email: $assignee})-[:LIVES_IN]->(h)
+		SET r.assignee = $assignee,
+			r.due_at = $dueAt,
+			r.overdue_penalized = false,
+			r.version = coalesce(r.version, 1) + 1
+		RETURN t.name as name, coalesce(t.reward, 0) as reward, r.status as status, r.version as version;`,
+		map[string]interface{}{
+			"email":    adminEmail,
+			"role":     home.Admin,
+			"home":     homeID,
+			"name":     taskName,
+			"assignee": assignment.Assignee,
+			"dueAt":    assignment.DueAt,
+		},
+	)
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	if len(result.Records) == 0 {
+		return task.Task{}, repository.ErrNotFound
+	}
+
+	row, err := record.Decode[taskRow](result.Records[0])
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	assigned := task.Task{
+		Name:     taskName,
+		Reward:   row.Reward,
+		Status:   row.Status,
+		Version:  row.Version,
+		Assignee: assignment.Assignee,
+		DueAt:    &assignment.DueAt,
+	}
+
+	return assigned, nil
+}
+
+func (r *CompletionRepository) Reject(ctx context.Context, adminEmail string, homeID string, taskName string, penalty score.Entry) (string, int64, error) {
+	var row penaltyRow
+	err := r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		// Reabre a tarefa e identifica o último morador da casa que a concluiu
+		result, err := tx.Run(ctx,
+			`MATCH (a:User {email: $email})-[:LIVES_IN {role: $role}]->(h:Home)-[r:HAS_TASK]->(t:Task {name: $name})
+			WHERE `+inHome("h")+` AND r.status = $finished
+			MATCH (resident:User)-[:LIVES_IN]->(h)
+			MATCH (resident)-[c:COMPLETED]->(t)
+			WITH h, r, t, resident, c
+			ORDER BY c.at DESC
+			LIMIT 1
+			SET r.status = $pending,
+				r.pending_since = $now,
+				r.version = coalesce(r.version, 1) + 1
+			RETURN h.id as home, resident.email as resident, coalesce(h.penalty_rejected, $defaultPenalty) as amount;`,
+			map[string]interface{}{
+				"email":          adminEmail,
+				"role":           home.Admin,
+				"home":           homeID,
+				"name":           taskName,
+				"pending":        task.Pending,
+				"finished":       task.Finished,
+				"now":            penalty.At,
+				"defaultPenalty": home.DefaultSettings().Penalties.Rejected,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Records) == 0 {
+			return repository.ErrNotFound
+		}
+
+		row, err = record.Decode[penaltyRow](result.Records[0])
+		if err != nil {
+			return err
+		}
+
+		if row.Amount <= 0 {
+			return nil
+		}
+
+		penalty.Amount = -row.Amount
+		return penalize(ctx, tx, row.Resident, row.Home, penalty)
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	return row.Resident, row.Amount, nil
+}
+
+func (r *CompletionRepository) RecurringChores(ctx context.Context) ([]syncchannel.Chore, error) {
+	result, err := r.read(ctx,
+		`MATCH (h:Home)-[r:HAS_TASK]->(t:Task)
+		WHERE t.recurrence IS NOT NULL AND r.last_completed_at IS NOT NULL
+		RETURN h as home, t.name as task, t.recurrence as recurrence, r.status as status,
+			coalesce(r.streak, 0) as streak, r.last_completed_at as lastAt;`,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[choreRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var chores []syncchannel.Chore
+	for _, row := range rows {
+		days := row.Recurrence.Days()
+		if days == 0 {
+			continue
+		}
+
+		chore := syncchannel.Chore{
+			Task:      row.Task,
+			Days:      days,
+			Status:    row.Status,
+			Streak:    streak.Streak{Current: row.Streak, LastAt: row.LastAt},
+			GraceDays: home.SettingsFromProps(row.Home.Props).GraceDays,
+		}
+		chore.Home, _ = row.Home.Props["id"].(string)
+
+		chores = append(chores, chore)
+	}
+
+	return chores, nil
+}
+
+func (r *CompletionRepository) UpdateChores(ctx context.Context, updates []syncchannel.ChoreUpdate, now time.Time) error {
+	var params []interface{}
+	for _, update := range updates {
+		params = append(params, map[string]interface{}{
+			"home":   update.Home,
+			"task":   update.Task,
+			"status": update.Status,
+			"streak": update.Streak,
+			"reopen": update.Reopen,
+		})
+	}
+
+	_, err := r.execute(ctx,
+		`UNWIND $updates as update
+		MATCH (h:Home {id: update.home})-[r:HAS_TASK]->(t:Task {name: update.task})
+		SET r.pending_since = CASE WHEN update.reopen THEN $now ELSE r.pending_since END,
+			r.status = update.status,
+			r.streak = update.streak,
+			r.version = coalesce(r.version, 1) + 1;`,
+		map[string]interface{}{
+			"updates": params,
+			"now":     now,
+		},
+	)
+	return err
+}
+
+func (r *CompletionRepository) ResidentStreaks(ctx context.Context) ([]syncchannel.ResidentStreak, error) {
+	result, err := r.read(ctx,
+		`MATCH (u:User)-[:LIVES_IN]->(h:Home)
+		WHERE u.last_completed_at IS NOT NULL
+			AND (coalesce(u.daily_streak, 0) > 0 OR coalesce(u.weekly_streak, 0) > 0)
+		RETURN u.email as email, coalesce(u.daily_streak, 0) as dailyStreak,
+			coalesce(u.weekly_streak, 0) as weeklyStreak, u.last_completed_at as lastAt,
+			collect(h) as homes;`,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[residentStreakRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var residents []syncchannel.ResidentStreak
+	for _, row := range rows {
+		resident := syncchannel.ResidentStreak{
+			Email:  row.Email,
+			Daily:  streak.Streak{Current: row.DailyStreak, LastAt: row.LastAt},
+			Weekly: streak.Streak{Current: row.WeeklyStreak, LastAt: row.LastAt},
+		}
+
+		// Moradores de mais de uma casa recebem a maior tolerância entre elas
+		for _, homeNode := range row.Homes {
+			if settings := home.SettingsFromProps(homeNode.Props); settings.GraceDays > resident.GraceDays {
+				resident.GraceDays = settings.GraceDays
+			}
+		}
+
+		residents = append(residents, resident)
+	}
+
+	return residents, nil
+}
+
+func (r *CompletionRepository) UpdateResidentStreaks(ctx context.Context, streaks []syncchannel.ResidentStreak) error {
+	var params []interface{}
+	for _, resident := range streaks {
+		params = append(params, map[string]interface{}{
+			"email":        resident.Email,
+			"dailyStreak":  resident.Daily.Current,
+			"weeklyStreak": resident.Weekly.Current,
+		})
+	}
+
+	_, err := r.execute(ctx,
+		`UNWIND $updates as update
+		MATCH (u:User {email: update.email})
+		SET u.daily_streak = update.dailyStreak,
+			u.weekly_streak = update.weeklyStreak,
+			u.version = coalesce(u.version, 1) + 1;`,
+		map[string]interface{}{"updates": params},
+	)
+	return err
+}
+
+func (r *CompletionRepository) PenalizeOverdue(ctx context.Context, now time.Time) error {
+	return r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		result, err := tx.Run(ctx,
+			`MATCH (h:Home)-[r:HAS_TASK]->(t:Task)
+			WHERE r.assignee IS NOT NULL AND r.due_at < $now
+				AND coalesce(r.status, $pending) <> $finished
+				AND NOT coalesce(r.overdue_penalized, false)
+			MATCH (u:User {email: r.assignee})-[:LIVES_IN]->(h)
+			SET r.overdue_penalized = true
+			RETURN h.id as home, t.name as task, u.email as resident, coalesce(h.penalty_overdue, $defaultPenalty) as amount;`,
+			map[string]interface{}{
+				"now":            now,
+				"pending":        task.Pending,
+				"finished":       task.Finished,
+				"defaultPenalty": home.DefaultSettings().Penalties.Overdue,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		rows, err := record.DecodeAll[penaltyRow](result.Records)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if row.Amount <= 0 {
+				continue
+			}
+
+			err := penalize(ctx, tx, row.Resident, row.Home, score.Entry{
+				ID:     uuid.New(),
+				Kind:   score.Penalty,
+				Reason: score.Overdue,
+				Task:   row.Task,
+				Amount: -row.Amount,
+				At:     now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+// Package graph implementa os repositórios da aplicação sobre o Neo4j.
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+)
+
+type base struct {
+	db *database.DatabaseHandler
+}
+
+// execute roda consultas que gravam dados, sempre no líder do cluster
+func (b base) execute(ctx context.Context, query string, params map[string]interface{}) (*neo4j.EagerResult, error) {
+	return b.db.Write(ctx, query, params)
+}
+
+// read roda consultas somente de leitura, que em cluster podem ir para um seguidor
+func (b base) read(ctx context.Context, query string, params map[string]interface{}) (*neo4j.EagerResult, error) {
+	return b.db.Read(ctx, query, params)
+}
+
+// nullable evita gravar propriedades com texto vazio
+func nullable(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// versionMatches é a condição das gravações condicionais sobre o nó n: com
+// $version zero qualquer versão serve. Nós gravados antes do controle de versão
+// estão na versão 1.
+func versionMatches(n string) string {
+	return fmt.Sprintf("($version = 0 OR coalesce(%s.version, 1) = $version)", n)
+}
+
+// inHome restringe a casa h à de $home; com $home vazio, como nas rotas antigas,
+// vale qualquer casa do usuário
+func inHome(h string) string {
+	return fmt.Sprintf("($home = '' OR %s.id = $home)", h)
+}
+
+// staleOrMissing explica uma gravação condicional que não encontrou o registro:
+// se a consulta exists ainda o encontra, ele mudou de versão
+func staleOrMissing(ctx context.Context, tx database.Tx, exists string, params map[string]interface{}) error {
+	result, err := tx.Run(ctx, exists, params)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Records) == 0 {
+		return repository.ErrNotFound
+	}
+	return repository.ErrStaleVersion
+}
@@ -0,0 +1,447 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+var errInvalidRecord = errors.New("Erro ao processar resultados da consulta")
+
+type HomeRepository struct {
+	base
+}
+
+func NewHomeRepository(db *database.DatabaseHandler) *HomeRepository {
+	return &HomeRepository{base{db}}
+}
+
+func (r *HomeRepository) Create(ctx context.Context, ownerEmail string, homeData home.Home) error {
+	result, err := r.execute(ctx,
+		`MATCH (u:User {email: $email})
+		MERGE (h:Home {id: $id, name: $name})
+		ON CREATE SET h.version = 1
+		MERGE (u)-[r:LIVES_IN]->(h)
+		ON CREATE SET r.role = $role
+		RETURN u.name as userName, u.email as userEmail, h.name as homeName;`,
+		map[string]interface{}{
+			"id":    homeData.ID.String(), // Converte UUID para string
+			"name":  homeData.Name,
+			"email": ownerEmail,
+			"role":  home.Admin,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Records) == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *HomeRepository) AddResident(ctx context.Context, email string, id string, resident user.User) (home.Home, error) {
+	// Associa o usuário à casa e obtém os residentes
+	result, err := r.execute(ctx,
+		`MATCH (u:User {email: $email})-[:LIVES_IN]->(home:Home)
+		WHERE `+inHome("home")+`
+		MERGE (newResident:User {email: $newResident})
+		MERGE (newResident)-[r:LIVES_IN]->(home)
+		ON CREATE SET r.role = $role,
+			home.version = coalesce(home.version, 1) + 1
+		RETURN home, [(home)<-[:LIVES_IN]-(resident:User) | resident] as residents;`,
+		map[string]interface{}{
+			"email":       email,
+			"home":        id,
+			"newResident": resident.Email,
+			"role":        home.Resident,
+		},
+	)
+	if err != nil {
+		return home.Home{}, err
+	}
+
+	if len(result.Records) == 0 {
+		return home.Home{}, repository.ErrNotFound
+	}
+	return homeFromRecords(result.Records)
+}
+
+func (r *HomeRepository) FindByID(ctx context.Context, id string) (home.Home, error) {
+	result, err := r.read(ctx,
+		`MATCH (home:Home {id: $id})
+		WHERE EXISTS { MATCH (:User)-[:LIVES_IN]->(home) }
+		RETURN home, [(home)<-[:LIVES_IN]-(resident:User) | resident] as residents;`,
+		map[string]interface{}{
+			"id": id,
+		},
+	)
+	if err != nil {
+		return home.Home{}, err
+	}
+
+	if len(result.Records) == 0 {
+		return home.Home{}, repository.ErrNotFound
+	}
+	return homeFromRecords(result.Records)
+}
+
+// Update renomeia a casa se adminEmail a administrar e, com versão informada,
+// se ela ainda estiver nessa versão
+func (r *HomeRepository) Update(ctx context.Context, adminEmail string, homeData home.Home) (home.Home, error) {
+	params := map[string]interface{}{
+		"id":      homeData.ID.String(),
+		"email":   adminEmail,
+		"role":    home.Admin,
+		"name":    homeData.Name,
+		"version": homeData.Version,
+	}
+
+	err := r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		result, err := tx.Run(ctx,
+			`MATCH (:User {email: $email})-[:LIVES_IN {role: $role}]->(home:Home {id: $id})
+			WHERE `+versionMatches("home")+`
+			SET home.name = $name,
+				home.version = coalesce(home.version, 1) + 1
+			RETURN home.id as id;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+		if len(result.Records) > 0 {
+			return nil
+		}
+
+		return notAdminStaleOrMissing(ctx, tx, params)
+	})
+	if err != nil {
+		return home.Home{}, err
+	}
+
+	return r.FindByID(ctx, homeData.ID.String())
+}
+
+// notAdminStaleOrMissing explica por que uma escrita restrita a administradores
+// não gravou nada: a casa $id não existe, $email não a administra ou a versão mudou
+func notAdminStaleOrMissing(ctx context.Context, tx database.Tx, params map[string]interface{}) error {
+	result, err := tx.Run(ctx,
+		`MATCH (home:Home {id: $id})
+		RETURN EXISTS { MATCH (:User {email: $email})-[:LIVES_IN {role: $role}]->(home) } as admin;`,
+		params,
+	)
+	if err != nil {
+		return err
+	}
+	if len(result.Records) == 0 {
+		return repository.ErrNotFound
+	}
+
+	row, err := record.Decode[struct {
+		Admin bool `neo4j:"admin"`
+	}](result.Records[0])
+	if err != nil {
+		return err
+	}
+	if !row.Admin {
+		return repository.ErrForbidden
+	}
+	return repository.ErrStaleVersion
+}
+
+// Delete troca o rótulo Home por DeletedHome, o que esconde a casa de todas as
+// consultas sem tocar em moradores, tarefas ou recompensas
+func (r *HomeRepository) Delete(ctx context.Context, id string, deletedBy string, version int64, at time.Time) error {
+	params := map[string]interface{}{
+		"id":        id,
+		"email":     deletedBy,
+		"role":      home.Admin,
+		"now":       at,
+		"deletedBy": nullable(deletedBy),
+		"version":   version,
+	}
+
+	return r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		result, err := tx.Run(ctx,
+			`MATCH (:User {email: $email})-[:LIVES_IN {role: $role}]->(home:Home {id: $id})
+			WHERE `+versionMatches("home")+`
+			REMOVE home:Home
+			SET home:DeletedHome,
+				home.deleted_at = $now,
+				home.deleted_by = $deletedBy,
+				home.version = coalesce(home.version, 1) + 1
+			RETURN home.id as id;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Records) == 0 {
+			return notAdminStaleOrMissing(ctx, tx, params)
+		}
+		return nil
+	})
+}
+
+type trashRow struct {
+	Name      string     `neo4j:"name"`
+	DeletedAt *time.Time `neo4j:"deletedAt,optional"`
+	DeletedBy string     `neo4j:"deletedBy,optional"`
+	Tasks     []struct {
+		Name      string    `neo4j:"name"`
+		DeletedAt time.Time `neo4j:"deleted_at"`
+		DeletedBy string    `neo4j:"deleted_by,optional"`
+	} `neo4j:"tasks"`
+}
+
+func (r *HomeRepository) Trash(ctx context.Context, email string, id string) ([]home.TrashItem, error) {
+	result, err := r.read(ctx,
+		`MATCH (u:User {email: $email})-[:LIVES_IN]->(home {id: $id})
+		WHERE home:Home OR home:DeletedHome
+		OPTIONAL MATCH (home)-[d:DELETED_TASK]->(t:Task)
+		WITH home, d, t
+		ORDER BY d.deleted_at DESC
+		RETURN home.name as name, home.deleted_at as deletedAt, home.deleted_by as deletedBy,
+			collect(CASE WHEN d IS NULL THEN null
+				ELSE {name: t.name, deleted_at: d.deleted_at, deleted_by: d.deleted_by} END) as tasks;`,
+		map[string]interface{}{
+			"email": email,
+			"id":    id,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Records) == 0 {
+		return nil, repository.ErrNotFound
+	}
+
+	row, err := record.Decode[trashRow](result.Records[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var trash []home.TrashItem
+	if row.DeletedAt != nil {
+		trash = append(trash, home.TrashItem{
+			Kind:      home.TrashHome,
+			Name:      row.Name,
+			DeletedAt: *row.DeletedAt,
+			DeletedBy: row.DeletedBy,
+		})
+	}
+	for _, t := range row.Tasks {
+		trash = append(trash, home.TrashItem{
+			Kind:      home.TrashTask,
+			Name:      t.Name,
+			DeletedAt: t.DeletedAt,
+			DeletedBy: t.DeletedBy,
+		})
+	}
+
+	return trash, nil
+}
+
+func (r *HomeRepository) Restore(ctx context.Context, email string, id string, restoration home.Restoration) error {
+	// Qualquer morador restaura tarefas, mas a casa só volta pelas mãos de um administrador
+	query := `MATCH (u:User {email: $email})-[l:LIVES_IN]->(home:DeletedHome {id: $id})
+		WITH home, l.role = $role as admin
+		FOREACH (_ IN CASE WHEN admin THEN [1] ELSE [] END |
+			REMOVE home:DeletedHome
+			SET home:Home,
+				home.deleted_at = null,
+				home.deleted_by = null,
+				home.version = coalesce(home.version, 1) + 1)
+		RETURN admin;`
+	if restoration.Kind == home.TrashTask {
+		query = `MATCH (u:User {email: $email})-[:LIVES_IN]->(home:Home {id: $id})-[d:DELETED_TASK]->(t:Task {name: $name})
+		CREATE (home)-[r:HAS_TASK]->(t)
+		SET r = properties(d),
+			r.deleted_at = null,
+			r.deleted_by = null,
+			r.version = coalesce(d.version, 1) + 1
+		DELETE d
+		RETURN true as admin;`
+	}
+
+	result, err := r.execute(ctx, query,
+		map[string]interface{}{
+			"email": email,
+			"id":    id,
+			"name":  restoration.Name,
+			"role":  home.Admin,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Records) == 0 {
+		return repository.ErrNotFound
+	}
+
+	row, err := record.Decode[struct {
+		Admin bool `neo4j:"admin"`
+	}](result.Records[0])
+	if err != nil {
+		return err
+	}
+	if !row.Admin {
+		return repository.ErrForbidden
+	}
+	return nil
+}
+
+type feedRow struct {
+	User        string    `neo4j:"user"`
+	Achievement string    `neo4j:"achievement,optional"`
+	At          time.Time `neo4j:"at"`
+}
+
+func (r *HomeRepository) Feed(ctx context.Context, id string, limit int) ([]home.FeedItem, error) {
+	result, err := r.read(ctx,
+		`MATCH (home:Home {id: $id})<-[:LIVES_IN]-(u:User)-[e:EARNED]->(a:Achievement)
+		RETURN u.email as user, a.name as achievement, e.at as at
+		ORDER BY e.at DESC
+		LIMIT $limit;`,
+		map[string]interface{}{
+			"id":    id,
+			"limit": limit,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[feedRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var feed []home.FeedItem
+	for _, row := range rows {
+		feed = append(feed, home.FeedItem{
+			Type:    "achievement",
+			User:    row.User,
+			Message: fmt.Sprintf("Conquista desbloqueada: %v", row.Achievement),
+			At:      row.At,
+		})
+	}
+
+	return feed, nil
+}
+
+func (r *HomeRepository) Settings(ctx context.Context, id string) (home.Settings, error) {
+	result, err := r.read(ctx,
+		`MATCH (home:Home {id: $id})
+		RETURN home;`,
+		map[string]interface{}{
+			"id": id,
+		},
+	)
+	if err != nil {
+		return home.Settings{}, err
+	}
+
+	if len(result.Records) == 0 {
+		return home.Settings{}, repository.ErrNotFound
+	}
+
+	homeNode, ok := result.Records[0].Values[0].(neo4j.Node)
+	if !ok {
+		return home.Settings{}, errInvalidRecord
+	}
+
+	return home.SettingsFromProps(homeNode.Props), nil
+}
+
+func (r *HomeRepository) UpdateSettings(ctx context.Context, adminEmail string, id string, settings home.Settings) error {
+	result, err := r.execute(ctx,
+		`MATCH (u:User {email: $email})-[:LIVES_IN {role: $role}]->(home:Home)
+		WHERE `+inHome("home")+`
+		SET home.grace_days = $graceDays,
+			home.streak_bonus = $streakBonus,
+			home.streak_bonus_cap = $streakBonusCap,
+			home.pricing_mode = $pricingMode,
+			home.pricing_curve = $pricingCurve,
+			home.pricing_rate = $pricingRate,
+			home.pricing_cap = $pricingCap,
+			home.penalty_overdue = $penaltyOverdue,
+			home.penalty_rejected = $penaltyRejected,
+			home.version = coalesce(home.version, 1) + 1
+		RETURN home;`,
+		map[string]interface{}{
+			"email":           adminEmail,
+			"role":            home.Admin,
+			"home":            id,
+			"graceDays":       settings.GraceDays,
+			"streakBonus":     settings.StreakBonus,
+			"streakBonusCap":  settings.StreakBonusCap,
+			"pricingMode":     settings.Pricing.Mode,
+			"pricingCurve":    settings.Pricing.Curve,
+			"pricingRate":     settings.Pricing.Rate,
+			"pricingCap":      settings.Pricing.Cap,
+			"penaltyOverdue":  settings.Penalties.Overdue,
+			"penaltyRejected": settings.Penalties.Rejected,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Records) == 0 {
+		return repository.ErrForbidden
+	}
+	return nil
+}
+
+type homeRow struct {
+	Home struct {
+		ID      uuid.UUID `neo4j:"id"`
+		Name    string    `neo4j:"name"`
+		Version int64     `neo4j:"version,optional"`
+	} `neo4j:"home"`
+	Residents []struct {
+		Name    string `neo4j:"name,optional"`
+		Email   string `neo4j:"email"`
+		Version int64  `neo4j:"version,optional"`
+	} `neo4j:"residents"`
+}
+
+func homeFromRecords(records []*neo4j.Record) (home.Home, error) {
+	var homeData home.Home
+	for _, rec := range records {
+		row, err := record.Decode[homeRow](rec)
+		if err != nil {
+			return home.Home{}, err
+		}
+
+		homeData.ID = row.Home.ID
+		homeData.Name = row.Home.Name
+		// Nós gravados antes do controle de versão estão na versão 1
+		homeData.Version = max(row.Home.Version, 1)
+
+		homeData.Residents = nil
+		for _, resident := range row.Residents {
+			homeData.Residents = append(homeData.Residents, user.User{
+				Name:    resident.Name,
+				Email:   resident.Email,
+				Version: max(resident.Version, 1),
+			})
+		}
+	}
+
+	return homeData, nil
+}
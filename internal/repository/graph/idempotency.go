@@ -0,0 +1,120 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/idempotency"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+)
+
+type IdempotencyRepository struct {
+	base
+}
+
+func NewIdempotencyRepository(db *database.DatabaseHandler) *IdempotencyRepository {
+	return &IdempotencyRepository{base{db}}
+}
+
+type idempotencyKeyRow struct {
+	Reserved    bool      `neo4j:"reserved"`
+	Key         string    `neo4j:"key"`
+	Fingerprint string    `neo4j:"fingerprint"`
+	ExpiresAt   time.Time `neo4j:"expiresAt"`
+	Status      *int64    `neo4j:"status"`
+	Header      string    `neo4j:"header,optional"`
+	Body        []byte    `neo4j:"body"`
+}
+
+// Reserve conta com a restrição de unicidade de IdempotencyKey.key: o MERGE
+// concorrente da mesma chave espera o primeiro terminar e encontra a reserva dele
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key idempotency.Key, now time.Time) (idempotency.Key, bool, error) {
+	result, err := r.execute(ctx,
+		`MERGE (k:IdempotencyKey {key: $key})
+		ON CREATE SET k.expires_at = $now
+		WITH k, k.expires_at <= $now as reserved
+		FOREACH (_ IN CASE WHEN reserved THEN [1] ELSE [] END |
+			SET k.fingerprint = $fingerprint,
+				k.expires_at = $expiresAt,
+				k.status = null,
+				k.header = null,
+				k.body = null
+		)
+		RETURN reserved, k.key as key, k.fingerprint as fingerprint, k.expires_at as expiresAt,
+			k.status as status, k.header as header, k.body as body;`,
+		map[string]interface{}{
+			"key":         key.Key,
+			"fingerprint": key.Fingerprint,
+			"expiresAt":   key.ExpiresAt,
+			"now":         now,
+		},
+	)
+	if err != nil {
+		return idempotency.Key{}, false, err
+	}
+
+	row, err := record.Decode[idempotencyKeyRow](result.Records[0])
+	if err != nil {
+		return idempotency.Key{}, false, err
+	}
+
+	existing := idempotency.Key{
+		Key:         row.Key,
+		Fingerprint: row.Fingerprint,
+		ExpiresAt:   row.ExpiresAt,
+	}
+	if row.Status != nil {
+		existing.Response = &idempotency.Response{Status: int(*row.Status), Body: row.Body}
+		if err := json.Unmarshal([]byte(row.Header), &existing.Response.Header); err != nil {
+			return idempotency.Key{}, false, err
+		}
+	}
+
+	return existing, row.Reserved, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, response idempotency.Response) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.execute(ctx,
+		`MATCH (k:IdempotencyKey {key: $key})
+		SET k.status = $status, k.header = $header, k.body = $body;`,
+		map[string]interface{}{
+			"key":    key,
+			"status": response.Status,
+			"header": string(header),
+			"body":   response.Body,
+		},
+	)
+	return err
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.execute(ctx,
+		`MATCH (k:IdempotencyKey {key: $key}) DELETE k;`,
+		map[string]interface{}{"key": key},
+	)
+	return err
+}
+
+func (r *IdempotencyRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.execute(ctx,
+		`MATCH (k:IdempotencyKey) WHERE k.expires_at < $before
+		DELETE k
+		RETURN count(k) as purged;`,
+		map[string]interface{}{"before": before},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	row, err := record.Decode[struct {
+		Purged int `neo4j:"purged"`
+	}](result.Records[0])
+	return row.Purged, err
+}
@@ -0,0 +1,167 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+	"github.com/nsbnroque/go-to-do-list/maintenance"
+)
+
+type MaintenanceRepository struct {
+	base
+}
+
+func NewMaintenanceRepository(db *database.DatabaseHandler) *MaintenanceRepository {
+	return &MaintenanceRepository{base{db}}
+}
+
+type orphanRow struct {
+	Kind string `neo4j:"kind"`
+	ID   string `neo4j:"id"`
+}
+
+func (r *MaintenanceRepository) Orphans(ctx context.Context) ([]maintenance.Orphan, error) {
+	result, err := r.read(ctx,
+		`MATCH (t:Task) WHERE NOT EXISTS { MATCH (t)<-[:HAS_TASK|DELETED_TASK]-() }
+		RETURN $task as kind, t.name as id
+		UNION ALL
+		MATCH (h) WHERE (h:Home OR h:DeletedHome) AND NOT EXISTS { MATCH (h)<-[:LIVES_IN]-(:User) }
+		RETURN $home as kind, toString(h.id) as id
+		UNION ALL
+		MATCH (r:Reward) WHERE NOT EXISTS { MATCH (r)<-[:OFFERS]-() }
+		RETURN $reward as kind, toString(r.id) as id
+		UNION ALL
+		MATCH (rd:Redemption)
+		WHERE NOT EXISTS { MATCH (rd)-[:OF]->(:Reward) } OR NOT EXISTS { MATCH (rd)<-[:REDEEMED]-(:User) }
+		RETURN $redemption as kind, toString(rd.id) as id
+		UNION ALL
+		MATCH (e:ScoreEntry)
+		WHERE NOT EXISTS { MATCH (e)<-[:HAS_SCORE_ENTRY]-(:User) } OR NOT (EXISTS { MATCH (:Home {id: e.home}) } OR EXISTS { MATCH (:DeletedHome {id: e.home}) })
+		RETURN $scoreEntry as kind, toString(e.id) as id;`,
+		map[string]interface{}{
+			"task":       string(maintenance.Task),
+			"home":       string(maintenance.Home),
+			"reward":     string(maintenance.Reward),
+			"redemption": string(maintenance.Redemption),
+			"scoreEntry": string(maintenance.ScoreEntry),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[orphanRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []maintenance.Orphan
+	for _, row := range rows {
+		orphans = append(orphans, maintenance.Orphan{Kind: maintenance.Kind(row.Kind), ID: row.ID})
+	}
+
+	return orphans, nil
+}
+
+func (r *MaintenanceRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	var purged int
+
+	err := r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		purged = 0
+
+		result, err := tx.Run(ctx,
+			`OPTIONAL MATCH (home:DeletedHome)
+			WHERE home.deleted_at < $before
+			RETURN collect(home.id) as ids;`,
+			map[string]interface{}{
+				"before": before,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		homes, err := record.Decode[struct {
+			IDs []string `neo4j:"ids"`
+		}](result.Records[0])
+		if err != nil {
+			return err
+		}
+
+		params := map[string]interface{}{
+			"ids":    homes.IDs,
+			"before": before,
+		}
+
+		// Remove as tarefas das casas expurgadas e as vencidas na lixeira; cada casa
+		// tem o seu nó de tarefa, que sai junto com a relação
+		result, err = tx.Run(ctx,
+			`OPTIONAL MATCH (home)-[d:HAS_TASK|DELETED_TASK]->(t:Task)
+			WHERE home.id IN $ids OR (type(d) = 'DELETED_TASK' AND d.deleted_at < $before)
+			WITH collect(t) as tasks, size([rel IN collect(d) WHERE type(rel) = 'DELETED_TASK']) as trashed
+			FOREACH (t IN tasks | DETACH DELETE t)
+			RETURN trashed;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		tasks, err := record.Decode[struct {
+			Trashed int64 `neo4j:"trashed"`
+		}](result.Records[0])
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Run(ctx,
+			`MATCH (home:DeletedHome)-[:OFFERS]->(r:Reward)
+			WHERE home.id IN $ids
+			OPTIONAL MATCH (rd:Redemption)-[:OF]->(r)
+			DETACH DELETE rd, r;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Run(ctx,
+			`MATCH (home:DeletedHome)-[:HAS_WEBHOOK]->(w:Webhook)
+			WHERE home.id IN $ids
+			OPTIONAL MATCH (w)-[:SENT]->(d:WebhookDelivery)
+			OPTIONAL MATCH (d)-[:TRIED]->(a:WebhookAttempt)
+			DETACH DELETE a, d, w;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Run(ctx,
+			`MATCH (e:ScoreEntry)
+			WHERE e.home IN $ids
+			DETACH DELETE e;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Run(ctx,
+			`MATCH (home:DeletedHome)
+			WHERE home.id IN $ids
+			DETACH DELETE home;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		purged = len(homes.IDs) + int(tasks.Trashed)
+		return nil
+	})
+
+	return purged, err
+}
@@ -0,0 +1,362 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+)
+
+// Migration altera o esquema ou os dados do grafo. As migrações são aplicadas em
+// ordem de versão e cada versão aplicada é registrada num nó SchemaMigration.
+// Cada comando roda em sua própria transação, já que o Neo4j não mistura
+// alterações de esquema e de dados numa mesma transação.
+type Migration struct {
+	Version     int64
+	Description string
+	Statements  []string
+}
+
+// Migrations nunca devem ser editadas depois de publicadas, apenas acrescentadas
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Une usuários duplicados pelo email",
+		Statements: []string{
+			`MATCH (u:User)
+			WITH u.email as email, collect(u) as users
+			WHERE email IS NOT NULL AND size(users) > 1
+			WITH head(users) as keep, tail(users) as duplicates
+			UNWIND duplicates as duplicate
+			CALL {
+				WITH keep, duplicate
+				MATCH (duplicate)-[r:LIVES_IN]->(h:Home)
+				MERGE (keep)-[moved:LIVES_IN]->(h)
+				ON CREATE SET moved = properties(r)
+				RETURN count(*) as homes
+			}
+			CALL {
+				WITH keep, duplicate
+				MATCH (duplicate)-[c:COMPLETED]->(t:Task)
+				CREATE (keep)-[moved:COMPLETED]->(t)
+				SET moved = properties(c)
+				RETURN count(*) as completions
+			}
+			CALL {
+				WITH keep, duplicate
+				MATCH (duplicate)-[e:EARNED]->(a:Achievement)
+				MERGE (keep)-[moved:EARNED]->(a)
+				ON CREATE SET moved = properties(e)
+				RETURN count(*) as achievements
+			}
+			CALL {
+				WITH keep, duplicate
+				MATCH (duplicate)-[:REDEEMED]->(rd:Redemption)
+				CREATE (keep)-[:REDEEMED]->(rd)
+				RETURN count(*) as redemptions
+			}
+			CALL {
+				WITH keep, duplicate
+				MATCH (duplicate)-[:HAS_SCORE_ENTRY]->(e:ScoreEntry)
+				CREATE (keep)-[:HAS_SCORE_ENTRY]->(e)
+				RETURN count(*) as entries
+			}
+			SET keep.score = coalesce(keep.score, 0) + coalesce(duplicate.score, 0),
+				keep.name = coalesce(keep.name, duplicate.name),
+				keep.password = coalesce(keep.password, duplicate.password)
+			DETACH DELETE duplicate;`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "Une tarefas duplicadas pelo nome",
+		Statements: []string{
+			`MATCH (t:Task)
+			WITH t.name as name, collect(t) as tasks
+			WHERE name IS NOT NULL AND size(tasks) > 1
+			WITH head(tasks) as keep, tail(tasks) as duplicates
+			UNWIND duplicates as duplicate
+			CALL {
+				WITH keep, duplicate
+				MATCH (h:Home)-[r:HAS_TASK]->(duplicate)
+				MERGE (h)-[moved:HAS_TASK]->(keep)
+				ON CREATE SET moved = properties(r)
+				RETURN count(*) as homes
+			}
+			CALL {
+				WITH keep, duplicate
+				MATCH (u:User)-[c:COMPLETED]->(duplicate)
+				CREATE (u)-[moved:COMPLETED]->(keep)
+				SET moved = properties(c)
+				RETURN count(*) as completions
+			}
+			DETACH DELETE duplicate;`,
+		},
+	},
+	{
+		Version:     3,
+		Description: "Une conquistas duplicadas pelo id",
+		Statements: []string{
+			`MATCH (a:Achievement)
+			WITH a.id as id, collect(a) as achievements
+			WHERE id IS NOT NULL AND size(achievements) > 1
+			WITH head(achievements) as keep, tail(achievements) as duplicates
+			UNWIND duplicates as duplicate
+			CALL {
+				WITH keep, duplicate
+				MATCH (u:User)-[e:EARNED]->(duplicate)
+				MERGE (u)-[moved:EARNED]->(keep)
+				ON CREATE SET moved = properties(e)
+				RETURN count(*) as users
+			}
+			DETACH DELETE duplicate;`,
+		},
+	},
+	{
+		Version:     4,
+		Description: "Restrições de unicidade dos identificadores",
+		Statements: []string{
+			`CREATE CONSTRAINT user_email IF NOT EXISTS FOR (u:User) REQUIRE u.email IS UNIQUE`,
+			`CREATE CONSTRAINT home_id IF NOT EXISTS FOR (h:Home) REQUIRE h.id IS UNIQUE`,
+			`CREATE CONSTRAINT task_name IF NOT EXISTS FOR (t:Task) REQUIRE t.name IS UNIQUE`,
+			`CREATE CONSTRAINT achievement_id IF NOT EXISTS FOR (a:Achievement) REQUIRE a.id IS UNIQUE`,
+			`CREATE CONSTRAINT reward_id IF NOT EXISTS FOR (r:Reward) REQUIRE r.id IS UNIQUE`,
+			`CREATE CONSTRAINT redemption_id IF NOT EXISTS FOR (rd:Redemption) REQUIRE rd.id IS UNIQUE`,
+			`CREATE CONSTRAINT score_entry_id IF NOT EXISTS FOR (e:ScoreEntry) REQUIRE e.id IS UNIQUE`,
+			`CREATE CONSTRAINT schema_migration_version IF NOT EXISTS FOR (m:SchemaMigration) REQUIRE m.version IS UNIQUE`,
+		},
+	},
+	{
+		Version:     5,
+		Description: "Índices do histórico de pontuação",
+		Statements: []string{
+			`CREATE INDEX score_entry_at IF NOT EXISTS FOR (e:ScoreEntry) ON (e.at)`,
+		},
+	},
+	{
+		Version:     6,
+		Description: "Índices da lixeira",
+		Statements: []string{
+			`CREATE INDEX deleted_home_id IF NOT EXISTS FOR (h:DeletedHome) ON (h.id)`,
+			`CREATE INDEX deleted_home_deleted_at IF NOT EXISTS FOR (h:DeletedHome) ON (h.deleted_at)`,
+		},
+	},
+	{
+		Version:     7,
+		Description: "Chaves de idempotência",
+		Statements: []string{
+			`CREATE CONSTRAINT idempotency_key IF NOT EXISTS FOR (k:IdempotencyKey) REQUIRE k.key IS UNIQUE`,
+			`CREATE INDEX idempotency_key_expires_at IF NOT EXISTS FOR (k:IdempotencyKey) ON (k.expires_at)`,
+		},
+	},
+	{
+		Version:     8,
+		Description: "Webhooks e entregas",
+		Statements: []string{
+			`CREATE CONSTRAINT webhook_id IF NOT EXISTS FOR (w:Webhook) REQUIRE w.id IS UNIQUE`,
+			`CREATE CONSTRAINT webhook_delivery_id IF NOT EXISTS FOR (d:WebhookDelivery) REQUIRE d.id IS UNIQUE`,
+			`CREATE INDEX webhook_delivery_due IF NOT EXISTS FOR (d:WebhookDelivery) ON (d.status, d.next_attempt_at)`,
+		},
+	},
+	{
+		Version:     9,
+		Description: "Nome de tarefa único por casa, e não no grafo todo",
+		Statements: []string{
+			`DROP CONSTRAINT task_name IF EXISTS`,
+			`CREATE CONSTRAINT task_home_name IF NOT EXISTS FOR (t:Task) REQUIRE (t.home, t.name) IS UNIQUE`,
+		},
+	},
+	{
+		Version:     10,
+		Description: "Separa as tarefas compartilhadas num nó por casa",
+		Statements: []string{
+			`MATCH (h)-[r:HAS_TASK]->(t:Task)
+			WHERE t.home IS NULL
+			CREATE (h)-[moved:HAS_TASK]->(copy:Task {home: h.id, name: t.name})
+			SET copy.reward = t.reward,
+				copy.recurrence = t.recurrence,
+				copy.version = t.version,
+				moved = properties(r)
+			DELETE r;`,
+			`MATCH (h)-[d:DELETED_TASK]->(t:Task)
+			WHERE t.home IS NULL
+			CREATE (h)-[moved:DELETED_TASK]->(copy:Task {home: h.id, name: t.name})
+			SET copy.reward = t.reward,
+				copy.recurrence = t.recurrence,
+				copy.version = t.version,
+				moved = properties(d)
+			DELETE d;`,
+			// As conclusões vão para a tarefa numa casa do usuário; as de quem não
+			// mora mais em nenhuma ficam no nó antigo, fora de qualquer casa
+			`MATCH (u:User)-[c:COMPLETED]->(t:Task)
+			WHERE t.home IS NULL
+			MATCH (u)-[:LIVES_IN]->(h)-[:HAS_TASK|DELETED_TASK]->(copy:Task)
+			WHERE copy.home = h.id AND copy.name = t.name
+			WITH u, c, head(collect(copy)) as copy
+			CREATE (u)-[moved:COMPLETED]->(copy)
+			SET moved = properties(c)
+			DELETE c;`,
+			`MATCH (t:Task)
+			WHERE t.home IS NULL AND NOT EXISTS { MATCH (t)--() }
+			DELETE t;`,
+		},
+	},
+	{
+		Version:     11,
+		Description: "Versão da tarefa na relação com a casa",
+		Statements: []string{
+			`MATCH ()-[r:HAS_TASK|DELETED_TASK]->(t:Task)
+			SET r.version = coalesce(r.version, t.version, 1)
+			REMOVE t.version;`,
+		},
+	},
+}
+
+// MigrationStatus descreve a versão do grafo em relação às migrações conhecidas
+type MigrationStatus struct {
+	Version int64
+	Latest  int64
+	// Dirty é a versão de uma migração iniciada e não concluída, 0 se não houver
+	Dirty   int64
+	Pending []Migration
+}
+
+type Migrator struct {
+	base
+}
+
+func NewMigrator(db *database.DatabaseHandler) *Migrator {
+	return &Migrator{base{db}}
+}
+
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	result, err := m.execute(ctx,
+		`OPTIONAL MATCH (m:SchemaMigration)
+		RETURN coalesce(max(CASE WHEN m.dirty THEN null ELSE m.version END), 0) as version,
+			coalesce(min(CASE WHEN m.dirty THEN m.version ELSE null END), 0) as dirty;`,
+		nil,
+	)
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	row, err := record.Decode[struct {
+		Version int64 `neo4j:"version"`
+		Dirty   int64 `neo4j:"dirty"`
+	}](result.Records[0])
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	status := MigrationStatus{
+		Version: row.Version,
+		Dirty:   row.Dirty,
+	}
+
+	for _, migration := range Migrations {
+		status.Latest = migration.Version
+		if migration.Version > status.Version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+
+	return status, nil
+}
+
+// Up aplica as migrações pendentes. Uma migração que falha fica marcada como
+// incompleta e impede novas execuções até que seja corrigida e liberada com Force.
+func (m *Migrator) Up(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	if status.Dirty != 0 {
+		return dirtyError(status.Dirty)
+	}
+
+	for _, migration := range status.Pending {
+		log.Printf("Aplicando migração %d: %s", migration.Version, migration.Description)
+
+		if err := m.mark(ctx, migration, true); err != nil {
+			return err
+		}
+
+		for _, statement := range migration.Statements {
+			if _, err := m.execute(ctx, statement, nil); err != nil {
+				return fmt.Errorf("Falha na migração %d: %v", migration.Version, err)
+			}
+		}
+
+		if err := m.mark(ctx, migration, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Force registra version como a versão atual, concluída, e descarta as
+// marcações das versões posteriores
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	_, err := m.execute(ctx,
+		`OPTIONAL MATCH (m:SchemaMigration)
+		WHERE m.version > $version
+		DETACH DELETE m;`,
+		map[string]interface{}{"version": version},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range Migrations {
+		if migration.Version > version {
+			break
+		}
+		if err := m.mark(ctx, migration, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) mark(ctx context.Context, migration Migration, dirty bool) error {
+	_, err := m.execute(ctx,
+		`MERGE (m:SchemaMigration {version: $version})
+		SET m.description = $description,
+			m.dirty = $dirty,
+			m.applied_at = CASE WHEN $dirty THEN null ELSE coalesce(m.applied_at, $now) END;`,
+		map[string]interface{}{
+			"version":     migration.Version,
+			"description": migration.Description,
+			"dirty":       dirty,
+			"now":         time.Now(),
+		},
+	)
+	return err
+}
+
+// Check impede a inicialização sobre um grafo com migração incompleta
+func (m *Migrator) Check(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	if status.Dirty != 0 {
+		return dirtyError(status.Dirty)
+	}
+
+	if len(status.Pending) > 0 {
+		log.Printf("Banco de dados na versão %d, há %d migrações pendentes até a versão %d", status.Version, len(status.Pending), status.Latest)
+	}
+	return nil
+}
+
+func dirtyError(version int64) error {
+	return fmt.Errorf("Migração %d não foi concluída, corrija o banco de dados e use 'migrate force <versão>'", version)
+}
@@ -0,0 +1,190 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/reward"
+)
+
+type rewardRow struct {
+	ID    uuid.UUID `neo4j:"id"`
+	Name  string    `neo4j:"name"`
+	Cost  int64     `neo4j:"cost"`
+	Stock int64     `neo4j:"stock"`
+}
+
+type redemptionRow struct {
+	ID         uuid.UUID `neo4j:"id,optional"`
+	Reward     string    `neo4j:"reward"`
+	User       string    `neo4j:"user,optional"`
+	Cost       int64     `neo4j:"cost"`
+	RedeemedAt time.Time `neo4j:"redeemedAt,optional"`
+	Fulfilled  bool      `neo4j:"fulfilled,optional"`
+}
+
+type RewardRepository struct {
+	base
+}
+
+func NewRewardRepository(db *database.DatabaseHandler) *RewardRepository {
+	return &RewardRepository{base{db}}
+}
+
+func (r *RewardRepository) Create(ctx context.Context, adminEmail string, homeID string, rewardData reward.Reward) error {
+	result, err := r.execute(ctx,
+		`MATCH (u:User {email: $email})-[:LIVES_IN {role: $role}]->(h:Home)
+		WHERE `+inHome("h")+`
+		WITH h
+		LIMIT 1
+		CREATE (h)-[:OFFERS]->(r:Reward {id: $id, name: $name, cost: $cost, stock: $stock})
+		RETURN r.id as id;`,
+		map[string]interface{}{
+			"email": adminEmail,
+			"role":  home.Admin,
+			"home":  homeID,
+			"id":    rewardData.ID.String(),
+			"name":  rewardData.Name,
+			"cost":  rewardData.Cost,
+			"stock": rewardData.Stock,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Records) == 0 {
+		return repository.ErrForbidden
+	}
+	return nil
+}
+
+func (r *RewardRepository) FindByUser(ctx context.Context, email string, homeID string) ([]reward.Reward, error) {
+	result, err := r.read(ctx,
+		`MATCH (u:User {email: $email})-[:LIVES_IN]->(h:Home)-[:OFFERS]->(r:Reward)
+		WHERE `+inHome("h")+`
+		RETURN r.id as id, r.name as name, r.cost as cost, r.stock as stock
+		ORDER BY r.cost;`,
+		map[string]interface{}{
+			"email": email,
+			"home":  homeID,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[rewardRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var rewards []reward.Reward
+	for _, row := range rows {
+		rewards = append(rewards, reward.Reward(row))
+	}
+
+	return rewards, nil
+}
+
+func (r *RewardRepository) Redeem(ctx context.Context, email string, homeID string, rewardID string, redemption reward.Redemption) (reward.Redemption, error) {
+	// A escrita em _lock trava a recompensa e o usuário até o fim da transação;
+	// só então estoque e saldo são conferidos, já sem resgates concorrentes
+	result, err := r.execute(ctx,
+		`MATCH (u:User {email: $email})-[:LIVES_IN]->(h:Home)-[:OFFERS]->(r:Reward {id: $rewardId})
+		WHERE `+inHome("h")+`
+		SET r._lock = true, u._lock = true
+		REMOVE r._lock, u._lock
+		WITH u, r
+		WHERE r.stock > 0 AND coalesce(u.score, 0) >= r.cost
+		SET r.stock = r.stock - 1,
+			u.score = coalesce(u.score, 0) - r.cost,
+			u.version = coalesce(u.version, 1) + 1
+		CREATE (u)-[:REDEEMED]->(rd:Redemption {id: $id, cost: r.cost, redeemed_at: $redeemedAt, fulfilled: false})-[:OF]->(r)
+		RETURN r.name as reward, rd.cost as cost;`,
+		map[string]interface{}{
+			"email":      email,
+			"home":       homeID,
+			"rewardId":   rewardID,
+			"id":         redemption.ID.String(),
+			"redeemedAt": redemption.RedeemedAt,
+		},
+	)
+	if err != nil {
+		return redemption, err
+	}
+
+	if len(result.Records) == 0 {
+		return redemption, repository.ErrConflict
+	}
+
+	row, err := record.Decode[redemptionRow](result.Records[0])
+	if err != nil {
+		return redemption, err
+	}
+	redemption.Reward = row.Reward
+	redemption.Cost = row.Cost
+
+	return redemption, nil
+}
+
+func (r *RewardRepository) Redemptions(ctx context.Context, email string, homeID string) ([]reward.Redemption, error) {
+	result, err := r.read(ctx,
+		`MATCH (u:User {email: $email})-[:LIVES_IN]->(h:Home)-[:OFFERS]->(r:Reward)
+		WHERE `+inHome("h")+`
+		MATCH (resident:User)-[:REDEEMED]->(rd:Redemption)-[:OF]->(r)
+		RETURN rd.id as id, r.name as reward, resident.email as user, rd.cost as cost,
+			rd.redeemed_at as redeemedAt, rd.fulfilled as fulfilled
+		ORDER BY rd.redeemed_at DESC;`,
+		map[string]interface{}{
+			"email": email,
+			"home":  homeID,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[redemptionRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var redemptions []reward.Redemption
+	for _, row := range rows {
+		redemptions = append(redemptions, reward.Redemption(row))
+	}
+
+	return redemptions, nil
+}
+
+func (r *RewardRepository) Fulfill(ctx context.Context, adminEmail string, homeID string, id string, at time.Time) error {
+	result, err := r.execute(ctx,
+		`MATCH (u:User {email: $email})-[:LIVES_IN {role: $role}]->(h:Home)-[:OFFERS]->(r:Reward)
+		WHERE `+inHome("h")+`
+		MATCH (rd:Redemption {id: $id})-[:OF]->(r)
+		SET rd.fulfilled = true,
+			rd.fulfilled_at = $fulfilledAt
+		RETURN rd.id as id;`,
+		map[string]interface{}{
+			"email":       adminEmail,
+			"role":        home.Admin,
+			"home":        homeID,
+			"id":          id,
+			"fulfilledAt": at,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Records) == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
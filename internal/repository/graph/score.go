@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/score"
+)
+
+type ScoreRepository struct {
+	base
+}
+
+func NewScoreRepository(db *database.DatabaseHandler) *ScoreRepository {
+	return &ScoreRepository{base{db}}
+}
+
+func (r *ScoreRepository) History(ctx context.Context, email string, limit int) ([]score.Entry, error) {
+	result, err := r.read(ctx,
+		`MATCH (u:User {email: $email})-[:HAS_SCORE_ENTRY]->(e:ScoreEntry)
+		RETURN e
+		ORDER BY e.at DESC
+		LIMIT $limit;`,
+		map[string]interface{}{
+			"email": email,
+			"limit": limit,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[entryRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []score.Entry
+	for _, row := range rows {
+		entries = append(entries, score.Entry(row.Entry))
+	}
+
+	return entries, nil
+}
+
+func (r *ScoreRepository) Waive(ctx context.Context, adminEmail string, homeID string, id string, waiver score.Waiver, at time.Time) (score.Entry, error) {
+	result, err := r.execute(ctx,
+		`MATCH (a:User {email: $email})-[:LIVES_IN {role: $role}]->(h:Home)
+		WHERE `+inHome("h")+`
+		MATCH (u:User)-[:HAS_SCORE_ENTRY]->(e:ScoreEntry {id: $id, home: h.id, kind: $kind})
+		WHERE NOT e.waived
+		SET e.waived = true,
+			e.waived_by = $email,
+			e.waive_reason = $reason,
+			e.waived_at = $now,
+			u.score = coalesce(u.score, 0) - e.amount,
+			u.version = coalesce(u.version, 1) + 1
+		RETURN e;`,
+		map[string]interface{}{
+			"email":  adminEmail,
+			"role":   home.Admin,
+			"home":   homeID,
+			"id":     id,
+			"kind":   score.Penalty,
+			"reason": waiver.Reason,
+			"now":    at,
+		},
+	)
+	if err != nil {
+		return score.Entry{}, err
+	}
+
+	if len(result.Records) == 0 {
+		return score.Entry{}, repository.ErrNotFound
+	}
+
+	row, err := record.Decode[entryRow](result.Records[0])
+	if err != nil {
+		return score.Entry{}, err
+	}
+
+	return score.Entry(row.Entry), nil
+}
+
+// entryProps traz as propriedades do nó ScoreEntry
+type entryProps struct {
+	ID          uuid.UUID    `neo4j:"id"`
+	Kind        score.Kind   `neo4j:"kind"`
+	Reason      score.Reason `neo4j:"reason"`
+	Task        string       `neo4j:"task,optional"`
+	Amount      int64        `neo4j:"amount"`
+	At          time.Time    `neo4j:"at"`
+	Waived      bool         `neo4j:"waived,optional"`
+	WaivedBy    string       `neo4j:"waived_by,optional"`
+	WaiveReason string       `neo4j:"waive_reason,optional"`
+}
+
+type entryRow struct {
+	Entry entryProps `neo4j:"e"`
+}
+
+// penalize desconta os pontos do morador e registra a penalidade no histórico
+func penalize(ctx context.Context, tx database.Tx, email string, homeID string, entry score.Entry) error {
+	_, err := tx.Run(ctx,
+		`MATCH (u:User {email: $email})
+		SET u.score = coalesce(u.score, 0) + $amount,
+			u.version = coalesce(u.version, 1) + 1
+		CREATE (u)-[:HAS_SCORE_ENTRY]->(:ScoreEntry {id: $id, home: $home, kind: $kind, reason: $reason,
+			task: $task, amount: $amount, at: $at, waived: false});`,
+		map[string]interface{}{
+			"email":  email,
+			"home":   homeID,
+			"id":     entry.ID.String(),
+			"kind":   entry.Kind,
+			"reason": entry.Reason,
+			"task":   entry.Task,
+			"amount": entry.Amount,
+			"at":     entry.At,
+		},
+	)
+	return err
+}
@@ -0,0 +1,232 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/pricing"
+	"github.com/nsbnroque/go-to-do-list/task"
+)
+
+// taskRow traz as colunas das consultas de tarefa, nem todas presentes em todas elas
+type taskRow struct {
+	Name         string          `neo4j:"name"`
+	Reward       int64           `neo4j:"reward,optional"`
+	Status       task.Status     `neo4j:"status,optional"`
+	Recurrence   task.Recurrence `neo4j:"recurrence,optional"`
+	Streak       int64           `neo4j:"streak,optional"`
+	Version      int64           `neo4j:"version,optional"`
+	PendingSince time.Time       `neo4j:"pendingSince,optional"`
+	Assignee     string          `neo4j:"assignee,optional"`
+	DueAt        *time.Time      `neo4j:"dueAt,optional"`
+	Home         *neo4j.Node     `neo4j:"home,optional"`
+}
+
+type TaskRepository struct {
+	base
+}
+
+func NewTaskRepository(db *database.DatabaseHandler) *TaskRepository {
+	return &TaskRepository{base{db}}
+}
+
+func (r *TaskRepository) Save(ctx context.Context, email string, homeID string, taskData task.Task) (task.Task, error) {
+	result, err := r.execute(ctx,
+		`MATCH (u:User {email: $email})-[:LIVES_IN]->(h:Home)
+		WHERE `+inHome("h")+`
+		MERGE (t:Task {home: h.id, name: $name})
+		SET t.reward = $reward,
+			t.recurrence = $recurrence
+		MERGE (h)-[r:HAS_TASK]->(t)
+		ON CREATE SET r.pending_since = $now
+		ON MATCH SET r.pending_since = CASE WHEN r.status = $status THEN coalesce(r.pending_since, $now) ELSE $now END,
+			r.status = $status
+		WITH h, r, t
+		OPTIONAL MATCH (h)-[d:DELETED_TASK]->(t)
+		SET r.version = coalesce(r.version, d.version, 0) + 1
+		DELETE d
+		RETURN t.name as name, t.reward as reward, t.recurrence as recurrence, r.version as version;`,
+		map[string]interface{}{
+			"name":       taskData.Name,
+			"status":     task.Pending,
+			"email":      email,
+			"home":       homeID,
+			"reward":     taskData.Reward,
+			"recurrence": nullableRecurrence(taskData.Recurrence),
+			"now":        time.Now(),
+		},
+	)
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	if len(result.Records) == 0 {
+		return task.Task{}, repository.ErrNotFound
+	}
+
+	row, err := record.Decode[taskRow](result.Records[0])
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	return task.Task{
+		Name:       row.Name,
+		Reward:     row.Reward,
+		Recurrence: row.Recurrence,
+		Version:    row.Version,
+	}, nil
+}
+
+// Update grava a recompensa e o status da tarefa em cada casa do usuário, ou só
+// na casa homeID quando informada
+func (r *TaskRepository) Update(ctx context.Context, email string, homeID string, taskData task.Task) (task.Task, error) {
+	params := map[string]interface{}{
+		"name":    taskData.Name,
+		"status":  taskData.Status,
+		"reward":  taskData.Reward,
+		"email":   email,
+		"home":    homeID,
+		"version": taskData.Version,
+	}
+
+	var updated task.Task
+	err := r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		result, err := tx.Run(ctx,
+			`MATCH (u:User {email: $email})-[:LIVES_IN]->(h:Home)-[r:HAS_TASK]->(t:Task {name: $name})
+			WHERE `+inHome("h")+` AND `+versionMatches("r")+`
+			SET t.reward = $reward,
+				r.status = $status,
+				r.version = coalesce(r.version, 1) + 1
+			RETURN t.name as name, t.reward as reward, r.status as status, r.version as version;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Records) == 0 {
+			return staleOrMissing(ctx, tx, userTaskExists, params)
+		}
+
+		row, err := record.Decode[taskRow](result.Records[0])
+		if err != nil {
+			return err
+		}
+
+		updated = task.Task{
+			Name:    row.Name,
+			Reward:  row.Reward,
+			Status:  row.Status,
+			Version: row.Version,
+		}
+		return nil
+	})
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	return updated, nil
+}
+
+// Delete troca a relação HAS_TASK por DELETED_TASK, que guarda o estado da
+// tarefa na casa para uma eventual restauração
+func (r *TaskRepository) Delete(ctx context.Context, email string, homeID string, name string, version int64, at time.Time) error {
+	params := map[string]interface{}{
+		"name":    name,
+		"email":   email,
+		"home":    homeID,
+		"now":     at,
+		"version": version,
+	}
+
+	return r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		result, err := tx.Run(ctx,
+			`MATCH (u:User {email: $email})-[:LIVES_IN]->(h:Home)-[r:HAS_TASK]->(t:Task {name: $name})
+			WHERE `+inHome("h")+` AND `+versionMatches("r")+`
+			OPTIONAL MATCH (h)-[old:DELETED_TASK]->(t)
+			DELETE old
+			CREATE (h)-[d:DELETED_TASK]->(t)
+			SET d = properties(r),
+				d.deleted_at = $now,
+				d.deleted_by = $email,
+				d.version = coalesce(r.version, 1) + 1
+			DELETE r
+			RETURN t.name as name;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Records) == 0 {
+			return staleOrMissing(ctx, tx, userTaskExists, params)
+		}
+		return nil
+	})
+}
+
+// userTaskExists encontra a tarefa $name nas casas do usuário $email, ou só na casa $home
+var userTaskExists = `MATCH (u:User {email: $email})-[:LIVES_IN]->(h:Home)-[:HAS_TASK]->(t:Task {name: $name})
+	WHERE ` + inHome("h") + `
+	RETURN t.name as name
+	LIMIT 1;`
+
+func (r *TaskRepository) FindByUser(ctx context.Context, email string, homeID string) ([]task.Entry, error) {
+	result, err := r.read(ctx,
+		`MATCH (u:User {email: $email})-[:LIVES_IN]->(h:Home)-[r:HAS_TASK]->(t:Task)
+		WHERE `+inHome("h")+`
+		RETURN t.name as name, t.reward as reward, r.status as status,
+			t.recurrence as recurrence, coalesce(r.streak, 0) as streak,
+			r.pending_since as pendingSince, h as home, r.assignee as assignee, r.due_at as dueAt,
+			coalesce(r.version, 1) as version;`,
+		map[string]interface{}{
+			"email": email,
+			"home":  homeID,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[taskRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []task.Entry
+	for _, row := range rows {
+		entry := task.Entry{
+			Task: task.Task{
+				Name:       row.Name,
+				Reward:     row.Reward,
+				Status:     row.Status,
+				Recurrence: row.Recurrence,
+				Streak:     row.Streak,
+				Version:    row.Version,
+				Assignee:   row.Assignee,
+				DueAt:      row.DueAt,
+			},
+			PendingSince: row.PendingSince,
+			Pricing:      pricing.Default(),
+		}
+		if row.Home != nil {
+			entry.Pricing = pricing.FromProps(row.Home.Props)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// nullableRecurrence remove a propriedade das tarefas avulsas
+func nullableRecurrence(recurrence task.Recurrence) interface{} {
+	if recurrence == "" {
+		return nil
+	}
+	return recurrence
+}
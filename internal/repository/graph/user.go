@@ -0,0 +1,182 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+// userRow traz as colunas das consultas de usuário.
+// Moradores adicionados por email ainda não têm nome nem senha.
+type userRow struct {
+	Name         string `neo4j:"name,optional"`
+	Email        string `neo4j:"email"`
+	Password     string `neo4j:"password,optional"`
+	Score        int64  `neo4j:"score,optional"`
+	DailyStreak  int64  `neo4j:"dailyStreak,optional"`
+	WeeklyStreak int64  `neo4j:"weeklyStreak,optional"`
+	Version      int64  `neo4j:"version,optional"`
+}
+
+type UserRepository struct {
+	base
+}
+
+func NewUserRepository(db *database.DatabaseHandler) *UserRepository {
+	return &UserRepository{base{db}}
+}
+
+func (r *UserRepository) Save(ctx context.Context, userData user.User) error {
+	_, err := r.execute(ctx,
+		`MERGE (u:User {email: $email})
+		ON CREATE SET u.version = 0
+		SET
+			u.name = $name,
+			u.password = $password,
+			u.version = coalesce(u.version, 1) + 1
+		RETURN u;`,
+		map[string]interface{}{
+			"name":     userData.Name,
+			"password": userData.Password,
+			"email":    userData.Email,
+		},
+	)
+	return err
+}
+
+func (r *UserRepository) Update(ctx context.Context, userData user.User) (user.User, error) {
+	params := map[string]interface{}{
+		"name":     userData.Name,
+		"password": nullable(userData.Password),
+		"email":    userData.Email,
+		"version":  userData.Version,
+	}
+
+	var updated user.User
+	err := r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		result, err := tx.Run(ctx,
+			`MATCH (u:User {email: $email})
+			WHERE `+versionMatches("u")+`
+			SET
+				u.name = $name,
+				u.password = coalesce($password, u.password),
+				u.version = coalesce(u.version, 1) + 1
+			RETURN u.name AS name, u.email AS email, u.version AS version;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Records) == 0 {
+			return staleOrMissing(ctx, tx, userExists, params)
+		}
+
+		row, err := record.Decode[userRow](result.Records[0])
+		if err != nil {
+			return err
+		}
+
+		updated = user.User{Name: row.Name, Email: row.Email, Version: row.Version}
+		return nil
+	})
+	if err != nil {
+		return user.User{}, err
+	}
+
+	return updated, nil
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]user.User, error) {
+	result, err := r.read(ctx,
+		`MATCH (u:User)
+		RETURN u.name AS name, u.email AS email, u.password AS password, coalesce(u.version, 1) AS version`,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[userRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []user.User
+	for _, row := range rows {
+		users = append(users, user.User{
+			Name:     row.Name,
+			Email:    row.Email,
+			Password: row.Password,
+			Version:  row.Version,
+		})
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (user.User, error) {
+	result, err := r.read(ctx,
+		`MATCH (u:User{email: $email})
+		RETURN u.name AS name, u.email AS email, coalesce(u.score, 0) AS score,
+			coalesce(u.daily_streak, 0) AS dailyStreak, coalesce(u.weekly_streak, 0) AS weeklyStreak,
+			coalesce(u.version, 1) AS version`,
+		map[string]interface{}{"email": email},
+	)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	if len(result.Records) == 0 {
+		return user.User{}, repository.ErrNotFound
+	}
+
+	row, err := record.Decode[userRow](result.Records[0])
+	if err != nil {
+		return user.User{}, err
+	}
+
+	return user.User{
+		Name:         row.Name,
+		Email:        row.Email,
+		Score:        row.Score,
+		DailyStreak:  row.DailyStreak,
+		WeeklyStreak: row.WeeklyStreak,
+		Version:      row.Version,
+	}, nil
+}
+
+func (r *UserRepository) DeleteByEmail(ctx context.Context, email string, version int64) error {
+	params := map[string]interface{}{"email": email, "version": version}
+
+	return r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		result, err := tx.Run(ctx,
+			`MATCH (u:User {email: $email})
+			WHERE `+versionMatches("u")+`
+			DETACH DELETE u
+			RETURN $email AS email;`,
+			params,
+		)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Records) > 0 {
+			return nil
+		}
+
+		// Excluir um usuário que não existe não é erro
+		err = staleOrMissing(ctx, tx, userExists, params)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	})
+}
+
+// userExists encontra o usuário $email
+const userExists = `MATCH (u:User {email: $email}) RETURN u.email AS email;`
@@ -0,0 +1,376 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/database/record"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/webhook"
+)
+
+type WebhookRepository struct {
+	base
+}
+
+func NewWebhookRepository(db *database.DatabaseHandler) *WebhookRepository {
+	return &WebhookRepository{base{db}}
+}
+
+// Webhooks ficam ligados à casa, as entregas ao webhook e as tentativas à entrega:
+//
+//	(:Home)-[:HAS_WEBHOOK]->(:Webhook)-[:SENT]->(:WebhookDelivery)-[:TRIED]->(:WebhookAttempt)
+const webhookFields = `w.id as id, home.id as home, w.url as url, w.events as events,
+	w.secret as secret, w.created_by as createdBy, w.created_at as createdAt`
+
+type webhookRow struct {
+	ID        uuid.UUID     `neo4j:"id"`
+	Home      string        `neo4j:"home"`
+	URL       string        `neo4j:"url"`
+	Events    []events.Type `neo4j:"events"`
+	Secret    string        `neo4j:"secret"`
+	CreatedBy string        `neo4j:"createdBy"`
+	CreatedAt time.Time     `neo4j:"createdAt"`
+}
+
+func (row webhookRow) webhook() webhook.Webhook {
+	return webhook.Webhook(row)
+}
+
+type deliveryRow struct {
+	ID            uuid.UUID      `neo4j:"id"`
+	Webhook       uuid.UUID      `neo4j:"webhook"`
+	Event         events.Type    `neo4j:"event"`
+	Payload       string         `neo4j:"payload"`
+	Status        webhook.Status `neo4j:"status"`
+	NextAttemptAt *time.Time     `neo4j:"nextAttemptAt"`
+	AttemptCount  int            `neo4j:"attemptCount"`
+	CreatedAt     time.Time      `neo4j:"createdAt"`
+	URL           string         `neo4j:"url,optional"`
+	Secret        string         `neo4j:"secret,optional"`
+	Attempts      []attemptRow   `neo4j:"attempts"`
+}
+
+type attemptRow struct {
+	Number     int       `neo4j:"number"`
+	At         time.Time `neo4j:"at"`
+	StatusCode int       `neo4j:"statusCode,optional"`
+	Error      string    `neo4j:"error,optional"`
+	DurationMs int64     `neo4j:"durationMs"`
+}
+
+func (row deliveryRow) delivery() webhook.Delivery {
+	delivery := webhook.Delivery{
+		ID:            row.ID,
+		Webhook:       row.Webhook,
+		Event:         row.Event,
+		Payload:       json.RawMessage(row.Payload),
+		Status:        row.Status,
+		NextAttemptAt: row.NextAttemptAt,
+		AttemptCount:  row.AttemptCount,
+		CreatedAt:     row.CreatedAt,
+		URL:           row.URL,
+		Secret:        row.Secret,
+	}
+	for _, attempt := range row.Attempts {
+		delivery.Attempts = append(delivery.Attempts, webhook.Attempt(attempt))
+	}
+	return delivery
+}
+
+// checkAdmin retorna repository.ErrForbidden se adminEmail não administrar a casa ativa
+func checkAdmin(ctx context.Context, tx database.Tx, adminEmail string, homeID string) error {
+	result, err := tx.Run(ctx,
+		`MATCH (:User {email: $email})-[:LIVES_IN {role: $role}]->(home:Home {id: $home})
+		RETURN home.id as id;`,
+		map[string]interface{}{
+			"email": adminEmail,
+			"role":  home.Admin,
+			"home":  homeID,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if len(result.Records) == 0 {
+		return repository.ErrForbidden
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, adminEmail string, hook webhook.Webhook) error {
+	return r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		if err := checkAdmin(ctx, tx, adminEmail, hook.Home); err != nil {
+			return err
+		}
+
+		eventTypes := make([]string, 0, len(hook.Events))
+		for _, eventType := range hook.Events {
+			eventTypes = append(eventTypes, string(eventType))
+		}
+
+		_, err := tx.Run(ctx,
+			`MATCH (home:Home {id: $home})
+			CREATE (home)-[:HAS_WEBHOOK]->(:Webhook {
+				id: $id, url: $url, events: $events, secret: $secret,
+				created_by: $createdBy, created_at: $createdAt
+			});`,
+			map[string]interface{}{
+				"home":      hook.Home,
+				"id":        hook.ID.String(),
+				"url":       hook.URL,
+				"events":    eventTypes,
+				"secret":    hook.Secret,
+				"createdBy": hook.CreatedBy,
+				"createdAt": hook.CreatedAt,
+			},
+		)
+		return err
+	})
+}
+
+func (r *WebhookRepository) FindByHome(ctx context.Context, adminEmail string, homeID string) ([]webhook.Webhook, error) {
+	var hooks []webhook.Webhook
+
+	err := r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		if err := checkAdmin(ctx, tx, adminEmail, homeID); err != nil {
+			return err
+		}
+
+		result, err := tx.Run(ctx,
+			`MATCH (home:Home {id: $home})-[:HAS_WEBHOOK]->(w:Webhook)
+			RETURN `+webhookFields+`
+			ORDER BY w.created_at;`,
+			map[string]interface{}{"home": homeID},
+		)
+		if err != nil {
+			return err
+		}
+
+		rows, err := record.DecodeAll[webhookRow](result.Records)
+		if err != nil {
+			return err
+		}
+
+		hooks = nil
+		for _, row := range rows {
+			hooks = append(hooks, row.webhook())
+		}
+		return nil
+	})
+
+	return hooks, err
+}
+
+func (r *WebhookRepository) FindByID(ctx context.Context, adminEmail string, homeID string, id string) (webhook.Webhook, error) {
+	var hook webhook.Webhook
+
+	err := r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		if err := checkAdmin(ctx, tx, adminEmail, homeID); err != nil {
+			return err
+		}
+
+		result, err := tx.Run(ctx,
+			`MATCH (home:Home {id: $home})-[:HAS_WEBHOOK]->(w:Webhook {id: $id})
+			RETURN `+webhookFields+`;`,
+			map[string]interface{}{"home": homeID, "id": id},
+		)
+		if err != nil {
+			return err
+		}
+		if len(result.Records) == 0 {
+			return repository.ErrNotFound
+		}
+
+		row, err := record.Decode[webhookRow](result.Records[0])
+		hook = row.webhook()
+		return err
+	})
+
+	return hook, err
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, adminEmail string, homeID string, id string) error {
+	return r.db.UnitOfWork(ctx, func(tx database.Tx) error {
+		if err := checkAdmin(ctx, tx, adminEmail, homeID); err != nil {
+			return err
+		}
+
+		result, err := tx.Run(ctx,
+			`MATCH (:Home {id: $home})-[:HAS_WEBHOOK]->(w:Webhook {id: $id})
+			OPTIONAL MATCH (w)-[:SENT]->(d:WebhookDelivery)
+			OPTIONAL MATCH (d)-[:TRIED]->(a:WebhookAttempt)
+			DETACH DELETE a, d, w
+			RETURN count(DISTINCT w) as deleted;`,
+			map[string]interface{}{"home": homeID, "id": id},
+		)
+		if err != nil {
+			return err
+		}
+
+		row, err := record.Decode[struct {
+			Deleted int64 `neo4j:"deleted"`
+		}](result.Records[0])
+		if err != nil {
+			return err
+		}
+		if row.Deleted == 0 {
+			return repository.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *WebhookRepository) Subscribed(ctx context.Context, homeID string, eventType events.Type) ([]webhook.Webhook, error) {
+	result, err := r.read(ctx,
+		`MATCH (home:Home {id: $home})-[:HAS_WEBHOOK]->(w:Webhook)
+		WHERE $event IN w.events
+		RETURN `+webhookFields+`;`,
+		map[string]interface{}{
+			"home":  homeID,
+			"event": string(eventType),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := record.DecodeAll[webhookRow](result.Records)
+	if err != nil {
+		return nil, err
+	}
+
+	var hooks []webhook.Webhook
+	for _, row := range rows {
+		hooks = append(hooks, row.webhook())
+	}
+	return hooks, nil
+}
+
+func (r *WebhookRepository) Enqueue(ctx context.Context, deliveries []webhook.Delivery) error {
+	rows := make([]map[string]interface{}, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		rows = append(rows, map[string]interface{}{
+			"id":            delivery.ID.String(),
+			"webhook":       delivery.Webhook.String(),
+			"event":         string(delivery.Event),
+			"payload":       string(delivery.Payload),
+			"status":        string(delivery.Status),
+			"nextAttemptAt": delivery.NextAttemptAt,
+			"attemptCount":  delivery.AttemptCount,
+			"createdAt":     delivery.CreatedAt,
+		})
+	}
+
+	_, err := r.execute(ctx,
+		`UNWIND $deliveries as delivery
+		MATCH (w:Webhook {id: delivery.webhook})
+		CREATE (w)-[:SENT]->(:WebhookDelivery {
+			id: delivery.id, event: delivery.event, payload: delivery.payload,
+			status: delivery.status, next_attempt_at: delivery.nextAttemptAt,
+			attempt_count: delivery.attemptCount, created_at: delivery.createdAt
+		});`,
+		map[string]interface{}{"deliveries": rows},
+	)
+	return err
+}
+
+func (r *WebhookRepository) Due(ctx context.Context, now time.Time, limit int) ([]webhook.Delivery, error) {
+	result, err := r.read(ctx,
+		`MATCH (w:Webhook)-[:SENT]->(d:WebhookDelivery {status: $status})
+		WHERE d.next_attempt_at <= $now
+		RETURN d.id as id, w.id as webhook, d.event as event, d.payload as payload, d.status as status,
+			d.next_attempt_at as nextAttemptAt, d.attempt_count as attemptCount, d.created_at as createdAt,
+			w.url as url, w.secret as secret, [] as attempts
+		ORDER BY d.next_attempt_at
+		LIMIT $limit;`,
+		map[string]interface{}{
+			"status": string(webhook.Pending),
+			"now":    now,
+			"limit":  limit,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeDeliveries(result.Records)
+}
+
+func (r *WebhookRepository) Record(ctx context.Context, delivery webhook.Delivery, attempt webhook.Attempt) error {
+	result, err := r.execute(ctx,
+		`MATCH (d:WebhookDelivery {id: $id})
+		SET d.status = $status,
+			d.next_attempt_at = $nextAttemptAt,
+			d.attempt_count = $attemptCount
+		CREATE (d)-[:TRIED]->(:WebhookAttempt {
+			number: $number, at: $at, status_code: $statusCode, error: $error, duration_ms: $durationMs
+		})
+		RETURN d.id as id;`,
+		map[string]interface{}{
+			"id":            delivery.ID.String(),
+			"status":        string(delivery.Status),
+			"nextAttemptAt": delivery.NextAttemptAt,
+			"attemptCount":  delivery.AttemptCount,
+			"number":        attempt.Number,
+			"at":            attempt.At,
+			"statusCode":    attempt.StatusCode,
+			"error":         nullable(attempt.Error),
+			"durationMs":    attempt.DurationMs,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if len(result.Records) == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Deliveries(ctx context.Context, id string, limit int) ([]webhook.Delivery, error) {
+	result, err := r.read(ctx,
+		`MATCH (w:Webhook {id: $id})-[:SENT]->(d:WebhookDelivery)
+		WITH w, d
+		ORDER BY d.created_at DESC
+		LIMIT $limit
+		OPTIONAL MATCH (d)-[:TRIED]->(a:WebhookAttempt)
+		WITH w, d, a
+		ORDER BY a.number
+		WITH w, d, collect(a {
+			.number, .at, statusCode: a.status_code, .error, durationMs: a.duration_ms
+		}) as attempts
+		RETURN d.id as id, w.id as webhook, d.event as event, d.payload as payload, d.status as status,
+			d.next_attempt_at as nextAttemptAt, d.attempt_count as attemptCount, d.created_at as createdAt,
+			attempts
+		ORDER BY d.created_at DESC;`,
+		map[string]interface{}{
+			"id":    id,
+			"limit": limit,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeDeliveries(result.Records)
+}
+
+func decodeDeliveries(records []*neo4j.Record) ([]webhook.Delivery, error) {
+	rows, err := record.DecodeAll[deliveryRow](records)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]webhook.Delivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, row.delivery())
+	}
+	return deliveries, nil
+}
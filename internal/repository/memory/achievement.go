@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/nsbnroque/go-to-do-list/achievement"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+)
+
+type AchievementRepository struct {
+	store *Store
+}
+
+func NewAchievementRepository(store *Store) *AchievementRepository {
+	return &AchievementRepository{store}
+}
+
+func (r *AchievementRepository) Progress(ctx context.Context, email string) ([]achievement.Completion, int64, map[string]bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, found := r.store.users[email]
+	if !found {
+		return nil, 0, nil, repository.ErrNotFound
+	}
+
+	var completions []achievement.Completion
+	for _, c := range u.Completions {
+		completions = append(completions, achievement.Completion{Task: c.Task, At: c.At})
+	}
+
+	// A posição no ranking conta os vizinhos, de qualquer casa, com mais pontos
+	ahead := map[string]bool{}
+	for _, h := range r.store.homesOf(email) {
+		for _, other := range h.Residents {
+			if o, found := r.store.users[other]; found && o.Score > u.Score {
+				ahead[other] = true
+			}
+		}
+	}
+	rank := int64(len(ahead)) + 1
+
+	earned := map[string]bool{}
+	for _, a := range u.Achievements {
+		earned[a.ID] = true
+	}
+
+	return completions, rank, earned, nil
+}
+
+func (r *AchievementRepository) Save(ctx context.Context, email string, achievements []achievement.Achievement) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, found := r.store.users[email]
+	if !found {
+		return nil
+	}
+
+	earned := map[string]bool{}
+	for _, a := range u.Achievements {
+		earned[a.ID] = true
+	}
+
+	for _, a := range achievements {
+		if !earned[a.ID] {
+			u.Achievements = append(u.Achievements, a)
+			earned[a.ID] = true
+		}
+	}
+
+	return nil
+}
+
+func (r *AchievementRepository) FindByUser(ctx context.Context, email string) ([]achievement.Achievement, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, found := r.store.users[email]
+	if !found {
+		return nil, nil
+	}
+
+	achievements := append([]achievement.Achievement(nil), u.Achievements...)
+	sort.SliceStable(achievements, func(i, j int) bool {
+		return achievements[i].EarnedAt.Before(achievements[j].EarnedAt)
+	})
+
+	return achievements, nil
+}
@@ -0,0 +1,408 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/backup"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/reward"
+	"github.com/nsbnroque/go-to-do-list/user"
+	"github.com/nsbnroque/go-to-do-list/webhook"
+)
+
+type BackupRepository struct {
+	store *Store
+}
+
+func NewBackupRepository(store *Store) *BackupRepository {
+	return &BackupRepository{store}
+}
+
+func (r *BackupRepository) Export(ctx context.Context) (backup.Archive, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var archive backup.Archive
+
+	var emails []string
+	for email := range r.store.users {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+
+	for _, email := range emails {
+		u := r.store.users[email]
+		archive.Users = append(archive.Users, backup.User{
+			Email:           u.Email,
+			Name:            u.Name,
+			Password:        u.Password,
+			Score:           u.Score,
+			DailyStreak:     u.DailyStreak,
+			WeeklyStreak:    u.WeeklyStreak,
+			LastCompletedAt: optionalTime(u.LastCompletedAt),
+		})
+
+		for _, c := range u.Completions {
+			archive.Completions = append(archive.Completions, backup.Completion{
+				User:   email,
+				Home:   c.Home,
+				Task:   c.Task,
+				At:     c.At,
+				Reward: c.Reward,
+				Bonus:  c.Bonus,
+			})
+		}
+
+		for _, a := range u.Achievements {
+			archive.Achievements = append(archive.Achievements, backup.Achievement{
+				Achievement: a,
+				User:        email,
+			})
+		}
+	}
+
+	var homes []*homeRecord
+	for _, h := range r.store.homes {
+		homes = append(homes, h)
+	}
+	for _, h := range r.store.deletedHomes {
+		homes = append(homes, h)
+	}
+	sort.Slice(homes, func(i, j int) bool {
+		return homes[i].ID.String() < homes[j].ID.String()
+	})
+
+	for _, h := range homes {
+		homeData := backup.Home{
+			ID:        h.ID,
+			Name:      h.Name,
+			Settings:  h.Settings,
+			DeletedAt: optionalTime(h.DeletedAt),
+			DeletedBy: h.DeletedBy,
+		}
+
+		for _, email := range h.Residents {
+			homeData.Residents = append(homeData.Residents, backup.Resident{
+				Email: email,
+				Role:  h.Roles[email],
+			})
+		}
+
+		for _, name := range h.TaskOrder {
+			homeData.Tasks = append(homeData.Tasks, choreBackup(h.Tasks[name]))
+		}
+
+		var trash []string
+		for name := range h.Trash {
+			trash = append(trash, name)
+		}
+		sort.Strings(trash)
+		for _, name := range trash {
+			homeData.Tasks = append(homeData.Tasks, choreBackup(h.Trash[name]))
+		}
+
+		archive.Homes = append(archive.Homes, homeData)
+	}
+
+	var rewardIDs []string
+	for id := range r.store.rewards {
+		rewardIDs = append(rewardIDs, id)
+	}
+	sort.Strings(rewardIDs)
+
+	for _, id := range rewardIDs {
+		rw := r.store.rewards[id]
+		archive.Rewards = append(archive.Rewards, backup.Reward{
+			Reward: rw.Reward,
+			Home:   rw.Home,
+		})
+	}
+
+	for _, rd := range r.store.redemptions {
+		archive.Redemptions = append(archive.Redemptions, backup.Redemption{
+			ID:          rd.ID,
+			Reward:      rd.RewardID,
+			User:        rd.User,
+			Cost:        rd.Cost,
+			RedeemedAt:  rd.RedeemedAt,
+			Fulfilled:   rd.Fulfilled,
+			FulfilledAt: optionalTime(rd.FulfilledAt),
+		})
+	}
+
+	for _, e := range r.store.entries {
+		archive.ScoreEntries = append(archive.ScoreEntries, backup.ScoreEntry{
+			Entry:    e.Entry,
+			User:     e.User,
+			Home:     e.Home,
+			WaivedAt: optionalTime(e.WaivedAt),
+		})
+	}
+
+	var webhookIDs []string
+	for id := range r.store.webhooks {
+		webhookIDs = append(webhookIDs, id)
+	}
+	sort.Strings(webhookIDs)
+
+	for _, id := range webhookIDs {
+		hook := r.store.webhooks[id]
+		archive.Webhooks = append(archive.Webhooks, backup.Webhook{
+			ID:        hook.ID,
+			Home:      hook.Home,
+			URL:       hook.URL,
+			Events:    hook.Events,
+			Secret:    hook.Secret,
+			CreatedBy: hook.CreatedBy,
+			CreatedAt: hook.CreatedAt,
+		})
+	}
+
+	return archive, nil
+}
+
+func (r *BackupRepository) Import(ctx context.Context, archive backup.Archive) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, userData := range archive.Users {
+		u, found := r.store.users[userData.Email]
+		if !found {
+			u = &userRecord{}
+			r.store.users[userData.Email] = u
+		}
+
+		u.User = user.User{
+			Email:        userData.Email,
+			Name:         userData.Name,
+			Password:     userData.Password,
+			Score:        userData.Score,
+			DailyStreak:  userData.DailyStreak,
+			WeeklyStreak: userData.WeeklyStreak,
+			// As versões não vão para o backup; a restauração conta como uma gravação
+			Version: u.Version + 1,
+		}
+		u.LastCompletedAt = timeOf(userData.LastCompletedAt)
+	}
+
+	for _, homeData := range archive.Homes {
+		id := homeData.ID.String()
+
+		h, found := r.store.anyHome(id)
+		if !found {
+			h = &homeRecord{
+				ID:    homeData.ID,
+				Roles: map[string]home.Role{},
+				Tasks: map[string]*choreRecord{},
+				Trash: map[string]*choreRecord{},
+			}
+		}
+
+		h.Name = homeData.Name
+		h.Settings = homeData.Settings
+		h.DeletedAt = timeOf(homeData.DeletedAt)
+		h.DeletedBy = homeData.DeletedBy
+		h.Version++
+
+		delete(r.store.homes, id)
+		delete(r.store.deletedHomes, id)
+		if h.DeletedAt.IsZero() {
+			r.store.homes[id] = h
+		} else {
+			r.store.deletedHomes[id] = h
+		}
+
+		for _, resident := range homeData.Residents {
+			if _, found := r.store.users[resident.Email]; !found {
+				continue
+			}
+			r.store.addResident(h, resident.Email, resident.Role)
+			h.Roles[resident.Email] = resident.Role
+		}
+
+		for _, taskData := range homeData.Tasks {
+			chore := choreFromBackup(taskData)
+			if previous, found := h.Tasks[chore.Name]; found {
+				chore.Version = previous.Version
+			} else if previous, found := h.Trash[chore.Name]; found {
+				chore.Version = previous.Version
+			}
+			chore.Version++
+
+			if !chore.DeletedAt.IsZero() {
+				h.Trash[chore.Name] = chore
+				continue
+			}
+
+			if _, found := h.Tasks[chore.Name]; !found {
+				h.TaskOrder = append(h.TaskOrder, chore.Name)
+			}
+			h.Tasks[chore.Name] = chore
+		}
+	}
+
+	for _, rewardData := range archive.Rewards {
+		r.store.rewards[rewardData.ID.String()] = &rewardRecord{
+			Reward: rewardData.Reward,
+			Home:   rewardData.Home,
+		}
+	}
+
+	for _, redemptionData := range archive.Redemptions {
+		rd := &redemptionRecord{
+			Redemption: reward.Redemption{
+				ID:         redemptionData.ID,
+				User:       redemptionData.User,
+				Cost:       redemptionData.Cost,
+				RedeemedAt: redemptionData.RedeemedAt,
+				Fulfilled:  redemptionData.Fulfilled,
+			},
+			RewardID: redemptionData.Reward,
+		}
+		rd.FulfilledAt = timeOf(redemptionData.FulfilledAt)
+		if rw, found := r.store.rewards[redemptionData.Reward]; found {
+			rd.Reward = rw.Name
+		}
+
+		replaced := false
+		for i, existing := range r.store.redemptions {
+			if existing.ID == rd.ID {
+				r.store.redemptions[i] = rd
+				replaced = true
+			}
+		}
+		if !replaced {
+			r.store.redemptions = append(r.store.redemptions, rd)
+		}
+	}
+
+	for _, completionData := range archive.Completions {
+		u, found := r.store.users[completionData.User]
+		if !found {
+			continue
+		}
+
+		c := completionRecord{
+			Home:   completionData.Home,
+			Task:   completionData.Task,
+			At:     completionData.At,
+			Reward: completionData.Reward,
+			Bonus:  completionData.Bonus,
+		}
+
+		duplicate := false
+		for _, existing := range u.Completions {
+			if existing.Home == c.Home && existing.Task == c.Task && existing.At.Equal(c.At) {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			u.Completions = append(u.Completions, c)
+		}
+	}
+
+	for _, achievementData := range archive.Achievements {
+		u, found := r.store.users[achievementData.User]
+		if !found {
+			continue
+		}
+
+		replaced := false
+		for i, existing := range u.Achievements {
+			if existing.ID == achievementData.ID {
+				u.Achievements[i] = achievementData.Achievement
+				replaced = true
+			}
+		}
+		if !replaced {
+			u.Achievements = append(u.Achievements, achievementData.Achievement)
+		}
+	}
+
+	for _, entryData := range archive.ScoreEntries {
+		e := &entryRecord{
+			Entry: entryData.Entry,
+			User:  entryData.User,
+			Home:  entryData.Home,
+		}
+		e.WaivedAt = timeOf(entryData.WaivedAt)
+
+		replaced := false
+		for i, existing := range r.store.entries {
+			if existing.ID == e.ID {
+				r.store.entries[i] = e
+				replaced = true
+			}
+		}
+		if !replaced {
+			r.store.entries = append(r.store.entries, e)
+		}
+	}
+
+	// Um webhook já cadastrado é substituído, mas mantém suas entregas
+	for _, webhookData := range archive.Webhooks {
+		r.store.webhooks[webhookData.ID.String()] = &webhook.Webhook{
+			ID:        webhookData.ID,
+			Home:      webhookData.Home,
+			URL:       webhookData.URL,
+			Events:    webhookData.Events,
+			Secret:    webhookData.Secret,
+			CreatedBy: webhookData.CreatedBy,
+			CreatedAt: webhookData.CreatedAt,
+		}
+	}
+
+	return nil
+}
+
+func choreBackup(chore *choreRecord) backup.Task {
+	return backup.Task{
+		Name:             chore.Name,
+		Reward:           chore.Reward,
+		Recurrence:       chore.Recurrence,
+		Status:           chore.Status,
+		Streak:           chore.Streak,
+		LastCompletedAt:  optionalTime(chore.LastCompletedAt),
+		PendingSince:     optionalTime(chore.PendingSince),
+		Assignee:         chore.Assignee,
+		DueAt:            chore.DueAt,
+		OverduePenalized: chore.OverduePenalized,
+		DeletedAt:        optionalTime(chore.DeletedAt),
+		DeletedBy:        chore.DeletedBy,
+	}
+}
+
+func choreFromBackup(taskData backup.Task) *choreRecord {
+	return &choreRecord{
+		Name:             taskData.Name,
+		Reward:           taskData.Reward,
+		Recurrence:       taskData.Recurrence,
+		Status:           taskData.Status,
+		Streak:           taskData.Streak,
+		LastCompletedAt:  timeOf(taskData.LastCompletedAt),
+		PendingSince:     timeOf(taskData.PendingSince),
+		Assignee:         taskData.Assignee,
+		DueAt:            taskData.DueAt,
+		OverduePenalized: taskData.OverduePenalized,
+		DeletedAt:        timeOf(taskData.DeletedAt),
+		DeletedBy:        taskData.DeletedBy,
+	}
+}
+
+// optionalTime converte horários vazios em nil
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// timeOf converte nil no horário vazio
+func timeOf(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
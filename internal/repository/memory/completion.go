@@ -0,0 +1,291 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/score"
+	"github.com/nsbnroque/go-to-do-list/streak"
+	syncchannel "github.com/nsbnroque/go-to-do-list/sync_channel"
+	"github.com/nsbnroque/go-to-do-list/task"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+type CompletionRepository struct {
+	store *Store
+}
+
+func NewCompletionRepository(store *Store) *CompletionRepository {
+	return &CompletionRepository{store}
+}
+
+func (r *CompletionRepository) Complete(ctx context.Context, email string, homeID string, taskName string, complete func(syncchannel.CompletionState) syncchannel.Completion) (user.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, chore := r.store.openChore(email, homeID, taskName)
+	if chore == nil {
+		return user.User{}, repository.ErrNotFound
+	}
+
+	u := r.store.users[email]
+	completion := complete(syncchannel.CompletionState{
+		Settings:     h.Settings,
+		Reward:       chore.Reward,
+		Recurrence:   chore.Recurrence,
+		PendingSince: chore.PendingSince,
+		Chore:        streak.Streak{Current: chore.Streak, LastAt: chore.LastCompletedAt},
+		Daily:        streak.Streak{Current: u.DailyStreak, LastAt: u.LastCompletedAt},
+		Weekly:       streak.Streak{Current: u.WeeklyStreak, LastAt: u.LastCompletedAt},
+	})
+
+	chore.Status = task.Finished
+	chore.Streak = 0
+	if completion.ChoreStreak != nil {
+		chore.Streak = *completion.ChoreStreak
+	}
+	chore.LastCompletedAt = completion.At
+	chore.PendingSince = time.Time{}
+	chore.Version++
+
+	u.Score += completion.Reward + completion.Bonus
+	u.DailyStreak = completion.Daily
+	u.WeeklyStreak = completion.Weekly
+	u.LastCompletedAt = completion.At
+	u.Version++
+	u.Completions = append(u.Completions, completionRecord{
+		Home:   h.ID.String(),
+		Task:   taskName,
+		At:     completion.At,
+		Reward: completion.Reward,
+		Bonus:  completion.Bonus,
+	})
+
+	return user.User{
+		Name:         u.Name,
+		Email:        u.Email,
+		Score:        u.Score,
+		DailyStreak:  u.DailyStreak,
+		WeeklyStreak: u.WeeklyStreak,
+		Version:      u.Version,
+	}, nil
+}
+
+func (r *CompletionRepository) Assign(ctx context.Context, adminEmail string, homeID string, taskName string, assignment task.Assignment) (task.Task, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, h := range r.store.adminHomesOf(adminEmail, homeID) {
+		chore, found := h.Tasks[taskName]
+		if _, lives := h.Roles[assignment.Assignee]; !found || !lives {
+			continue
+		}
+
+		dueAt := assignment.DueAt
+		chore.Assignee = assignment.Assignee
+		chore.DueAt = &dueAt
+		chore.OverduePenalized = false
+		chore.Version++
+
+		return task.Task{
+			Name:     taskName,
+			Reward:   chore.Reward,
+			Status:   chore.Status,
+			Version:  chore.Version,
+			Assignee: assignment.Assignee,
+			DueAt:    &dueAt,
+		}, nil
+	}
+
+	return task.Task{}, repository.ErrNotFound
+}
+
+func (r *CompletionRepository) Reject(ctx context.Context, adminEmail string, homeID string, taskName string, penalty score.Entry) (string, int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, h := range r.store.adminHomesOf(adminEmail, homeID) {
+		chore, found := h.Tasks[taskName]
+		if !found || chore.Status != task.Finished {
+			continue
+		}
+
+		// O último morador da casa a concluir a tarefa é quem recebe a penalidade
+		var resident *userRecord
+		var lastAt time.Time
+		for _, email := range h.Residents {
+			u, found := r.store.users[email]
+			if !found {
+				continue
+			}
+			for _, c := range u.Completions {
+				if c.Home == h.ID.String() && c.Task == taskName && !c.At.Before(lastAt) {
+					resident, lastAt = u, c.At
+				}
+			}
+		}
+		if resident == nil {
+			continue
+		}
+
+		chore.Status = task.Pending
+		chore.PendingSince = penalty.At
+		chore.Version++
+
+		amount := h.Settings.Penalties.Rejected
+		if amount > 0 {
+			penalty.Amount = -amount
+			r.store.penalize(resident, h, penalty)
+		}
+
+		return resident.Email, amount, nil
+	}
+
+	return "", 0, repository.ErrNotFound
+}
+
+func (r *CompletionRepository) RecurringChores(ctx context.Context) ([]syncchannel.Chore, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var chores []syncchannel.Chore
+	for _, h := range r.store.homes {
+		for _, name := range h.TaskOrder {
+			chore := h.Tasks[name]
+
+			days := chore.Recurrence.Days()
+			if days == 0 || chore.LastCompletedAt.IsZero() {
+				continue
+			}
+
+			chores = append(chores, syncchannel.Chore{
+				Home:      h.ID.String(),
+				Task:      chore.Name,
+				Days:      days,
+				Status:    chore.Status,
+				Streak:    streak.Streak{Current: chore.Streak, LastAt: chore.LastCompletedAt},
+				GraceDays: h.Settings.GraceDays,
+			})
+		}
+	}
+
+	return chores, nil
+}
+
+func (r *CompletionRepository) UpdateChores(ctx context.Context, updates []syncchannel.ChoreUpdate, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, update := range updates {
+		h, found := r.store.homes[update.Home]
+		if !found {
+			continue
+		}
+		chore, found := h.Tasks[update.Task]
+		if !found {
+			continue
+		}
+
+		if update.Reopen {
+			chore.PendingSince = now
+		}
+		chore.Status = update.Status
+		chore.Streak = update.Streak
+		chore.Version++
+	}
+
+	return nil
+}
+
+func (r *CompletionRepository) ResidentStreaks(ctx context.Context) ([]syncchannel.ResidentStreak, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var residents []syncchannel.ResidentStreak
+	for _, u := range r.store.users {
+		homes := r.store.homesOf(u.Email)
+		if len(homes) == 0 || u.LastCompletedAt.IsZero() || (u.DailyStreak <= 0 && u.WeeklyStreak <= 0) {
+			continue
+		}
+
+		resident := syncchannel.ResidentStreak{
+			Email:  u.Email,
+			Daily:  streak.Streak{Current: u.DailyStreak, LastAt: u.LastCompletedAt},
+			Weekly: streak.Streak{Current: u.WeeklyStreak, LastAt: u.LastCompletedAt},
+		}
+
+		// Moradores de mais de uma casa recebem a maior tolerância entre elas
+		for _, h := range homes {
+			if h.Settings.GraceDays > resident.GraceDays {
+				resident.GraceDays = h.Settings.GraceDays
+			}
+		}
+
+		residents = append(residents, resident)
+	}
+
+	return residents, nil
+}
+
+func (r *CompletionRepository) UpdateResidentStreaks(ctx context.Context, streaks []syncchannel.ResidentStreak) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, resident := range streaks {
+		if u, found := r.store.users[resident.Email]; found {
+			u.DailyStreak = resident.Daily.Current
+			u.WeeklyStreak = resident.Weekly.Current
+			u.Version++
+		}
+	}
+
+	return nil
+}
+
+func (r *CompletionRepository) PenalizeOverdue(ctx context.Context, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, h := range r.store.homes {
+		for _, name := range h.TaskOrder {
+			chore := h.Tasks[name]
+			if chore.Assignee == "" || chore.DueAt == nil || !chore.DueAt.Before(now) ||
+				chore.Status == task.Finished || chore.OverduePenalized {
+				continue
+			}
+
+			u, found := r.store.users[chore.Assignee]
+			if _, lives := h.Roles[chore.Assignee]; !found || !lives {
+				continue
+			}
+
+			chore.OverduePenalized = true
+
+			if amount := h.Settings.Penalties.Overdue; amount > 0 {
+				r.store.penalize(u, h, score.Entry{
+					ID:     uuid.New(),
+					Kind:   score.Penalty,
+					Reason: score.Overdue,
+					Task:   chore.Name,
+					Amount: -amount,
+					At:     now,
+				})
+			}
+		}
+	}
+
+	return nil
+}
+
+// openChore procura a tarefa ainda não concluída nas casas do usuário, ou só na
+// casa homeID quando informada
+func (s *Store) openChore(email string, homeID string, taskName string) (*homeRecord, *choreRecord) {
+	for _, h := range s.homesIn(email, homeID) {
+		if chore, found := h.Tasks[taskName]; found && chore.Status != task.Finished {
+			return h, chore
+		}
+	}
+	return nil, nil
+}
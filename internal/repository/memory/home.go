@@ -0,0 +1,299 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+type HomeRepository struct {
+	store *Store
+}
+
+func NewHomeRepository(store *Store) *HomeRepository {
+	return &HomeRepository{store}
+}
+
+func (r *HomeRepository) Create(ctx context.Context, ownerEmail string, homeData home.Home) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, found := r.store.users[ownerEmail]; !found {
+		return repository.ErrNotFound
+	}
+
+	h, found := r.store.homes[homeData.ID.String()]
+	if !found {
+		h = &homeRecord{
+			ID:       homeData.ID,
+			Name:     homeData.Name,
+			Settings: home.DefaultSettings(),
+			Roles:    map[string]home.Role{},
+			Tasks:    map[string]*choreRecord{},
+			Trash:    map[string]*choreRecord{},
+		}
+		r.store.homes[homeData.ID.String()] = h
+	}
+	r.store.addResident(h, ownerEmail, home.Admin)
+
+	return nil
+}
+
+func (r *HomeRepository) AddResident(ctx context.Context, email string, id string, resident user.User) (home.Home, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	homes := r.store.homesIn(email, id)
+	if len(homes) == 0 {
+		return home.Home{}, repository.ErrNotFound
+	}
+
+	// O morador é criado apenas com o email, como no MERGE do Neo4j
+	if _, found := r.store.users[resident.Email]; !found {
+		r.store.users[resident.Email] = &userRecord{User: user.User{Email: resident.Email, Version: 1}}
+	}
+
+	for _, h := range homes {
+		r.store.addResident(h, resident.Email, home.Resident)
+	}
+
+	return r.store.homeData(homes[len(homes)-1]), nil
+}
+
+func (r *HomeRepository) FindByID(ctx context.Context, id string) (home.Home, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, found := r.store.homes[id]
+	if !found || len(h.Residents) == 0 {
+		return home.Home{}, repository.ErrNotFound
+	}
+
+	return r.store.homeData(h), nil
+}
+
+func (r *HomeRepository) Update(ctx context.Context, adminEmail string, homeData home.Home) (home.Home, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, found := r.store.homes[homeData.ID.String()]
+	if !found || len(h.Residents) == 0 {
+		return home.Home{}, repository.ErrNotFound
+	}
+	if h.Roles[adminEmail] != home.Admin {
+		return home.Home{}, repository.ErrForbidden
+	}
+	if homeData.Version != 0 && h.Version != homeData.Version {
+		return home.Home{}, repository.ErrStaleVersion
+	}
+
+	h.Name = homeData.Name
+	h.Version++
+
+	return r.store.homeData(h), nil
+}
+
+// Delete move a casa para deletedHomes; os moradores continuam ligados a ela
+// para poder consultar a lixeira e restaurá-la
+func (r *HomeRepository) Delete(ctx context.Context, id string, deletedBy string, version int64, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, found := r.store.homes[id]
+	if !found || len(h.Residents) == 0 {
+		return repository.ErrNotFound
+	}
+	if h.Roles[deletedBy] != home.Admin {
+		return repository.ErrForbidden
+	}
+	if version != 0 && h.Version != version {
+		return repository.ErrStaleVersion
+	}
+
+	h.DeletedAt = at
+	h.DeletedBy = deletedBy
+	h.Version++
+	r.store.deletedHomes[id] = h
+	delete(r.store.homes, id)
+
+	return nil
+}
+
+func (r *HomeRepository) Trash(ctx context.Context, email string, id string) ([]home.TrashItem, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, found := r.store.anyHome(id)
+	if !found {
+		return nil, repository.ErrNotFound
+	}
+	if _, resident := h.Roles[email]; !resident {
+		return nil, repository.ErrNotFound
+	}
+
+	var trash []home.TrashItem
+	if !h.DeletedAt.IsZero() {
+		trash = append(trash, home.TrashItem{
+			Kind:      home.TrashHome,
+			Name:      h.Name,
+			DeletedAt: h.DeletedAt,
+			DeletedBy: h.DeletedBy,
+		})
+	}
+
+	var tasks []home.TrashItem
+	for _, chore := range h.Trash {
+		tasks = append(tasks, home.TrashItem{
+			Kind:      home.TrashTask,
+			Name:      chore.Name,
+			DeletedAt: chore.DeletedAt,
+			DeletedBy: chore.DeletedBy,
+		})
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].DeletedAt.After(tasks[j].DeletedAt)
+	})
+
+	return append(trash, tasks...), nil
+}
+
+func (r *HomeRepository) Restore(ctx context.Context, email string, id string, restoration home.Restoration) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if restoration.Kind == home.TrashHome {
+		h, found := r.store.deletedHomes[id]
+		if !found {
+			return repository.ErrNotFound
+		}
+		role, resident := h.Roles[email]
+		if !resident {
+			return repository.ErrNotFound
+		}
+		if role != home.Admin {
+			return repository.ErrForbidden
+		}
+
+		h.DeletedAt = time.Time{}
+		h.DeletedBy = ""
+		h.Version++
+		r.store.homes[id] = h
+		delete(r.store.deletedHomes, id)
+
+		return nil
+	}
+
+	h, found := r.store.homes[id]
+	if !found {
+		return repository.ErrNotFound
+	}
+	if _, resident := h.Roles[email]; !resident {
+		return repository.ErrNotFound
+	}
+
+	chore, found := h.Trash[restoration.Name]
+	if !found {
+		return repository.ErrNotFound
+	}
+
+	chore.DeletedAt = time.Time{}
+	chore.DeletedBy = ""
+	chore.Version++
+	h.Tasks[chore.Name] = chore
+	h.TaskOrder = append(h.TaskOrder, chore.Name)
+	delete(h.Trash, chore.Name)
+
+	return nil
+}
+
+func (r *HomeRepository) Feed(ctx context.Context, id string, limit int) ([]home.FeedItem, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, found := r.store.homes[id]
+	if !found {
+		return nil, nil
+	}
+
+	var feed []home.FeedItem
+	for _, email := range h.Residents {
+		u, found := r.store.users[email]
+		if !found {
+			continue
+		}
+
+		for _, a := range u.Achievements {
+			feed = append(feed, home.FeedItem{
+				Type:    "achievement",
+				User:    email,
+				Message: fmt.Sprintf("Conquista desbloqueada: %v", a.Name),
+				At:      a.EarnedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(feed, func(i, j int) bool {
+		return feed[i].At.After(feed[j].At)
+	})
+
+	if len(feed) > limit {
+		feed = feed[:limit]
+	}
+
+	return feed, nil
+}
+
+func (r *HomeRepository) Settings(ctx context.Context, id string) (home.Settings, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	h, found := r.store.homes[id]
+	if !found {
+		return home.Settings{}, repository.ErrNotFound
+	}
+
+	return h.Settings, nil
+}
+
+func (r *HomeRepository) UpdateSettings(ctx context.Context, adminEmail string, id string, settings home.Settings) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	homes := r.store.adminHomesOf(adminEmail, id)
+	if len(homes) == 0 {
+		return repository.ErrForbidden
+	}
+
+	for _, h := range homes {
+		h.Settings = settings
+		h.Version++
+	}
+
+	return nil
+}
+
+// homeData monta a casa com nome e email dos moradores
+func (s *Store) homeData(h *homeRecord) home.Home {
+	homeData := home.Home{
+		ID:      h.ID,
+		Name:    h.Name,
+		Version: h.Version,
+	}
+
+	for _, email := range h.Residents {
+		if u, found := s.users[email]; found {
+			homeData.Residents = append(homeData.Residents, user.User{
+				Name:    u.Name,
+				Email:   u.Email,
+				Version: u.Version,
+			})
+		}
+	}
+
+	return homeData
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/idempotency"
+)
+
+type IdempotencyRepository struct {
+	store *Store
+}
+
+func NewIdempotencyRepository(store *Store) *IdempotencyRepository {
+	return &IdempotencyRepository{store}
+}
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key idempotency.Key, now time.Time) (idempotency.Key, bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if existing, found := r.store.idempotencyKeys[key.Key]; found && existing.ExpiresAt.After(now) {
+		return existing, false, nil
+	}
+
+	key.Response = nil
+	r.store.idempotencyKeys[key.Key] = key
+	return key, true, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, response idempotency.Response) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if reserved, found := r.store.idempotencyKeys[key]; found {
+		reserved.Response = &response
+		r.store.idempotencyKeys[key] = reserved
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.idempotencyKeys, key)
+	return nil
+}
+
+func (r *IdempotencyRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	purged := 0
+	for k, key := range r.store.idempotencyKeys {
+		if key.ExpiresAt.Before(before) {
+			delete(r.store.idempotencyKeys, k)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/maintenance"
+	"github.com/nsbnroque/go-to-do-list/webhook"
+)
+
+type MaintenanceRepository struct {
+	store *Store
+}
+
+func NewMaintenanceRepository(store *Store) *MaintenanceRepository {
+	return &MaintenanceRepository{store}
+}
+
+// Orphans não procura tarefas soltas porque aqui elas só existem dentro de uma casa
+func (r *MaintenanceRepository) Orphans(ctx context.Context) ([]maintenance.Orphan, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var orphans []maintenance.Orphan
+
+	for _, homes := range []map[string]*homeRecord{r.store.homes, r.store.deletedHomes} {
+		for id, h := range homes {
+			if len(h.Residents) == 0 {
+				orphans = append(orphans, maintenance.Orphan{Kind: maintenance.Home, ID: id})
+			}
+		}
+	}
+
+	for id, rw := range r.store.rewards {
+		if _, found := r.store.anyHome(rw.Home); !found {
+			orphans = append(orphans, maintenance.Orphan{Kind: maintenance.Reward, ID: id})
+		}
+	}
+
+	for _, rd := range r.store.redemptions {
+		_, rewardFound := r.store.rewards[rd.RewardID]
+		_, userFound := r.store.users[rd.User]
+		if !rewardFound || !userFound {
+			orphans = append(orphans, maintenance.Orphan{Kind: maintenance.Redemption, ID: rd.ID.String()})
+		}
+	}
+
+	for _, e := range r.store.entries {
+		_, userFound := r.store.users[e.User]
+		_, homeFound := r.store.anyHome(e.Home)
+		if !userFound || !homeFound {
+			orphans = append(orphans, maintenance.Orphan{Kind: maintenance.ScoreEntry, ID: e.ID.String()})
+		}
+	}
+
+	return orphans, nil
+}
+
+func (r *MaintenanceRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	purged := 0
+
+	for id, h := range r.store.deletedHomes {
+		if !h.DeletedAt.Before(before) {
+			continue
+		}
+		purged += 1 + len(h.Trash)
+		r.store.purgeHome(h)
+		delete(r.store.deletedHomes, id)
+	}
+
+	for _, h := range r.store.homes {
+		for name, chore := range h.Trash {
+			if chore.DeletedAt.Before(before) {
+				delete(h.Trash, name)
+				purged++
+			}
+		}
+	}
+
+	return purged, nil
+}
+
+// purgeHome descarta o que só existe por causa da casa: recompensas, resgates,
+// penalidades, conclusões e webhooks, além do vínculo com os moradores
+func (s *Store) purgeHome(h *homeRecord) {
+	id := h.ID.String()
+
+	for _, email := range h.Residents {
+		if u, found := s.users[email]; found {
+			u.Homes = removeString(u.Homes, id)
+		}
+	}
+
+	for _, u := range s.users {
+		var completions []completionRecord
+		for _, c := range u.Completions {
+			if c.Home != id {
+				completions = append(completions, c)
+			}
+		}
+		u.Completions = completions
+	}
+
+	var redemptions []*redemptionRecord
+	for _, rd := range s.redemptions {
+		if rw, found := s.rewards[rd.RewardID]; !found || rw.Home != id {
+			redemptions = append(redemptions, rd)
+		}
+	}
+	s.redemptions = redemptions
+
+	for rewardID, rw := range s.rewards {
+		if rw.Home == id {
+			delete(s.rewards, rewardID)
+		}
+	}
+
+	var entries []*entryRecord
+	for _, e := range s.entries {
+		if e.Home != id {
+			entries = append(entries, e)
+		}
+	}
+	s.entries = entries
+
+	s.deleteWebhooks(func(hook *webhook.Webhook) bool { return hook.Home == id })
+}
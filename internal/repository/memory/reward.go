@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/reward"
+)
+
+type RewardRepository struct {
+	store *Store
+}
+
+func NewRewardRepository(store *Store) *RewardRepository {
+	return &RewardRepository{store}
+}
+
+func (r *RewardRepository) Create(ctx context.Context, adminEmail string, homeID string, rewardData reward.Reward) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	homes := r.store.adminHomesOf(adminEmail, homeID)
+	if len(homes) == 0 {
+		return repository.ErrForbidden
+	}
+
+	r.store.rewards[rewardData.ID.String()] = &rewardRecord{
+		Reward: rewardData,
+		Home:   homes[0].ID.String(),
+	}
+
+	return nil
+}
+
+func (r *RewardRepository) FindByUser(ctx context.Context, email string, homeID string) ([]reward.Reward, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var rewards []reward.Reward
+	for _, rw := range r.store.rewardsOf(email, homeID) {
+		rewards = append(rewards, rw.Reward)
+	}
+
+	sort.SliceStable(rewards, func(i, j int) bool {
+		return rewards[i].Cost < rewards[j].Cost
+	})
+
+	return rewards, nil
+}
+
+func (r *RewardRepository) Redeem(ctx context.Context, email string, homeID string, rewardID string, redemption reward.Redemption) (reward.Redemption, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, found := r.store.users[email]
+	if !found {
+		return redemption, repository.ErrConflict
+	}
+
+	rw, found := r.store.rewardsOf(email, homeID)[rewardID]
+	if !found || rw.Stock <= 0 || u.Score < rw.Cost {
+		return redemption, repository.ErrConflict
+	}
+
+	rw.Stock--
+	u.Score -= rw.Cost
+	u.Version++
+
+	redemption.Reward = rw.Name
+	redemption.Cost = rw.Cost
+	r.store.redemptions = append(r.store.redemptions, &redemptionRecord{
+		Redemption: redemption,
+		RewardID:   rewardID,
+	})
+
+	return redemption, nil
+}
+
+func (r *RewardRepository) Redemptions(ctx context.Context, email string, homeID string) ([]reward.Redemption, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	rewards := r.store.rewardsOf(email, homeID)
+
+	var redemptions []reward.Redemption
+	for _, rd := range r.store.redemptions {
+		if _, found := rewards[rd.RewardID]; found {
+			redemptions = append(redemptions, rd.Redemption)
+		}
+	}
+
+	sort.SliceStable(redemptions, func(i, j int) bool {
+		return redemptions[i].RedeemedAt.After(redemptions[j].RedeemedAt)
+	})
+
+	return redemptions, nil
+}
+
+func (r *RewardRepository) Fulfill(ctx context.Context, adminEmail string, homeID string, id string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, h := range r.store.adminHomesOf(adminEmail, homeID) {
+		for _, rd := range r.store.redemptions {
+			rw, found := r.store.rewards[rd.RewardID]
+			if !found || rw.Home != h.ID.String() || rd.ID.String() != id {
+				continue
+			}
+
+			rd.Fulfilled = true
+			rd.FulfilledAt = at
+			return nil
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
+// rewardsOf retorna as recompensas oferecidas nas casas do usuário, ou só na casa
+// homeID quando informada, indexadas pelo id
+func (s *Store) rewardsOf(email string, homeID string) map[string]*rewardRecord {
+	homes := map[string]bool{}
+	for _, h := range s.homesIn(email, homeID) {
+		homes[h.ID.String()] = true
+	}
+
+	rewards := map[string]*rewardRecord{}
+	for id, rw := range s.rewards {
+		if homes[rw.Home] {
+			rewards[id] = rw
+		}
+	}
+	return rewards
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/score"
+)
+
+type ScoreRepository struct {
+	store *Store
+}
+
+func NewScoreRepository(store *Store) *ScoreRepository {
+	return &ScoreRepository{store}
+}
+
+func (r *ScoreRepository) History(ctx context.Context, email string, limit int) ([]score.Entry, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var entries []score.Entry
+	for _, e := range r.store.entries {
+		if e.User == email {
+			entries = append(entries, e.Entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].At.After(entries[j].At)
+	})
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
+
+func (r *ScoreRepository) Waive(ctx context.Context, adminEmail string, homeID string, id string, waiver score.Waiver, at time.Time) (score.Entry, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	homes := map[string]bool{}
+	for _, h := range r.store.adminHomesOf(adminEmail, homeID) {
+		homes[h.ID.String()] = true
+	}
+
+	for _, e := range r.store.entries {
+		if e.ID.String() != id || !homes[e.Home] || e.Kind != score.Penalty || e.Waived {
+			continue
+		}
+
+		u, found := r.store.users[e.User]
+		if !found {
+			break
+		}
+
+		e.Waived = true
+		e.WaivedBy = adminEmail
+		e.WaiveReason = waiver.Reason
+		e.WaivedAt = at
+		u.Score -= e.Amount
+		u.Version++
+
+		return e.Entry, nil
+	}
+
+	return score.Entry{}, repository.ErrNotFound
+}
@@ -0,0 +1,205 @@
+// Package memory implementa os repositórios da aplicação em memória, sem
+// dependências externas. Os dados se perdem ao encerrar o processo, então o
+// backend serve para desenvolvimento e para exercitar a API sem o Neo4j.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/achievement"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/idempotency"
+	"github.com/nsbnroque/go-to-do-list/reward"
+	"github.com/nsbnroque/go-to-do-list/score"
+	"github.com/nsbnroque/go-to-do-list/task"
+	"github.com/nsbnroque/go-to-do-list/user"
+	"github.com/nsbnroque/go-to-do-list/webhook"
+)
+
+// Store guarda todos os dados e é compartilhado pelos repositórios.
+// Cada operação segura o mutex do início ao fim, o que a torna atômica
+// como as consultas equivalentes no Neo4j.
+type Store struct {
+	mu sync.Mutex
+
+	users       map[string]*userRecord
+	homes       map[string]*homeRecord
+	rewards     map[string]*rewardRecord
+	redemptions []*redemptionRecord
+	entries     []*entryRecord
+
+	// Casas na lixeira ficam fora de homes, e assim fora de todas as consultas
+	deletedHomes map[string]*homeRecord
+
+	idempotencyKeys map[string]idempotency.Key
+
+	webhooks map[string]*webhook.Webhook
+	// Entregas na ordem em que foram gravadas
+	deliveries []*webhook.Delivery
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:        map[string]*userRecord{},
+		homes:        map[string]*homeRecord{},
+		deletedHomes: map[string]*homeRecord{},
+		rewards:      map[string]*rewardRecord{},
+
+		idempotencyKeys: map[string]idempotency.Key{},
+		webhooks:        map[string]*webhook.Webhook{},
+	}
+}
+
+type userRecord struct {
+	user.User
+	LastCompletedAt time.Time
+
+	// Casas do usuário, na ordem em que passou a morar nelas
+	Homes        []string
+	Completions  []completionRecord
+	Achievements []achievement.Achievement
+}
+
+type completionRecord struct {
+	Home   string
+	Task   string
+	At     time.Time
+	Reward int64
+	Bonus  int64
+}
+
+type homeRecord struct {
+	ID       uuid.UUID
+	Name     string
+	Settings home.Settings
+	Version  int64
+
+	// Moradores na ordem de entrada e o papel de cada um
+	Residents []string
+	Roles     map[string]home.Role
+
+	Tasks     map[string]*choreRecord
+	TaskOrder []string
+	// Tarefas excluídas, pelo nome
+	Trash map[string]*choreRecord
+
+	DeletedAt time.Time
+	DeletedBy string
+}
+
+type choreRecord struct {
+	Name             string
+	Reward           int64
+	Recurrence       task.Recurrence
+	Status           task.Status
+	Streak           int64
+	LastCompletedAt  time.Time
+	PendingSince     time.Time
+	Assignee         string
+	DueAt            *time.Time
+	OverduePenalized bool
+	Version          int64
+
+	DeletedAt time.Time
+	DeletedBy string
+}
+
+type rewardRecord struct {
+	reward.Reward
+	Home string
+}
+
+type redemptionRecord struct {
+	reward.Redemption
+	RewardID    string
+	FulfilledAt time.Time
+}
+
+type entryRecord struct {
+	score.Entry
+	User     string
+	Home     string
+	WaivedAt time.Time
+}
+
+// homesOf retorna as casas em que o usuário mora
+func (s *Store) homesOf(email string) []*homeRecord {
+	u, found := s.users[email]
+	if !found {
+		return nil
+	}
+
+	var homes []*homeRecord
+	for _, id := range u.Homes {
+		if h, found := s.homes[id]; found {
+			homes = append(homes, h)
+		}
+	}
+	return homes
+}
+
+// homesIn retorna a casa id do usuário ou, com id vazio, todas as suas casas
+func (s *Store) homesIn(email string, id string) []*homeRecord {
+	var homes []*homeRecord
+	for _, h := range s.homesOf(email) {
+		if id == "" || h.ID.String() == id {
+			homes = append(homes, h)
+		}
+	}
+	return homes
+}
+
+// anyHome procura a casa entre as ativas e as que estão na lixeira
+func (s *Store) anyHome(id string) (*homeRecord, bool) {
+	if h, found := s.homes[id]; found {
+		return h, true
+	}
+	h, found := s.deletedHomes[id]
+	return h, found
+}
+
+// adminHomesOf retorna a casa id, ou com id vazio todas as casas, administradas pelo usuário
+func (s *Store) adminHomesOf(email string, id string) []*homeRecord {
+	var homes []*homeRecord
+	for _, h := range s.homesIn(email, id) {
+		if h.Roles[email] == home.Admin {
+			homes = append(homes, h)
+		}
+	}
+	return homes
+}
+
+func (s *Store) addResident(h *homeRecord, email string, role home.Role) {
+	if _, found := h.Roles[email]; found {
+		return
+	}
+	h.Residents = append(h.Residents, email)
+	h.Roles[email] = role
+	h.Version++
+
+	u := s.users[email]
+	u.Homes = append(u.Homes, h.ID.String())
+}
+
+// penalize desconta os pontos do morador e registra a penalidade no histórico
+func (s *Store) penalize(u *userRecord, h *homeRecord, entry score.Entry) {
+	u.Score += entry.Amount
+	u.Version++
+	s.entries = append(s.entries, &entryRecord{
+		Entry: entry,
+		User:  u.Email,
+		Home:  h.ID.String(),
+	})
+}
+
+func removeString(values []string, value string) []string {
+	var result []string
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
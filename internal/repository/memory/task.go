@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/task"
+)
+
+type TaskRepository struct {
+	store *Store
+}
+
+func NewTaskRepository(store *Store) *TaskRepository {
+	return &TaskRepository{store}
+}
+
+func (r *TaskRepository) Save(ctx context.Context, email string, homeID string, taskData task.Task) (task.Task, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	homes := r.store.homesIn(email, homeID)
+	if len(homes) == 0 {
+		return task.Task{}, repository.ErrNotFound
+	}
+
+	now := time.Now()
+	var saved *choreRecord
+	for _, h := range homes {
+		delete(h.Trash, taskData.Name)
+
+		chore, found := h.Tasks[taskData.Name]
+		if !found {
+			chore = &choreRecord{
+				Name:         taskData.Name,
+				PendingSince: now,
+			}
+			h.Tasks[taskData.Name] = chore
+			h.TaskOrder = append(h.TaskOrder, taskData.Name)
+		} else {
+			// Tarefa reaberta volta a valorizar a partir de agora
+			if chore.Status != task.Pending || chore.PendingSince.IsZero() {
+				chore.PendingSince = now
+			}
+			chore.Status = task.Pending
+		}
+
+		chore.Reward = taskData.Reward
+		chore.Recurrence = taskData.Recurrence
+		chore.Version++
+		saved = chore
+	}
+
+	return task.Task{
+		Name:       taskData.Name,
+		Reward:     taskData.Reward,
+		Recurrence: taskData.Recurrence,
+		Version:    saved.Version,
+	}, nil
+}
+
+func (r *TaskRepository) Update(ctx context.Context, email string, homeID string, taskData task.Task) (task.Task, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	chores, err := r.store.choresOf(email, homeID, taskData.Name, taskData.Version)
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	for _, chore := range chores {
+		chore.Reward = taskData.Reward
+		chore.Status = taskData.Status
+		chore.Version++
+	}
+
+	updated := chores[len(chores)-1]
+	return task.Task{
+		Name:    updated.Name,
+		Reward:  updated.Reward,
+		Status:  updated.Status,
+		Version: updated.Version,
+	}, nil
+}
+
+func (r *TaskRepository) Delete(ctx context.Context, email string, homeID string, name string, version int64, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, err := r.store.choresOf(email, homeID, name, version); err != nil {
+		return err
+	}
+
+	for _, h := range r.store.homesIn(email, homeID) {
+		if chore, found := h.Tasks[name]; found {
+			chore.DeletedAt = at
+			chore.DeletedBy = email
+			h.Trash[name] = chore
+
+			delete(h.Tasks, name)
+			h.TaskOrder = removeString(h.TaskOrder, name)
+		}
+	}
+
+	return nil
+}
+
+// choresOf retorna a tarefa em cada casa do usuário, ou só na casa homeID quando
+// informada. Com version diferente de zero, todas precisam estar nessa versão,
+// como na gravação condicional dos outros repositórios.
+func (s *Store) choresOf(email string, homeID string, name string, version int64) ([]*choreRecord, error) {
+	var chores []*choreRecord
+	for _, h := range s.homesIn(email, homeID) {
+		if chore, found := h.Tasks[name]; found {
+			if version != 0 && chore.Version != version {
+				return nil, repository.ErrStaleVersion
+			}
+			chores = append(chores, chore)
+		}
+	}
+
+	if len(chores) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return chores, nil
+}
+
+func (r *TaskRepository) FindByUser(ctx context.Context, email string, homeID string) ([]task.Entry, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var entries []task.Entry
+	for _, h := range r.store.homesIn(email, homeID) {
+		for _, name := range h.TaskOrder {
+			chore := h.Tasks[name]
+
+			entry := task.Entry{
+				Task: task.Task{
+					Name:       chore.Name,
+					Reward:     chore.Reward,
+					Status:     chore.Status,
+					Recurrence: chore.Recurrence,
+					Streak:     chore.Streak,
+					Version:    chore.Version,
+					Assignee:   chore.Assignee,
+					DueAt:      chore.DueAt,
+				},
+				PendingSince: chore.PendingSince,
+				Pricing:      h.Settings.Pricing,
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
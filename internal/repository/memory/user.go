@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+type UserRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store}
+}
+
+func (r *UserRepository) Save(ctx context.Context, userData user.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, found := r.store.users[userData.Email]
+	if !found {
+		u = &userRecord{User: user.User{Email: userData.Email}}
+		r.store.users[userData.Email] = u
+	}
+	u.Name = userData.Name
+	u.Password = userData.Password
+	u.Version++
+
+	return nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, userData user.User) (user.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, found := r.store.users[userData.Email]
+	if !found {
+		return user.User{}, repository.ErrNotFound
+	}
+	if userData.Version != 0 && u.Version != userData.Version {
+		return user.User{}, repository.ErrStaleVersion
+	}
+	u.Name = userData.Name
+	if userData.Password != "" {
+		u.Password = userData.Password
+	}
+	u.Version++
+
+	return user.User{Name: u.Name, Email: u.Email, Version: u.Version}, nil
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]user.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var users []user.User
+	for _, u := range r.store.users {
+		users = append(users, user.User{
+			Name:     u.Name,
+			Email:    u.Email,
+			Password: u.Password,
+			Version:  u.Version,
+		})
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Email < users[j].Email
+	})
+
+	return users, nil
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (user.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, found := r.store.users[email]
+	if !found {
+		return user.User{}, repository.ErrNotFound
+	}
+
+	return user.User{
+		Name:         u.Name,
+		Email:        u.Email,
+		Score:        u.Score,
+		DailyStreak:  u.DailyStreak,
+		WeeklyStreak: u.WeeklyStreak,
+		Version:      u.Version,
+	}, nil
+}
+
+func (r *UserRepository) DeleteByEmail(ctx context.Context, email string, version int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, found := r.store.users[email]
+	if !found {
+		return nil
+	}
+	if version != 0 && u.Version != version {
+		return repository.ErrStaleVersion
+	}
+
+	// Assim como o DETACH DELETE, remove o usuário das casas e descarta seus registros
+	for _, id := range u.Homes {
+		if h, found := r.store.anyHome(id); found {
+			h.Residents = removeString(h.Residents, email)
+			delete(h.Roles, email)
+		}
+	}
+
+	var redemptions []*redemptionRecord
+	for _, rd := range r.store.redemptions {
+		if rd.User != email {
+			redemptions = append(redemptions, rd)
+		}
+	}
+	r.store.redemptions = redemptions
+
+	var entries []*entryRecord
+	for _, e := range r.store.entries {
+		if e.User != email {
+			entries = append(entries, e)
+		}
+	}
+	r.store.entries = entries
+
+	delete(r.store.users, email)
+	return nil
+}
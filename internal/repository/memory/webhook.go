@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/webhook"
+)
+
+type WebhookRepository struct {
+	store *Store
+}
+
+func NewWebhookRepository(store *Store) *WebhookRepository {
+	return &WebhookRepository{store}
+}
+
+// adminHome retorna a casa ativa administrada por adminEmail
+func (s *Store) adminHome(adminEmail string, id string) (*homeRecord, error) {
+	h, found := s.homes[id]
+	if !found || h.Roles[adminEmail] != home.Admin {
+		return nil, repository.ErrForbidden
+	}
+	return h, nil
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, adminEmail string, hook webhook.Webhook) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, err := r.store.adminHome(adminEmail, hook.Home); err != nil {
+		return err
+	}
+
+	r.store.webhooks[hook.ID.String()] = &hook
+	return nil
+}
+
+func (r *WebhookRepository) FindByHome(ctx context.Context, adminEmail string, id string) ([]webhook.Webhook, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, err := r.store.adminHome(adminEmail, id); err != nil {
+		return nil, err
+	}
+
+	var hooks []webhook.Webhook
+	for _, hook := range r.store.webhooks {
+		if hook.Home == id {
+			hooks = append(hooks, *hook)
+		}
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].CreatedAt.Before(hooks[j].CreatedAt)
+	})
+	return hooks, nil
+}
+
+func (r *WebhookRepository) FindByID(ctx context.Context, adminEmail string, homeID string, id string) (webhook.Webhook, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	hook, err := r.store.homeWebhook(adminEmail, homeID, id)
+	if err != nil {
+		return webhook.Webhook{}, err
+	}
+	return *hook, nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, adminEmail string, homeID string, id string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, err := r.store.homeWebhook(adminEmail, homeID, id); err != nil {
+		return err
+	}
+
+	r.store.deleteWebhooks(func(hook *webhook.Webhook) bool { return hook.ID.String() == id })
+	return nil
+}
+
+func (r *WebhookRepository) Subscribed(ctx context.Context, homeID string, eventType events.Type) ([]webhook.Webhook, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var hooks []webhook.Webhook
+	for _, hook := range r.store.webhooks {
+		if _, active := r.store.homes[hook.Home]; active && hook.Home == homeID && hook.Subscribes(eventType) {
+			hooks = append(hooks, *hook)
+		}
+	}
+	return hooks, nil
+}
+
+func (r *WebhookRepository) Enqueue(ctx context.Context, deliveries []webhook.Delivery) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		d := delivery
+		d.URL = ""
+		d.Secret = ""
+		r.store.deliveries = append(r.store.deliveries, &d)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Due(ctx context.Context, now time.Time, limit int) ([]webhook.Delivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var due []webhook.Delivery
+	for _, delivery := range r.store.deliveries {
+		if len(due) == limit {
+			break
+		}
+		if delivery.Status != webhook.Pending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+
+		hook, found := r.store.webhooks[delivery.Webhook.String()]
+		if !found {
+			continue
+		}
+		d := *delivery
+		d.Attempts = nil
+		d.URL = hook.URL
+		d.Secret = hook.Secret
+		due = append(due, d)
+	}
+	return due, nil
+}
+
+func (r *WebhookRepository) Record(ctx context.Context, delivery webhook.Delivery, attempt webhook.Attempt) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, d := range r.store.deliveries {
+		if d.ID == delivery.ID {
+			d.Status = delivery.Status
+			d.NextAttemptAt = delivery.NextAttemptAt
+			d.AttemptCount = delivery.AttemptCount
+			d.Attempts = append(d.Attempts, attempt)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *WebhookRepository) Deliveries(ctx context.Context, id string, limit int) ([]webhook.Delivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var deliveries []webhook.Delivery
+	for i := len(r.store.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if d := r.store.deliveries[i]; d.Webhook.String() == id {
+			delivery := *d
+			delivery.Attempts = append([]webhook.Attempt(nil), d.Attempts...)
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// homeWebhook retorna o webhook da casa administrada por adminEmail
+func (s *Store) homeWebhook(adminEmail string, homeID string, id string) (*webhook.Webhook, error) {
+	if _, err := s.adminHome(adminEmail, homeID); err != nil {
+		return nil, err
+	}
+
+	hook, found := s.webhooks[id]
+	if !found || hook.Home != homeID {
+		return nil, repository.ErrNotFound
+	}
+	return hook, nil
+}
+
+// deleteWebhooks remove os webhooks selecionados e suas entregas
+func (s *Store) deleteWebhooks(selected func(hook *webhook.Webhook) bool) {
+	for id, hook := range s.webhooks {
+		if selected(hook) {
+			delete(s.webhooks, id)
+		}
+	}
+
+	var deliveries []*webhook.Delivery
+	for _, d := range s.deliveries {
+		if _, found := s.webhooks[d.Webhook.String()]; found {
+			deliveries = append(deliveries, d)
+		}
+	}
+	s.deliveries = deliveries
+}
@@ -0,0 +1,13 @@
+package repository
+
+import "errors"
+
+// Erros comuns a todas as implementações de repositório, para que os handlers
+// escolham o status HTTP sem depender do banco de dados utilizado
+var (
+	ErrNotFound  = errors.New("registro não encontrado")
+	ErrForbidden = errors.New("operação permitida apenas a administradores da residência")
+	ErrConflict  = errors.New("operação conflita com o estado atual do registro")
+	// ErrStaleVersion indica que o registro mudou desde a versão informada pelo cliente
+	ErrStaleVersion = errors.New("registro alterado desde a versão informada")
+)
@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/nsbnroque/go-to-do-list/achievement"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+)
+
+type AchievementRepository struct {
+	base
+}
+
+func NewAchievementRepository(db *database.SQLiteHandler) *AchievementRepository {
+	return &AchievementRepository{base{db}}
+}
+
+func (r *AchievementRepository) Progress(ctx context.Context, email string) ([]achievement.Completion, int64, map[string]bool, error) {
+	// A posição no ranking conta os vizinhos, de qualquer casa, com mais pontos
+	var rank int64
+	err := r.db.DB.QueryRowContext(ctx,
+		`SELECT 1 + (
+			SELECT count(DISTINCT o.email)
+			FROM residents me
+			JOIN residents o ON o.home_id = me.home_id
+			JOIN homes h ON h.id = me.home_id
+			JOIN users other ON other.email = o.email
+			WHERE me.email = u.email AND other.score > u.score AND h.deleted_at IS NULL
+		)
+		FROM users u
+		WHERE u.email = ?`,
+		email,
+	).Scan(&rank)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT task, at FROM completions WHERE email = ?`,
+		email,
+	)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer rows.Close()
+
+	var completions []achievement.Completion
+	for rows.Next() {
+		var completion achievement.Completion
+		if err := rows.Scan(&completion.Task, &completion.At); err != nil {
+			return nil, 0, nil, err
+		}
+		completions = append(completions, completion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, nil, err
+	}
+
+	earned := map[string]bool{}
+	achievements, err := r.FindByUser(ctx, email)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	for _, a := range achievements {
+		earned[a.ID] = true
+	}
+
+	return completions, rank, earned, nil
+}
+
+func (r *AchievementRepository) Save(ctx context.Context, email string, achievements []achievement.Achievement) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for _, a := range achievements {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO achievements (email, id, name, description, earned_at)
+				SELECT email, ?, ?, ?, ? FROM users WHERE email = ?
+				ON CONFLICT (email, id) DO NOTHING`,
+				a.ID, a.Name, a.Description, timestamp(a.EarnedAt), email,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *AchievementRepository) FindByUser(ctx context.Context, email string) ([]achievement.Achievement, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT id, name, description, earned_at FROM achievements
+		WHERE email = ?
+		ORDER BY earned_at`,
+		email,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var achievements []achievement.Achievement
+	for rows.Next() {
+		var a achievement.Achievement
+		if err := rows.Scan(&a.ID, &a.Name, &a.Description, &a.EarnedAt); err != nil {
+			return nil, err
+		}
+		achievements = append(achievements, a)
+	}
+
+	return achievements, rows.Err()
+}
@@ -0,0 +1,540 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/backup"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/task"
+)
+
+type BackupRepository struct {
+	base
+}
+
+func NewBackupRepository(db *database.SQLiteHandler) *BackupRepository {
+	return &BackupRepository{base{db}}
+}
+
+// Export lê tudo numa única transação, para que o arquivo seja consistente
+func (r *BackupRepository) Export(ctx context.Context) (backup.Archive, error) {
+	var archive backup.Archive
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		archive = backup.Archive{}
+
+		steps := []func(ctx context.Context, tx *sql.Tx, archive *backup.Archive) error{
+			exportUsers, exportHomes, exportRewards, exportRedemptions,
+			exportCompletions, exportAchievements, exportScoreEntries, exportWebhooks,
+		}
+		for _, step := range steps {
+			if err := step(ctx, tx, &archive); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return archive, err
+}
+
+func exportUsers(ctx context.Context, tx *sql.Tx, archive *backup.Archive) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT email, name, password, score, daily_streak, weekly_streak, last_completed_at
+		FROM users ORDER BY email`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u backup.User
+		var lastAt sql.NullTime
+		if err := rows.Scan(&u.Email, &u.Name, &u.Password, &u.Score, &u.DailyStreak, &u.WeeklyStreak, &lastAt); err != nil {
+			return err
+		}
+		u.LastCompletedAt = optionalTime(lastAt)
+		archive.Users = append(archive.Users, u)
+	}
+
+	return rows.Err()
+}
+
+func exportHomes(ctx context.Context, tx *sql.Tx, archive *backup.Archive) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT h.id, h.name, `+settingsColumns+`, h.deleted_at, coalesce(h.deleted_by, '')
+		FROM homes h ORDER BY h.id`,
+	)
+	if err != nil {
+		return err
+	}
+
+	index := map[string]int{}
+	for rows.Next() {
+		var h backup.Home
+		var deletedAt sql.NullTime
+		dest := append([]interface{}{&h.ID, &h.Name}, settingsDest(&h.Settings)...)
+		dest = append(dest, &deletedAt, &h.DeletedBy)
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return err
+		}
+		h.DeletedAt = optionalTime(deletedAt)
+
+		index[h.ID.String()] = len(archive.Homes)
+		archive.Homes = append(archive.Homes, h)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = tx.QueryContext(ctx, `SELECT home_id, email, role FROM residents ORDER BY rowid`)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var homeID string
+		var resident backup.Resident
+		if err := rows.Scan(&homeID, &resident.Email, &resident.Role); err != nil {
+			rows.Close()
+			return err
+		}
+		if i, found := index[homeID]; found {
+			archive.Homes[i].Residents = append(archive.Homes[i].Residents, resident)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Tarefas ativas primeiro, na ordem de criação, depois as da lixeira
+	queries := []string{
+		`SELECT ` + taskColumns + `, NULL, NULL FROM tasks ORDER BY rowid`,
+		`SELECT ` + taskColumns + `, deleted_at, deleted_by FROM deleted_tasks ORDER BY home_id, name`,
+	}
+	for _, query := range queries {
+		if err := exportTasks(ctx, tx, query, archive, index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportTasks(ctx context.Context, tx *sql.Tx, query string, archive *backup.Archive, index map[string]int) error {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var homeID string
+		var t backup.Task
+		var recurrence, status, assignee, deletedBy sql.NullString
+		var lastAt, pendingSince, dueAt, deletedAt sql.NullTime
+		err := rows.Scan(&homeID, &t.Name, &t.Reward, &recurrence, &status, &t.Streak, &lastAt,
+			&pendingSince, &assignee, &dueAt, &t.OverduePenalized, &deletedAt, &deletedBy)
+		if err != nil {
+			return err
+		}
+
+		t.Recurrence = task.Recurrence(recurrence.String)
+		t.Status = task.Status(status.String)
+		t.Assignee = assignee.String
+		t.LastCompletedAt = optionalTime(lastAt)
+		t.PendingSince = optionalTime(pendingSince)
+		t.DueAt = optionalTime(dueAt)
+		t.DeletedAt = optionalTime(deletedAt)
+		t.DeletedBy = deletedBy.String
+
+		if i, found := index[homeID]; found {
+			archive.Homes[i].Tasks = append(archive.Homes[i].Tasks, t)
+		}
+	}
+
+	return rows.Err()
+}
+
+func exportRewards(ctx context.Context, tx *sql.Tx, archive *backup.Archive) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, home_id, name, cost, stock FROM rewards ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rw backup.Reward
+		if err := rows.Scan(&rw.ID, &rw.Home, &rw.Name, &rw.Cost, &rw.Stock); err != nil {
+			return err
+		}
+		archive.Rewards = append(archive.Rewards, rw)
+	}
+
+	return rows.Err()
+}
+
+func exportRedemptions(ctx context.Context, tx *sql.Tx, archive *backup.Archive) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, reward_id, email, cost, redeemed_at, fulfilled, fulfilled_at
+		FROM redemptions ORDER BY redeemed_at, id`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rd backup.Redemption
+		var fulfilledAt sql.NullTime
+		if err := rows.Scan(&rd.ID, &rd.Reward, &rd.User, &rd.Cost, &rd.RedeemedAt, &rd.Fulfilled, &fulfilledAt); err != nil {
+			return err
+		}
+		rd.FulfilledAt = optionalTime(fulfilledAt)
+		archive.Redemptions = append(archive.Redemptions, rd)
+	}
+
+	return rows.Err()
+}
+
+func exportCompletions(ctx context.Context, tx *sql.Tx, archive *backup.Archive) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT email, home_id, task, at, reward, bonus FROM completions ORDER BY rowid`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c backup.Completion
+		if err := rows.Scan(&c.User, &c.Home, &c.Task, &c.At, &c.Reward, &c.Bonus); err != nil {
+			return err
+		}
+		archive.Completions = append(archive.Completions, c)
+	}
+
+	return rows.Err()
+}
+
+func exportAchievements(ctx context.Context, tx *sql.Tx, archive *backup.Archive) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT email, id, name, description, earned_at FROM achievements ORDER BY email, earned_at`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a backup.Achievement
+		if err := rows.Scan(&a.User, &a.ID, &a.Name, &a.Description, &a.EarnedAt); err != nil {
+			return err
+		}
+		archive.Achievements = append(archive.Achievements, a)
+	}
+
+	return rows.Err()
+}
+
+func exportScoreEntries(ctx context.Context, tx *sql.Tx, archive *backup.Archive) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, email, home_id, kind, reason, task, amount, at, waived,
+			coalesce(waived_by, ''), coalesce(waive_reason, ''), waived_at
+		FROM score_entries ORDER BY at, id`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e backup.ScoreEntry
+		var waivedAt sql.NullTime
+		err := rows.Scan(&e.ID, &e.User, &e.Home, &e.Kind, &e.Reason, &e.Task, &e.Amount, &e.At, &e.Waived,
+			&e.WaivedBy, &e.WaiveReason, &waivedAt)
+		if err != nil {
+			return err
+		}
+		e.WaivedAt = optionalTime(waivedAt)
+		archive.ScoreEntries = append(archive.ScoreEntries, e)
+	}
+
+	return rows.Err()
+}
+
+func exportWebhooks(ctx context.Context, tx *sql.Tx, archive *backup.Archive) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, home_id, url, events, secret, created_by, created_at FROM webhooks ORDER BY id`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hook backup.Webhook
+		var eventTypes string
+		if err := rows.Scan(&hook.ID, &hook.Home, &hook.URL, &eventTypes, &hook.Secret, &hook.CreatedBy, &hook.CreatedAt); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(eventTypes), &hook.Events); err != nil {
+			return err
+		}
+		archive.Webhooks = append(archive.Webhooks, hook)
+	}
+
+	return rows.Err()
+}
+
+// Import usa upserts em todas as tabelas; conclusões, que não têm chave,
+// só são gravadas se ainda não houver uma igual
+func (r *BackupRepository) Import(ctx context.Context, archive backup.Archive) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for _, u := range archive.Users {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO users (email, name, password, score, daily_streak, weekly_streak, last_completed_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (email) DO UPDATE SET
+					name = excluded.name,
+					password = excluded.password,
+					score = excluded.score,
+					daily_streak = excluded.daily_streak,
+					weekly_streak = excluded.weekly_streak,
+					last_completed_at = excluded.last_completed_at,
+					version = version + 1`,
+				u.Email, u.Name, u.Password, u.Score, u.DailyStreak, u.WeeklyStreak, optionalTimestamp(u.LastCompletedAt),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, h := range archive.Homes {
+			if err := importHome(ctx, tx, h); err != nil {
+				return err
+			}
+		}
+
+		for _, rw := range archive.Rewards {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO rewards (id, home_id, name, cost, stock) VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET
+					home_id = excluded.home_id,
+					name = excluded.name,
+					cost = excluded.cost,
+					stock = excluded.stock`,
+				rw.ID.String(), rw.Home, rw.Name, rw.Cost, rw.Stock,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, rd := range archive.Redemptions {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO redemptions (id, reward_id, email, cost, redeemed_at, fulfilled, fulfilled_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET
+					reward_id = excluded.reward_id,
+					email = excluded.email,
+					cost = excluded.cost,
+					redeemed_at = excluded.redeemed_at,
+					fulfilled = excluded.fulfilled,
+					fulfilled_at = excluded.fulfilled_at`,
+				rd.ID.String(), rd.Reward, rd.User, rd.Cost, timestamp(rd.RedeemedAt), rd.Fulfilled, optionalTimestamp(rd.FulfilledAt),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, c := range archive.Completions {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO completions (email, home_id, task, at, reward, bonus)
+				SELECT ?, ?, ?, ?, ?, ?
+				WHERE NOT EXISTS (
+					SELECT 1 FROM completions WHERE email = ? AND home_id = ? AND task = ? AND at = ?
+				)`,
+				c.User, c.Home, c.Task, timestamp(c.At), c.Reward, c.Bonus,
+				c.User, c.Home, c.Task, timestamp(c.At),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, a := range archive.Achievements {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO achievements (email, id, name, description, earned_at) VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT (email, id) DO UPDATE SET
+					name = excluded.name,
+					description = excluded.description,
+					earned_at = excluded.earned_at`,
+				a.User, a.ID, a.Name, a.Description, timestamp(a.EarnedAt),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, e := range archive.ScoreEntries {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO score_entries (id, email, home_id, kind, reason, task, amount, at,
+					waived, waived_by, waive_reason, waived_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET
+					email = excluded.email,
+					home_id = excluded.home_id,
+					kind = excluded.kind,
+					reason = excluded.reason,
+					task = excluded.task,
+					amount = excluded.amount,
+					at = excluded.at,
+					waived = excluded.waived,
+					waived_by = excluded.waived_by,
+					waive_reason = excluded.waive_reason,
+					waived_at = excluded.waived_at`,
+				e.ID.String(), e.User, e.Home, e.Kind, e.Reason, e.Task, e.Amount, timestamp(e.At),
+				e.Waived, nullable(e.WaivedBy), nullable(e.WaiveReason), optionalTimestamp(e.WaivedAt),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		// O upsert, ao contrário de INSERT OR REPLACE, não apaga as entregas em cascata
+		for _, hook := range archive.Webhooks {
+			eventTypes, err := json.Marshal(hook.Events)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO webhooks (id, home_id, url, events, secret, created_by, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (id) DO UPDATE SET
+					home_id = excluded.home_id,
+					url = excluded.url,
+					events = excluded.events,
+					secret = excluded.secret,
+					created_by = excluded.created_by,
+					created_at = excluded.created_at`,
+				hook.ID.String(), hook.Home, hook.URL, string(eventTypes), hook.Secret, hook.CreatedBy, timestamp(hook.CreatedAt),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func importHome(ctx context.Context, tx *sql.Tx, h backup.Home) error {
+	id := h.ID.String()
+	settings := h.Settings
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO homes (id, name, grace_days, streak_bonus, streak_bonus_cap,
+			pricing_mode, pricing_curve, pricing_rate, pricing_cap, penalty_overdue, penalty_rejected,
+			deleted_at, deleted_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			grace_days = excluded.grace_days,
+			streak_bonus = excluded.streak_bonus,
+			streak_bonus_cap = excluded.streak_bonus_cap,
+			pricing_mode = excluded.pricing_mode,
+			pricing_curve = excluded.pricing_curve,
+			pricing_rate = excluded.pricing_rate,
+			pricing_cap = excluded.pricing_cap,
+			penalty_overdue = excluded.penalty_overdue,
+			penalty_rejected = excluded.penalty_rejected,
+			deleted_at = excluded.deleted_at,
+			deleted_by = excluded.deleted_by,
+			version = version + 1`,
+		id, h.Name, settings.GraceDays, settings.StreakBonus, settings.StreakBonusCap,
+		settings.Pricing.Mode, settings.Pricing.Curve, settings.Pricing.Rate, settings.Pricing.Cap,
+		settings.Penalties.Overdue, settings.Penalties.Rejected,
+		optionalTimestamp(h.DeletedAt), nullable(h.DeletedBy),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, resident := range h.Residents {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO residents (home_id, email, role) VALUES (?, ?, ?)
+			ON CONFLICT (home_id, email) DO UPDATE SET role = excluded.role`,
+			id, resident.Email, resident.Role,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, t := range h.Tasks {
+		// As versões não vão para o backup; a tarefa restaurada segue a numeração
+		// da que estiver gravada, ativa ou na lixeira, como uma gravação a mais
+		var version int64
+		err := tx.QueryRowContext(ctx,
+			`SELECT coalesce(max(version), 0) + 1 FROM (
+				SELECT version FROM tasks WHERE home_id = ? AND name = ?
+				UNION ALL
+				SELECT version FROM deleted_tasks WHERE home_id = ? AND name = ?
+			)`,
+			id, t.Name, id, t.Name,
+		).Scan(&version)
+		if err != nil {
+			return err
+		}
+
+		values := []interface{}{
+			id, t.Name, t.Reward, nullable(string(t.Recurrence)), nullable(string(t.Status)), t.Streak,
+			optionalTimestamp(t.LastCompletedAt), optionalTimestamp(t.PendingSince), nullable(t.Assignee),
+			optionalTimestamp(t.DueAt), t.OverduePenalized, version,
+		}
+
+		if t.DeletedAt == nil {
+			_, err = tx.ExecContext(ctx,
+				`INSERT OR REPLACE INTO tasks (`+taskColumns+`, version) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				values...,
+			)
+		} else {
+			_, err = tx.ExecContext(ctx,
+				`INSERT OR REPLACE INTO deleted_tasks (`+taskColumns+`, version, deleted_at, deleted_by)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				append(values, optionalTimestamp(t.DeletedAt), nullable(t.DeletedBy))...,
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// optionalTime converte NULL em nil
+func optionalTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// optionalTimestamp grava nil como NULL
+func optionalTimestamp(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return timestamp(*t)
+}
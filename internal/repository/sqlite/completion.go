@@ -0,0 +1,362 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/score"
+	syncchannel "github.com/nsbnroque/go-to-do-list/sync_channel"
+	"github.com/nsbnroque/go-to-do-list/task"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+type CompletionRepository struct {
+	base
+}
+
+func NewCompletionRepository(db *database.SQLiteHandler) *CompletionRepository {
+	return &CompletionRepository{base{db}}
+}
+
+// openChore seleciona a tarefa ainda não concluída numa das casas do usuário, ou
+// na casa do segundo parâmetro, repetido no terceiro, quando informada
+const openChore = `FROM residents r
+	JOIN homes h ON h.id = r.home_id
+	JOIN tasks t ON t.home_id = r.home_id
+	JOIN users u ON u.email = r.email
+	WHERE r.email = ? AND (? = '' OR r.home_id = ?) AND t.name = ? AND coalesce(t.status, '') <> ? AND h.deleted_at IS NULL
+	ORDER BY r.rowid
+	LIMIT 1`
+
+func (r *CompletionRepository) Complete(ctx context.Context, email string, homeID string, taskName string, complete func(syncchannel.CompletionState) syncchannel.Completion) (user.User, error) {
+	var completed user.User
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var choreHome string
+		var state syncchannel.CompletionState
+		var choreLastAt, pendingSince, lastAt sql.NullTime
+
+		dest := append([]interface{}{&choreHome}, settingsDest(&state.Settings)...)
+		dest = append(dest,
+			&state.Reward, &state.Recurrence, &state.Chore.Current, &choreLastAt, &pendingSince,
+			&state.Daily.Current, &state.Weekly.Current, &lastAt,
+		)
+		err := tx.QueryRowContext(ctx,
+			`SELECT r.home_id, `+settingsColumns+`, t.reward, coalesce(t.recurrence, ''), t.streak, t.last_completed_at,
+				t.pending_since, u.daily_streak, u.weekly_streak, u.last_completed_at
+			`+openChore,
+			email, homeID, homeID, taskName, task.Finished,
+		).Scan(dest...)
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		state.Chore.LastAt = choreLastAt.Time
+		state.PendingSince = pendingSince.Time
+		state.Daily.LastAt = lastAt.Time
+		state.Weekly.LastAt = lastAt.Time
+		completion := complete(state)
+
+		var choreStreak int64
+		if completion.ChoreStreak != nil {
+			choreStreak = *completion.ChoreStreak
+		}
+
+		// Outra conexão pode ter concluído a tarefa entre a leitura e a gravação
+		result, err := tx.ExecContext(ctx,
+			`UPDATE tasks SET status = ?, streak = ?, last_completed_at = ?, pending_since = NULL, version = version + 1
+			WHERE home_id = ? AND name = ? AND coalesce(status, '') <> ?`,
+			task.Finished, choreStreak, timestamp(completion.At), choreHome, taskName, task.Finished,
+		)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return repository.ErrConflict
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE users SET score = score + ?, daily_streak = ?, weekly_streak = ?, last_completed_at = ?,
+				version = version + 1
+			WHERE email = ?`,
+			completion.Reward+completion.Bonus, completion.Daily, completion.Weekly, timestamp(completion.At), email,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO completions (email, home_id, task, at, reward, bonus) VALUES (?, ?, ?, ?, ?, ?)`,
+			email, choreHome, taskName, timestamp(completion.At), completion.Reward, completion.Bonus,
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx,
+			`SELECT name, email, score, daily_streak, weekly_streak FROM users WHERE email = ?`,
+			email,
+		).Scan(&completed.Name, &completed.Email, &completed.Score, &completed.DailyStreak, &completed.WeeklyStreak)
+	})
+
+	return completed, err
+}
+
+func (r *CompletionRepository) Assign(ctx context.Context, adminEmail string, homeID string, taskName string, assignment task.Assignment) (task.Task, error) {
+	assigned := task.Task{
+		Name:     taskName,
+		Assignee: assignment.Assignee,
+		DueAt:    &assignment.DueAt,
+	}
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var choreHome string
+		err := tx.QueryRowContext(ctx,
+			`SELECT t.home_id, t.reward, coalesce(t.status, '')
+			FROM residents a
+			JOIN homes h ON h.id = a.home_id
+			JOIN tasks t ON t.home_id = a.home_id
+			JOIN residents r ON r.home_id = a.home_id
+			WHERE a.email = ? AND a.role = ? AND `+inHome("a.home_id")+` AND t.name = ? AND r.email = ?
+				AND h.deleted_at IS NULL
+			ORDER BY a.rowid
+			LIMIT 1`,
+			adminEmail, home.Admin, homeID, homeID, taskName, assignment.Assignee,
+		).Scan(&choreHome, &assigned.Reward, &assigned.Status)
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE tasks SET assignee = ?, due_at = ?, overdue_penalized = 0, version = version + 1
+			WHERE home_id = ? AND name = ?`,
+			assignment.Assignee, timestamp(assignment.DueAt), choreHome, taskName,
+		)
+		return err
+	})
+
+	return assigned, err
+}
+
+func (r *CompletionRepository) Reject(ctx context.Context, adminEmail string, homeID string, taskName string, penalty score.Entry) (string, int64, error) {
+	var resident, choreHome string
+	var amount int64
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		// O último morador da casa a concluir a tarefa é quem recebe a penalidade
+		err := tx.QueryRowContext(ctx,
+			`SELECT c.email, t.home_id, h.penalty_rejected
+			FROM residents a
+			JOIN homes h ON h.id = a.home_id
+			JOIN tasks t ON t.home_id = a.home_id
+			JOIN completions c ON c.home_id = t.home_id AND c.task = t.name
+			JOIN residents r ON r.home_id = a.home_id AND r.email = c.email
+			WHERE a.email = ? AND a.role = ? AND `+inHome("a.home_id")+` AND t.name = ? AND t.status = ?
+				AND h.deleted_at IS NULL
+			ORDER BY a.rowid, c.at DESC
+			LIMIT 1`,
+			adminEmail, home.Admin, homeID, homeID, taskName, task.Finished,
+		).Scan(&resident, &choreHome, &amount)
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE tasks SET status = ?, pending_since = ?, version = version + 1 WHERE home_id = ? AND name = ?`,
+			task.Pending, timestamp(penalty.At), choreHome, taskName,
+		)
+		if err != nil {
+			return err
+		}
+
+		if amount <= 0 {
+			return nil
+		}
+
+		penalty.Amount = -amount
+		return penalize(ctx, tx, resident, choreHome, penalty)
+	})
+
+	return resident, amount, err
+}
+
+func (r *CompletionRepository) RecurringChores(ctx context.Context) ([]syncchannel.Chore, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT t.home_id, t.name, t.recurrence, coalesce(t.status, ''), t.streak, t.last_completed_at, h.grace_days
+		FROM tasks t
+		JOIN homes h ON h.id = t.home_id
+		WHERE t.recurrence IS NOT NULL AND t.last_completed_at IS NOT NULL AND h.deleted_at IS NULL`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chores []syncchannel.Chore
+	for rows.Next() {
+		var chore syncchannel.Chore
+		var recurrence task.Recurrence
+		err := rows.Scan(&chore.Home, &chore.Task, &recurrence, &chore.Status,
+			&chore.Streak.Current, &chore.Streak.LastAt, &chore.GraceDays,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if chore.Days = recurrence.Days(); chore.Days == 0 {
+			continue
+		}
+		chores = append(chores, chore)
+	}
+
+	return chores, rows.Err()
+}
+
+func (r *CompletionRepository) UpdateChores(ctx context.Context, updates []syncchannel.ChoreUpdate, now time.Time) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for _, update := range updates {
+			_, err := tx.ExecContext(ctx,
+				`UPDATE tasks SET
+					pending_since = CASE WHEN ? THEN ? ELSE pending_since END,
+					status = ?,
+					streak = ?,
+					version = version + 1
+				WHERE home_id = ? AND name = ?`,
+				update.Reopen, timestamp(now), update.Status, update.Streak, update.Home, update.Task,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *CompletionRepository) ResidentStreaks(ctx context.Context) ([]syncchannel.ResidentStreak, error) {
+	// Moradores de mais de uma casa recebem a maior tolerância entre elas
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT u.email, u.daily_streak, u.weekly_streak, u.last_completed_at, max(h.grace_days)
+		FROM users u
+		JOIN residents r ON r.email = u.email
+		JOIN homes h ON h.id = r.home_id
+		WHERE u.last_completed_at IS NOT NULL AND (u.daily_streak > 0 OR u.weekly_streak > 0) AND h.deleted_at IS NULL
+		GROUP BY u.email`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var residents []syncchannel.ResidentStreak
+	for rows.Next() {
+		var resident syncchannel.ResidentStreak
+		var lastAt time.Time
+		err := rows.Scan(&resident.Email, &resident.Daily.Current, &resident.Weekly.Current, &lastAt, &resident.GraceDays)
+		if err != nil {
+			return nil, err
+		}
+
+		resident.Daily.LastAt = lastAt
+		resident.Weekly.LastAt = lastAt
+		residents = append(residents, resident)
+	}
+
+	return residents, rows.Err()
+}
+
+func (r *CompletionRepository) UpdateResidentStreaks(ctx context.Context, streaks []syncchannel.ResidentStreak) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for _, resident := range streaks {
+			_, err := tx.ExecContext(ctx,
+				`UPDATE users SET daily_streak = ?, weekly_streak = ?, version = version + 1 WHERE email = ?`,
+				resident.Daily.Current, resident.Weekly.Current, resident.Email,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type overdueChore struct {
+	home     string
+	task     string
+	assignee string
+	amount   int64
+}
+
+func (r *CompletionRepository) PenalizeOverdue(ctx context.Context, now time.Time) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT t.home_id, t.name, t.assignee, h.penalty_overdue
+			FROM tasks t
+			JOIN homes h ON h.id = t.home_id
+			JOIN residents r ON r.home_id = t.home_id AND r.email = t.assignee
+			WHERE t.due_at < ? AND coalesce(t.status, '') <> ? AND NOT t.overdue_penalized AND h.deleted_at IS NULL`,
+			timestamp(now), task.Finished,
+		)
+		if err != nil {
+			return err
+		}
+
+		var overdue []overdueChore
+		for rows.Next() {
+			var chore overdueChore
+			if err := rows.Scan(&chore.home, &chore.task, &chore.assignee, &chore.amount); err != nil {
+				rows.Close()
+				return err
+			}
+			overdue = append(overdue, chore)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, chore := range overdue {
+			_, err := tx.ExecContext(ctx,
+				`UPDATE tasks SET overdue_penalized = 1 WHERE home_id = ? AND name = ?`,
+				chore.home, chore.task,
+			)
+			if err != nil {
+				return err
+			}
+
+			if chore.amount <= 0 {
+				continue
+			}
+
+			err = penalize(ctx, tx, chore.assignee, chore.home, score.Entry{
+				ID:     uuid.New(),
+				Kind:   score.Penalty,
+				Reason: score.Overdue,
+				Task:   chore.task,
+				Amount: -chore.amount,
+				At:     now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
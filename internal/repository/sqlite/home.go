@@ -0,0 +1,436 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+type HomeRepository struct {
+	base
+}
+
+func NewHomeRepository(db *database.SQLiteHandler) *HomeRepository {
+	return &HomeRepository{base{db}}
+}
+
+func (r *HomeRepository) Create(ctx context.Context, ownerEmail string, homeData home.Home) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM users WHERE email = ?)`,
+			ownerEmail,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return repository.ErrNotFound
+		}
+
+		settings := home.DefaultSettings()
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO homes (id, name, grace_days, streak_bonus, streak_bonus_cap,
+				pricing_mode, pricing_curve, pricing_rate, pricing_cap, penalty_overdue, penalty_rejected)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO NOTHING`,
+			homeData.ID.String(), homeData.Name, settings.GraceDays, settings.StreakBonus, settings.StreakBonusCap,
+			settings.Pricing.Mode, settings.Pricing.Curve, settings.Pricing.Rate, settings.Pricing.Cap,
+			settings.Penalties.Overdue, settings.Penalties.Rejected,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO residents (home_id, email, role) VALUES (?, ?, ?)
+			ON CONFLICT (home_id, email) DO NOTHING`,
+			homeData.ID.String(), ownerEmail, home.Admin,
+		)
+		return err
+	})
+}
+
+func (r *HomeRepository) AddResident(ctx context.Context, email string, homeID string, resident user.User) (home.Home, error) {
+	var updated string
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		homeIDs, err := homesOf(ctx, tx, email, homeID)
+		if err != nil {
+			return err
+		}
+		if len(homeIDs) == 0 {
+			return repository.ErrNotFound
+		}
+
+		// O morador é criado apenas com o email caso ainda não tenha cadastro
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO users (email) VALUES (?) ON CONFLICT (email) DO NOTHING`,
+			resident.Email,
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range homeIDs {
+			result, err := tx.ExecContext(ctx,
+				`INSERT INTO residents (home_id, email, role) VALUES (?, ?, ?)
+				ON CONFLICT (home_id, email) DO NOTHING`,
+				id, resident.Email, home.Resident,
+			)
+			if err != nil {
+				return err
+			}
+
+			// Um novo morador muda a casa
+			if added, err := result.RowsAffected(); err != nil {
+				return err
+			} else if added > 0 {
+				_, err = tx.ExecContext(ctx, `UPDATE homes SET version = version + 1 WHERE id = ?`, id)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		updated = homeIDs[len(homeIDs)-1]
+		return nil
+	})
+	if err != nil {
+		return home.Home{}, err
+	}
+
+	return r.FindByID(ctx, updated)
+}
+
+func (r *HomeRepository) Update(ctx context.Context, adminEmail string, homeData home.Home) (home.Home, error) {
+	id := homeData.ID.String()
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		err := checkVersion(ctx, tx, "homes", "id = ? AND deleted_at IS NULL", homeData.Version, id)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx,
+			`UPDATE homes SET name = ?, version = version + 1
+			WHERE id = ? AND id IN (SELECT home_id FROM residents WHERE email = ? AND role = ?)`,
+			homeData.Name, id, adminEmail, home.Admin,
+		)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return repository.ErrForbidden
+		}
+		return nil
+	})
+	if err != nil {
+		return home.Home{}, err
+	}
+
+	return r.FindByID(ctx, id)
+}
+
+func (r *HomeRepository) FindByID(ctx context.Context, id string) (home.Home, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT h.id, h.name, h.version, u.name, u.email, u.version
+		FROM homes h
+		JOIN residents r ON r.home_id = h.id
+		JOIN users u ON u.email = r.email
+		WHERE h.id = ? AND h.deleted_at IS NULL
+		ORDER BY r.rowid`,
+		id,
+	)
+	if err != nil {
+		return home.Home{}, err
+	}
+	defer rows.Close()
+
+	var homeData home.Home
+	found := false
+	for rows.Next() {
+		var resident user.User
+		if err := rows.Scan(&homeData.ID, &homeData.Name, &homeData.Version, &resident.Name, &resident.Email, &resident.Version); err != nil {
+			return home.Home{}, err
+		}
+		homeData.Residents = append(homeData.Residents, resident)
+		found = true
+	}
+	if err := rows.Err(); err != nil {
+		return home.Home{}, err
+	}
+
+	if !found {
+		return home.Home{}, repository.ErrNotFound
+	}
+	return homeData, nil
+}
+
+// Delete marca a casa como excluída; ela some das consultas, que filtram
+// por deleted_at, mas moradores, tarefas e recompensas continuam gravados
+func (r *HomeRepository) Delete(ctx context.Context, id string, deletedBy string, version int64, at time.Time) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkVersion(ctx, tx, "homes", "id = ? AND deleted_at IS NULL", version, id); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx,
+			`UPDATE homes SET deleted_at = ?, deleted_by = ?, version = version + 1
+			WHERE id = ? AND id IN (SELECT home_id FROM residents WHERE email = ? AND role = ?)`,
+			timestamp(at), nullable(deletedBy), id, deletedBy, home.Admin,
+		)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return repository.ErrForbidden
+		}
+		return nil
+	})
+}
+
+func (r *HomeRepository) Trash(ctx context.Context, email string, id string) ([]home.TrashItem, error) {
+	var name string
+	var deletedAt sql.NullTime
+	var deletedBy sql.NullString
+	err := r.db.DB.QueryRowContext(ctx,
+		`SELECT h.name, h.deleted_at, h.deleted_by
+		FROM homes h
+		JOIN residents r ON r.home_id = h.id
+		WHERE h.id = ? AND r.email = ?`,
+		id, email,
+	).Scan(&name, &deletedAt, &deletedBy)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var trash []home.TrashItem
+	if deletedAt.Valid {
+		trash = append(trash, home.TrashItem{
+			Kind:      home.TrashHome,
+			Name:      name,
+			DeletedAt: deletedAt.Time,
+			DeletedBy: deletedBy.String,
+		})
+	}
+
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT name, deleted_at, coalesce(deleted_by, '')
+		FROM deleted_tasks
+		WHERE home_id = ?
+		ORDER BY deleted_at DESC`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := home.TrashItem{Kind: home.TrashTask}
+		if err := rows.Scan(&item.Name, &item.DeletedAt, &item.DeletedBy); err != nil {
+			return nil, err
+		}
+		trash = append(trash, item)
+	}
+
+	return trash, rows.Err()
+}
+
+func (r *HomeRepository) Restore(ctx context.Context, email string, id string, restoration home.Restoration) error {
+	if restoration.Kind == home.TrashHome {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			var role home.Role
+			err := tx.QueryRowContext(ctx,
+				`SELECT residents.role FROM residents JOIN homes ON homes.id = residents.home_id
+				WHERE homes.id = ? AND homes.deleted_at IS NOT NULL AND residents.email = ?`,
+				id, email,
+			).Scan(&role)
+			if errors.Is(err, sql.ErrNoRows) {
+				return repository.ErrNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if role != home.Admin {
+				return repository.ErrForbidden
+			}
+
+			_, err = tx.ExecContext(ctx,
+				`UPDATE homes SET deleted_at = NULL, deleted_by = NULL, version = version + 1 WHERE id = ?`,
+				id,
+			)
+			return err
+		})
+	}
+
+	// Tarefas só voltam para casas ativas
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx,
+			`INSERT INTO tasks (`+taskColumns+`, version)
+			SELECT `+taskColumns+`, version + 1 FROM deleted_tasks
+			WHERE home_id = ? AND name = ?
+				AND home_id IN (`+activeHomesOf+`)`,
+			id, restoration.Name, email,
+		)
+		if err != nil {
+			return err
+		}
+
+		if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return repository.ErrNotFound
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM deleted_tasks WHERE home_id = ? AND name = ?`,
+			id, restoration.Name,
+		)
+		return err
+	})
+}
+
+func (r *HomeRepository) Feed(ctx context.Context, id string, limit int) ([]home.FeedItem, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT a.email, a.name, a.earned_at
+		FROM achievements a
+		JOIN residents r ON r.email = a.email
+		JOIN homes h ON h.id = r.home_id
+		WHERE r.home_id = ? AND h.deleted_at IS NULL
+		ORDER BY a.earned_at DESC
+		LIMIT ?`,
+		id, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feed []home.FeedItem
+	for rows.Next() {
+		var name string
+		item := home.FeedItem{Type: "achievement"}
+		if err := rows.Scan(&item.User, &name, &item.At); err != nil {
+			return nil, err
+		}
+		item.Message = fmt.Sprintf("Conquista desbloqueada: %v", name)
+
+		feed = append(feed, item)
+	}
+
+	return feed, rows.Err()
+}
+
+func (r *HomeRepository) Settings(ctx context.Context, id string) (home.Settings, error) {
+	var settings home.Settings
+	err := r.db.DB.QueryRowContext(ctx,
+		`SELECT `+settingsColumns+` FROM homes h WHERE h.id = ? AND h.deleted_at IS NULL`,
+		id,
+	).Scan(settingsDest(&settings)...)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return home.Settings{}, repository.ErrNotFound
+	}
+	return settings, err
+}
+
+func (r *HomeRepository) UpdateSettings(ctx context.Context, adminEmail string, id string, settings home.Settings) error {
+	result, err := r.db.DB.ExecContext(ctx,
+		`UPDATE homes SET
+			grace_days = ?,
+			streak_bonus = ?,
+			streak_bonus_cap = ?,
+			pricing_mode = ?,
+			pricing_curve = ?,
+			pricing_rate = ?,
+			pricing_cap = ?,
+			penalty_overdue = ?,
+			penalty_rejected = ?,
+			version = version + 1
+		WHERE deleted_at IS NULL AND `+inHome("id")+`
+			AND id IN (SELECT home_id FROM residents WHERE email = ? AND role = ?)`,
+		settings.GraceDays, settings.StreakBonus, settings.StreakBonusCap,
+		settings.Pricing.Mode, settings.Pricing.Curve, settings.Pricing.Rate, settings.Pricing.Cap,
+		settings.Penalties.Overdue, settings.Penalties.Rejected,
+		id, id, adminEmail, home.Admin,
+	)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return repository.ErrForbidden
+	}
+	return nil
+}
+
+// settingsDest retorna os destinos de Scan para as colunas de settingsColumns
+func settingsDest(settings *home.Settings) []interface{} {
+	return []interface{}{
+		&settings.GraceDays, &settings.StreakBonus, &settings.StreakBonusCap,
+		&settings.Pricing.Mode, &settings.Pricing.Curve, &settings.Pricing.Rate, &settings.Pricing.Cap,
+		&settings.Penalties.Overdue, &settings.Penalties.Rejected,
+	}
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// activeHomesOf seleciona as casas ativas do morador cujo email é o parâmetro
+const activeHomesOf = `SELECT r.home_id FROM residents r
+	JOIN homes h ON h.id = r.home_id
+	WHERE r.email = ? AND h.deleted_at IS NULL`
+
+// activeHomesIn restringe activeHomesOf à casa do segundo parâmetro, repetido no
+// terceiro, ou a todas as casas do morador quando ela é vazia
+const activeHomesIn = activeHomesOf + ` AND (? = '' OR r.home_id = ?)`
+
+// inHome compara a coluna com a casa do parâmetro, que deve ser passado duas
+// vezes; com a casa vazia, como nas rotas antigas, qualquer casa serve
+func inHome(column string) string {
+	return fmt.Sprintf("(? = '' OR %s = ?)", column)
+}
+
+// homesOf retorna as casas ativas do usuário, na ordem em que passou a morar
+// nelas, ou apenas a casa id quando informada
+func homesOf(ctx context.Context, q queryer, email string, id string) ([]string, error) {
+	rows, err := q.QueryContext(ctx,
+		activeHomesIn+` ORDER BY r.rowid`,
+		email, id, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
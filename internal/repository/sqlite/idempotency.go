@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/idempotency"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+)
+
+type IdempotencyRepository struct {
+	base
+}
+
+func NewIdempotencyRepository(db *database.SQLiteHandler) *IdempotencyRepository {
+	return &IdempotencyRepository{base{db}}
+}
+
+// Reserve só sobrescreve a chave existente se ela já expirou
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key idempotency.Key, now time.Time) (idempotency.Key, bool, error) {
+	var existing idempotency.Key
+	var reserved bool
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx,
+			`INSERT INTO idempotency_keys (key, fingerprint, expires_at) VALUES (?, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET
+				fingerprint = excluded.fingerprint,
+				expires_at = excluded.expires_at,
+				status = NULL,
+				header = NULL,
+				body = NULL
+			WHERE idempotency_keys.expires_at <= ?`,
+			key.Key, key.Fingerprint, timestamp(key.ExpiresAt), timestamp(now),
+		)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if reserved = affected > 0; reserved {
+			existing = key
+			return nil
+		}
+
+		var status sql.NullInt64
+		var header sql.NullString
+		var body []byte
+		err = tx.QueryRowContext(ctx,
+			`SELECT key, fingerprint, expires_at, status, header, body FROM idempotency_keys WHERE key = ?`,
+			key.Key,
+		).Scan(&existing.Key, &existing.Fingerprint, &existing.ExpiresAt, &status, &header, &body)
+		if err != nil {
+			return err
+		}
+
+		if status.Valid {
+			existing.Response = &idempotency.Response{Status: int(status.Int64), Body: body}
+			return json.Unmarshal([]byte(header.String), &existing.Response.Header)
+		}
+		return nil
+	})
+	if err != nil {
+		return idempotency.Key{}, false, err
+	}
+
+	return existing, reserved, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, response idempotency.Response) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.DB.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status = ?, header = ?, body = ? WHERE key = ?`,
+		response.Status, string(header), response.Body, key,
+	)
+	return err
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.db.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = ?`, key)
+	return err
+}
+
+func (r *IdempotencyRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.DB.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE expires_at < ?`,
+		timestamp(before),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := result.RowsAffected()
+	return int(purged), err
+}
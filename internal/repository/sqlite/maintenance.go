@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/maintenance"
+)
+
+type MaintenanceRepository struct {
+	base
+}
+
+func NewMaintenanceRepository(db *database.SQLiteHandler) *MaintenanceRepository {
+	return &MaintenanceRepository{base{db}}
+}
+
+// Orphans só precisa olhar casas sem moradores e penalidades de casas que não
+// existem mais, o resto é garantido pelas chaves estrangeiras
+func (r *MaintenanceRepository) Orphans(ctx context.Context) ([]maintenance.Orphan, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT ?, id FROM homes
+		WHERE NOT EXISTS (SELECT 1 FROM residents WHERE residents.home_id = homes.id)
+		UNION ALL
+		SELECT ?, id FROM score_entries
+		WHERE NOT EXISTS (SELECT 1 FROM homes WHERE homes.id = score_entries.home_id)`,
+		string(maintenance.Home), string(maintenance.ScoreEntry),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orphans []maintenance.Orphan
+	for rows.Next() {
+		var orphan maintenance.Orphan
+		if err := rows.Scan(&orphan.Kind, &orphan.ID); err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, orphan)
+	}
+
+	return orphans, rows.Err()
+}
+
+// Purge conta com as chaves estrangeiras para tarefas, moradias, recompensas e
+// resgates das casas expurgadas; penalidades e conclusões, que guardam só o id, saem aqui
+func (r *MaintenanceRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	var purged int64
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		purged = 0
+		expired := timestamp(before)
+
+		result, err := tx.ExecContext(ctx,
+			`DELETE FROM deleted_tasks
+			WHERE deleted_at < ? OR home_id IN (SELECT id FROM homes WHERE deleted_at < ?)`,
+			expired, expired,
+		)
+		if err != nil {
+			return err
+		}
+		tasks, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM score_entries WHERE home_id IN (SELECT id FROM homes WHERE deleted_at < ?)`,
+			expired,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM completions WHERE home_id IN (SELECT id FROM homes WHERE deleted_at < ?)`,
+			expired,
+		)
+		if err != nil {
+			return err
+		}
+
+		result, err = tx.ExecContext(ctx, `DELETE FROM homes WHERE deleted_at < ?`, expired)
+		if err != nil {
+			return err
+		}
+		homes, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		purged = tasks + homes
+		return nil
+	})
+
+	return int(purged), err
+}
@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/reward"
+)
+
+type RewardRepository struct {
+	base
+}
+
+func NewRewardRepository(db *database.SQLiteHandler) *RewardRepository {
+	return &RewardRepository{base{db}}
+}
+
+func (r *RewardRepository) Create(ctx context.Context, adminEmail string, homeID string, rewardData reward.Reward) error {
+	result, err := r.db.DB.ExecContext(ctx,
+		`INSERT INTO rewards (id, home_id, name, cost, stock)
+		SELECT ?, r.home_id, ?, ?, ? FROM residents r
+		JOIN homes h ON h.id = r.home_id
+		WHERE r.email = ? AND r.role = ? AND h.deleted_at IS NULL AND `+inHome("r.home_id")+`
+		ORDER BY r.rowid
+		LIMIT 1`,
+		rewardData.ID.String(), rewardData.Name, rewardData.Cost, rewardData.Stock,
+		adminEmail, home.Admin, homeID, homeID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return repository.ErrForbidden
+	}
+	return nil
+}
+
+func (r *RewardRepository) FindByUser(ctx context.Context, email string, homeID string) ([]reward.Reward, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT rw.id, rw.name, rw.cost, rw.stock
+		FROM rewards rw
+		JOIN residents r ON r.home_id = rw.home_id
+		JOIN homes h ON h.id = rw.home_id
+		WHERE r.email = ? AND h.deleted_at IS NULL AND `+inHome("rw.home_id")+`
+		ORDER BY rw.cost`,
+		email, homeID, homeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rewards []reward.Reward
+	for rows.Next() {
+		var rw reward.Reward
+		if err := rows.Scan(&rw.ID, &rw.Name, &rw.Cost, &rw.Stock); err != nil {
+			return nil, err
+		}
+		rewards = append(rewards, rw)
+	}
+
+	return rewards, rows.Err()
+}
+
+func (r *RewardRepository) Redeem(ctx context.Context, email string, homeID string, rewardID string, redemption reward.Redemption) (reward.Redemption, error) {
+	// Debita o saldo e o estoque na mesma transação para evitar resgates além do permitido
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`SELECT rw.name, rw.cost
+			FROM rewards rw
+			JOIN residents r ON r.home_id = rw.home_id
+			JOIN homes h ON h.id = rw.home_id
+			JOIN users u ON u.email = r.email
+			WHERE r.email = ? AND rw.id = ? AND rw.stock > 0 AND u.score >= rw.cost AND h.deleted_at IS NULL
+				AND `+inHome("rw.home_id"),
+			email, rewardID, homeID, homeID,
+		).Scan(&redemption.Reward, &redemption.Cost)
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrConflict
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE rewards SET stock = stock - 1 WHERE id = ?`, rewardID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET score = score - ?, version = version + 1 WHERE email = ?`, redemption.Cost, email); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO redemptions (id, reward_id, email, cost, redeemed_at) VALUES (?, ?, ?, ?, ?)`,
+			redemption.ID.String(), rewardID, email, redemption.Cost, timestamp(redemption.RedeemedAt),
+		)
+		return err
+	})
+
+	return redemption, err
+}
+
+func (r *RewardRepository) Redemptions(ctx context.Context, email string, homeID string) ([]reward.Redemption, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT rd.id, rw.name, rd.email, rd.cost, rd.redeemed_at, rd.fulfilled
+		FROM redemptions rd
+		JOIN rewards rw ON rw.id = rd.reward_id
+		JOIN residents r ON r.home_id = rw.home_id
+		JOIN homes h ON h.id = rw.home_id
+		WHERE r.email = ? AND h.deleted_at IS NULL AND `+inHome("rw.home_id")+`
+		ORDER BY rd.redeemed_at DESC`,
+		email, homeID, homeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var redemptions []reward.Redemption
+	for rows.Next() {
+		var rd reward.Redemption
+		if err := rows.Scan(&rd.ID, &rd.Reward, &rd.User, &rd.Cost, &rd.RedeemedAt, &rd.Fulfilled); err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, rd)
+	}
+
+	return redemptions, rows.Err()
+}
+
+func (r *RewardRepository) Fulfill(ctx context.Context, adminEmail string, homeID string, id string, at time.Time) error {
+	result, err := r.db.DB.ExecContext(ctx,
+		`UPDATE redemptions SET fulfilled = 1, fulfilled_at = ?
+		WHERE id = ? AND reward_id IN (
+			SELECT rw.id FROM rewards rw
+			JOIN residents r ON r.home_id = rw.home_id
+			JOIN homes h ON h.id = rw.home_id
+			WHERE r.email = ? AND r.role = ? AND h.deleted_at IS NULL AND `+inHome("rw.home_id")+`
+		)`,
+		timestamp(at), id, adminEmail, home.Admin, homeID, homeID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
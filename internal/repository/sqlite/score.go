@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/score"
+)
+
+type ScoreRepository struct {
+	base
+}
+
+func NewScoreRepository(db *database.SQLiteHandler) *ScoreRepository {
+	return &ScoreRepository{base{db}}
+}
+
+const entryColumns = `e.id, e.kind, e.reason, e.task, e.amount, e.at, e.waived,
+	coalesce(e.waived_by, ''), coalesce(e.waive_reason, '')`
+
+func (r *ScoreRepository) History(ctx context.Context, email string, limit int) ([]score.Entry, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT `+entryColumns+` FROM score_entries e
+		WHERE e.email = ?
+		ORDER BY e.at DESC
+		LIMIT ?`,
+		email, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []score.Entry
+	for rows.Next() {
+		var entry score.Entry
+		if err := rows.Scan(entryDest(&entry)...); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *ScoreRepository) Waive(ctx context.Context, adminEmail string, homeID string, id string, waiver score.Waiver, at time.Time) (score.Entry, error) {
+	var entry score.Entry
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var email string
+		err := tx.QueryRowContext(ctx,
+			`SELECT e.email FROM score_entries e
+			JOIN residents r ON r.home_id = e.home_id
+			JOIN homes h ON h.id = e.home_id
+			WHERE e.id = ? AND e.kind = ? AND NOT e.waived AND r.email = ? AND r.role = ? AND h.deleted_at IS NULL
+				AND `+inHome("e.home_id"),
+			id, score.Penalty, adminEmail, home.Admin, homeID, homeID,
+		).Scan(&email)
+		if errors.Is(err, sql.ErrNoRows) {
+			return repository.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE score_entries SET waived = 1, waived_by = ?, waive_reason = ?, waived_at = ? WHERE id = ?`,
+			adminEmail, waiver.Reason, timestamp(at), id,
+		)
+		if err != nil {
+			return err
+		}
+
+		// O valor da penalidade é negativo, então subtraí-lo devolve os pontos
+		_, err = tx.ExecContext(ctx,
+			`UPDATE users SET score = score - (SELECT amount FROM score_entries WHERE id = ?), version = version + 1
+			WHERE email = ?`,
+			id, email,
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx,
+			`SELECT `+entryColumns+` FROM score_entries e WHERE e.id = ?`,
+			id,
+		).Scan(entryDest(&entry)...)
+	})
+
+	return entry, err
+}
+
+// entryDest retorna os destinos de Scan para as colunas de entryColumns
+func entryDest(entry *score.Entry) []interface{} {
+	return []interface{}{
+		&entry.ID, &entry.Kind, &entry.Reason, &entry.Task, &entry.Amount, &entry.At, &entry.Waived,
+		&entry.WaivedBy, &entry.WaiveReason,
+	}
+}
+
+// penalize desconta os pontos do morador e registra a penalidade no histórico
+func penalize(ctx context.Context, tx *sql.Tx, email string, homeID string, entry score.Entry) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET score = score + ?, version = version + 1 WHERE email = ?`, entry.Amount, email); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO score_entries (id, email, home_id, kind, reason, task, amount, at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID.String(), email, homeID, entry.Kind, entry.Reason, entry.Task, entry.Amount, timestamp(entry.At),
+	)
+	return err
+}
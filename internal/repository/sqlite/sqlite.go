@@ -0,0 +1,284 @@
+// Package sqlite implementa os repositórios da aplicação sobre um arquivo SQLite,
+// para quem não quer manter um Neo4j só para acompanhar as tarefas da casa.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+)
+
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS users (
+		email TEXT PRIMARY KEY,
+		name TEXT NOT NULL DEFAULT '',
+		password TEXT NOT NULL DEFAULT '',
+		score INTEGER NOT NULL DEFAULT 0,
+		daily_streak INTEGER NOT NULL DEFAULT 0,
+		weekly_streak INTEGER NOT NULL DEFAULT 0,
+		last_completed_at DATETIME,
+		version INTEGER NOT NULL DEFAULT 1
+	)`,
+	`CREATE TABLE IF NOT EXISTS homes (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		grace_days INTEGER NOT NULL,
+		streak_bonus INTEGER NOT NULL,
+		streak_bonus_cap INTEGER NOT NULL,
+		pricing_mode TEXT NOT NULL,
+		pricing_curve TEXT NOT NULL,
+		pricing_rate INTEGER NOT NULL,
+		pricing_cap INTEGER NOT NULL,
+		penalty_overdue INTEGER NOT NULL,
+		penalty_rejected INTEGER NOT NULL,
+		deleted_at DATETIME,
+		deleted_by TEXT,
+		version INTEGER NOT NULL DEFAULT 1
+	)`,
+	`CREATE TABLE IF NOT EXISTS residents (
+		home_id TEXT NOT NULL REFERENCES homes (id) ON DELETE CASCADE,
+		email TEXT NOT NULL REFERENCES users (email) ON DELETE CASCADE,
+		role TEXT NOT NULL,
+		PRIMARY KEY (home_id, email)
+	)`,
+	`CREATE INDEX IF NOT EXISTS residents_email ON residents (email)`,
+	`CREATE TABLE IF NOT EXISTS tasks (
+		home_id TEXT NOT NULL REFERENCES homes (id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		reward INTEGER NOT NULL DEFAULT 0,
+		recurrence TEXT,
+		status TEXT,
+		streak INTEGER NOT NULL DEFAULT 0,
+		last_completed_at DATETIME,
+		pending_since DATETIME,
+		assignee TEXT,
+		due_at DATETIME,
+		overdue_penalized INTEGER NOT NULL DEFAULT 0,
+		version INTEGER NOT NULL DEFAULT 1,
+		PRIMARY KEY (home_id, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS deleted_tasks (
+		home_id TEXT NOT NULL REFERENCES homes (id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		reward INTEGER NOT NULL DEFAULT 0,
+		recurrence TEXT,
+		status TEXT,
+		streak INTEGER NOT NULL DEFAULT 0,
+		last_completed_at DATETIME,
+		pending_since DATETIME,
+		assignee TEXT,
+		due_at DATETIME,
+		overdue_penalized INTEGER NOT NULL DEFAULT 0,
+		version INTEGER NOT NULL DEFAULT 1,
+		deleted_at DATETIME NOT NULL,
+		deleted_by TEXT,
+		PRIMARY KEY (home_id, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS completions (
+		email TEXT NOT NULL REFERENCES users (email) ON DELETE CASCADE,
+		home_id TEXT NOT NULL,
+		task TEXT NOT NULL,
+		at DATETIME NOT NULL,
+		reward INTEGER NOT NULL,
+		bonus INTEGER NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS completions_email ON completions (email)`,
+	`CREATE TABLE IF NOT EXISTS achievements (
+		email TEXT NOT NULL REFERENCES users (email) ON DELETE CASCADE,
+		id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL,
+		earned_at DATETIME NOT NULL,
+		PRIMARY KEY (email, id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS rewards (
+		id TEXT PRIMARY KEY,
+		home_id TEXT NOT NULL REFERENCES homes (id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		cost INTEGER NOT NULL,
+		stock INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS redemptions (
+		id TEXT PRIMARY KEY,
+		reward_id TEXT NOT NULL REFERENCES rewards (id) ON DELETE CASCADE,
+		email TEXT NOT NULL REFERENCES users (email) ON DELETE CASCADE,
+		cost INTEGER NOT NULL,
+		redeemed_at DATETIME NOT NULL,
+		fulfilled INTEGER NOT NULL DEFAULT 0,
+		fulfilled_at DATETIME
+	)`,
+	`CREATE TABLE IF NOT EXISTS score_entries (
+		id TEXT PRIMARY KEY,
+		email TEXT NOT NULL REFERENCES users (email) ON DELETE CASCADE,
+		home_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		task TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		at DATETIME NOT NULL,
+		waived INTEGER NOT NULL DEFAULT 0,
+		waived_by TEXT,
+		waive_reason TEXT,
+		waived_at DATETIME
+	)`,
+	`CREATE INDEX IF NOT EXISTS score_entries_email ON score_entries (email, at)`,
+	`CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key TEXT PRIMARY KEY,
+		fingerprint TEXT NOT NULL,
+		expires_at DATETIME NOT NULL,
+		status INTEGER,
+		header TEXT,
+		body BLOB
+	)`,
+	`CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at ON idempotency_keys (expires_at)`,
+	`CREATE TABLE IF NOT EXISTS webhooks (
+		id TEXT PRIMARY KEY,
+		home_id TEXT NOT NULL REFERENCES homes (id) ON DELETE CASCADE,
+		url TEXT NOT NULL,
+		events TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS webhooks_home_id ON webhooks (home_id)`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		webhook_id TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+		event TEXT NOT NULL,
+		payload BLOB NOT NULL,
+		status TEXT NOT NULL,
+		next_attempt_at DATETIME,
+		attempt_count INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at)`,
+	`CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, created_at)`,
+	`CREATE TABLE IF NOT EXISTS webhook_attempts (
+		delivery_id TEXT NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+		number INTEGER NOT NULL,
+		at DATETIME NOT NULL,
+		status_code INTEGER,
+		error TEXT,
+		duration_ms INTEGER NOT NULL,
+		PRIMARY KEY (delivery_id, number)
+	)`,
+}
+
+// columns lista as colunas acrescentadas depois da criação das tabelas, que
+// arquivos de versões anteriores ainda não têm
+var columns = []struct {
+	table      string
+	name       string
+	definition string
+}{
+	{"homes", "deleted_at", "DATETIME"},
+	{"homes", "deleted_by", "TEXT"},
+	{"users", "version", "INTEGER NOT NULL DEFAULT 1"},
+	{"homes", "version", "INTEGER NOT NULL DEFAULT 1"},
+	{"tasks", "version", "INTEGER NOT NULL DEFAULT 1"},
+	{"deleted_tasks", "version", "INTEGER NOT NULL DEFAULT 1"},
+}
+
+// CreateSchema cria as tabelas e colunas que ainda não existem, na inicialização do servidor
+func CreateSchema(ctx context.Context, db *database.SQLiteHandler) error {
+	for _, statement := range schema {
+		if _, err := db.DB.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	for _, column := range columns {
+		var exists bool
+		err := db.DB.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM pragma_table_info(?) WHERE name = ?)`,
+			column.table, column.name,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		_, err = db.DB.ExecContext(ctx,
+			fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, column.table, column.name, column.definition),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type base struct {
+	db *database.SQLiteHandler
+}
+
+// inTx executa fn numa transação, desfeita se fn retornar erro
+func (b base) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := b.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkVersion confere a versão das linhas de table selecionadas por where antes
+// de uma gravação condicional, retornando repository.ErrNotFound se não houver
+// nenhuma. Com version zero qualquer versão serve. Como há uma única conexão
+// aberta, nada é gravado entre a conferência e a gravação da mesma transação.
+func checkVersion(ctx context.Context, tx *sql.Tx, table string, where string, version int64, args ...interface{}) error {
+	var found, stale bool
+	err := tx.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT count(*) > 0, coalesce(max(version != ?), 0) FROM %s WHERE %s`, table, where),
+		append([]interface{}{version}, args...)...,
+	).Scan(&found, &stale)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return repository.ErrNotFound
+	}
+	if version != 0 && stale {
+		return repository.ErrStaleVersion
+	}
+	return nil
+}
+
+// timestamp grava horários em UTC, para que a ordem do texto siga a cronológica,
+// e horários vazios como NULL
+func timestamp(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// nullable grava textos vazios como NULL
+func nullable(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// taskColumns lista as colunas compartilhadas por tasks e deleted_tasks
+const taskColumns = `home_id, name, reward, recurrence, status, streak, last_completed_at,
+	pending_since, assignee, due_at, overdue_penalized`
+
+// settingsColumns lista as configurações da casa h na ordem esperada por settingsDest
+const settingsColumns = `h.grace_days, h.streak_bonus, h.streak_bonus_cap,
+	h.pricing_mode, h.pricing_curve, h.pricing_rate, h.pricing_cap,
+	h.penalty_overdue, h.penalty_rejected`
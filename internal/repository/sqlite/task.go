@@ -0,0 +1,181 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/task"
+)
+
+type TaskRepository struct {
+	base
+}
+
+func NewTaskRepository(db *database.SQLiteHandler) *TaskRepository {
+	return &TaskRepository{base{db}}
+}
+
+func (r *TaskRepository) Save(ctx context.Context, email string, homeID string, taskData task.Task) (task.Task, error) {
+	var version int64
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		homeIDs, err := homesOf(ctx, tx, email, homeID)
+		if err != nil {
+			return err
+		}
+		if len(homeIDs) == 0 {
+			return repository.ErrNotFound
+		}
+
+		// Tarefa reaberta volta a valorizar a partir de agora
+		now := timestamp(time.Now())
+		for _, id := range homeIDs {
+			_, err = tx.ExecContext(ctx,
+				`DELETE FROM deleted_tasks WHERE home_id = ? AND name = ?`,
+				id, taskData.Name,
+			)
+			if err != nil {
+				return err
+			}
+
+			err = tx.QueryRowContext(ctx,
+				`INSERT INTO tasks (home_id, name, reward, recurrence, pending_since) VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT (home_id, name) DO UPDATE SET
+					reward = excluded.reward,
+					recurrence = excluded.recurrence,
+					pending_since = CASE WHEN status = ? THEN coalesce(pending_since, excluded.pending_since) ELSE excluded.pending_since END,
+					status = ?,
+					version = version + 1
+				RETURNING version`,
+				id, taskData.Name, taskData.Reward, nullable(string(taskData.Recurrence)), now,
+				task.Pending, task.Pending,
+			).Scan(&version)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	return task.Task{
+		Name:       taskData.Name,
+		Reward:     taskData.Reward,
+		Recurrence: taskData.Recurrence,
+		Version:    version,
+	}, nil
+}
+
+func (r *TaskRepository) Update(ctx context.Context, email string, homeID string, taskData task.Task) (task.Task, error) {
+	updated := task.Task{
+		Name:   taskData.Name,
+		Reward: taskData.Reward,
+		Status: taskData.Status,
+	}
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		err := checkVersion(ctx, tx, "tasks", `name = ? AND home_id IN (`+activeHomesIn+`)`,
+			taskData.Version, taskData.Name, email, homeID, homeID,
+		)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx,
+			`UPDATE tasks SET reward = ?, status = ?, version = version + 1
+			WHERE name = ? AND home_id IN (`+activeHomesIn+`)
+			RETURNING version`,
+			taskData.Reward, taskData.Status, taskData.Name, email, homeID, homeID,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			if err := rows.Scan(&updated.Version); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	return updated, nil
+}
+
+// Delete copia a tarefa para deleted_tasks, substituindo uma exclusão anterior
+// de mesmo nome, e a remove de tasks
+func (r *TaskRepository) Delete(ctx context.Context, email string, homeID string, name string, version int64, at time.Time) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		err := checkVersion(ctx, tx, "tasks", `name = ? AND home_id IN (`+activeHomesIn+`)`,
+			version, name, email, homeID, homeID,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT OR REPLACE INTO deleted_tasks (`+taskColumns+`, version, deleted_at, deleted_by)
+			SELECT `+taskColumns+`, version + 1, ?, ? FROM tasks
+			WHERE name = ? AND home_id IN (`+activeHomesIn+`)`,
+			timestamp(at), email, name, email, homeID, homeID,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM tasks
+			WHERE name = ? AND home_id IN (`+activeHomesIn+`)`,
+			name, email, homeID, homeID,
+		)
+		return err
+	})
+}
+
+func (r *TaskRepository) FindByUser(ctx context.Context, email string, homeID string) ([]task.Entry, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT t.name, t.reward, coalesce(t.status, ''), coalesce(t.recurrence, ''), t.streak,
+			t.pending_since, coalesce(t.assignee, ''), t.due_at, t.version,
+			h.pricing_mode, h.pricing_curve, h.pricing_rate, h.pricing_cap
+		FROM residents r
+		JOIN homes h ON h.id = r.home_id
+		JOIN tasks t ON t.home_id = r.home_id
+		WHERE r.email = ? AND h.deleted_at IS NULL AND `+inHome("r.home_id")+`
+		ORDER BY r.rowid, t.rowid`,
+		email, homeID, homeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []task.Entry
+	for rows.Next() {
+		var entry task.Entry
+		var pendingSince, dueAt sql.NullTime
+		err := rows.Scan(&entry.Task.Name, &entry.Task.Reward, &entry.Task.Status, &entry.Task.Recurrence, &entry.Task.Streak,
+			&pendingSince, &entry.Task.Assignee, &dueAt, &entry.Task.Version,
+			&entry.Pricing.Mode, &entry.Pricing.Curve, &entry.Pricing.Rate, &entry.Pricing.Cap,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.PendingSince = pendingSince.Time
+		if dueAt.Valid {
+			entry.Task.DueAt = &dueAt.Time
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+type UserRepository struct {
+	base
+}
+
+func NewUserRepository(db *database.SQLiteHandler) *UserRepository {
+	return &UserRepository{base{db}}
+}
+
+func (r *UserRepository) Save(ctx context.Context, userData user.User) error {
+	_, err := r.db.DB.ExecContext(ctx,
+		`INSERT INTO users (email, name, password) VALUES (?, ?, ?)
+		ON CONFLICT (email) DO UPDATE SET
+			name = excluded.name,
+			password = excluded.password,
+			version = version + 1`,
+		userData.Email, userData.Name, userData.Password,
+	)
+	return err
+}
+
+func (r *UserRepository) Update(ctx context.Context, userData user.User) (user.User, error) {
+	updated := user.User{Name: userData.Name, Email: userData.Email}
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkVersion(ctx, tx, "users", "email = ?", userData.Version, userData.Email); err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx,
+			`UPDATE users SET name = ?, password = coalesce(?, password), version = version + 1 WHERE email = ?
+			RETURNING version`,
+			userData.Name, nullable(userData.Password), userData.Email,
+		).Scan(&updated.Version)
+	})
+	if err != nil {
+		return user.User{}, err
+	}
+
+	return updated, nil
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]user.User, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT name, email, password, version FROM users ORDER BY email`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []user.User
+	for rows.Next() {
+		var u user.User
+		if err := rows.Scan(&u.Name, &u.Email, &u.Password, &u.Version); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (user.User, error) {
+	var u user.User
+	err := r.db.DB.QueryRowContext(ctx,
+		`SELECT name, email, score, daily_streak, weekly_streak, version FROM users WHERE email = ?`,
+		email,
+	).Scan(&u.Name, &u.Email, &u.Score, &u.DailyStreak, &u.WeeklyStreak, &u.Version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.User{}, repository.ErrNotFound
+	}
+	return u, err
+}
+
+func (r *UserRepository) DeleteByEmail(ctx context.Context, email string, version int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		err := checkVersion(ctx, tx, "users", "email = ?", version, email)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// As chaves estrangeiras removem junto as moradias, conquistas, resgates e penalidades
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM users WHERE email = ?`,
+			email,
+		)
+		return err
+	})
+}
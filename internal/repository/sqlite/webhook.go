@@ -0,0 +1,285 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/webhook"
+)
+
+type WebhookRepository struct {
+	base
+}
+
+func NewWebhookRepository(db *database.SQLiteHandler) *WebhookRepository {
+	return &WebhookRepository{base{db}}
+}
+
+const webhookColumns = `w.id, w.home_id, w.url, w.events, w.secret, w.created_by, w.created_at`
+
+// checkAdmin retorna repository.ErrForbidden se adminEmail não administrar a casa ativa
+func (r *WebhookRepository) checkAdmin(ctx context.Context, adminEmail string, homeID string) error {
+	var admin bool
+	err := r.db.DB.QueryRowContext(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM residents r JOIN homes h ON h.id = r.home_id
+			WHERE r.home_id = ? AND r.email = ? AND r.role = ? AND h.deleted_at IS NULL
+		)`,
+		homeID, adminEmail, home.Admin,
+	).Scan(&admin)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return repository.ErrForbidden
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, adminEmail string, hook webhook.Webhook) error {
+	if err := r.checkAdmin(ctx, adminEmail, hook.Home); err != nil {
+		return err
+	}
+
+	eventTypes, err := json.Marshal(hook.Events)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.DB.ExecContext(ctx,
+		`INSERT INTO webhooks (id, home_id, url, events, secret, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		hook.ID.String(), hook.Home, hook.URL, string(eventTypes), hook.Secret, hook.CreatedBy, timestamp(hook.CreatedAt),
+	)
+	return err
+}
+
+func (r *WebhookRepository) FindByHome(ctx context.Context, adminEmail string, homeID string) ([]webhook.Webhook, error) {
+	if err := r.checkAdmin(ctx, adminEmail, homeID); err != nil {
+		return nil, err
+	}
+
+	return r.query(ctx,
+		`SELECT `+webhookColumns+` FROM webhooks w WHERE w.home_id = ? ORDER BY w.created_at`,
+		homeID,
+	)
+}
+
+func (r *WebhookRepository) FindByID(ctx context.Context, adminEmail string, homeID string, id string) (webhook.Webhook, error) {
+	if err := r.checkAdmin(ctx, adminEmail, homeID); err != nil {
+		return webhook.Webhook{}, err
+	}
+
+	hooks, err := r.query(ctx,
+		`SELECT `+webhookColumns+` FROM webhooks w WHERE w.home_id = ? AND w.id = ?`,
+		homeID, id,
+	)
+	if err != nil {
+		return webhook.Webhook{}, err
+	}
+	if len(hooks) == 0 {
+		return webhook.Webhook{}, repository.ErrNotFound
+	}
+	return hooks[0], nil
+}
+
+// Delete conta com ON DELETE CASCADE para remover entregas e tentativas
+func (r *WebhookRepository) Delete(ctx context.Context, adminEmail string, homeID string, id string) error {
+	if err := r.checkAdmin(ctx, adminEmail, homeID); err != nil {
+		return err
+	}
+
+	result, err := r.db.DB.ExecContext(ctx,
+		`DELETE FROM webhooks WHERE home_id = ? AND id = ?`,
+		homeID, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Subscribed(ctx context.Context, homeID string, eventType events.Type) ([]webhook.Webhook, error) {
+	hooks, err := r.query(ctx,
+		`SELECT `+webhookColumns+`
+		FROM webhooks w JOIN homes h ON h.id = w.home_id
+		WHERE h.deleted_at IS NULL AND w.home_id = ?`,
+		homeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscribed []webhook.Webhook
+	for _, hook := range hooks {
+		if hook.Subscribes(eventType) {
+			subscribed = append(subscribed, hook)
+		}
+	}
+	return subscribed, nil
+}
+
+func (r *WebhookRepository) query(ctx context.Context, query string, args ...interface{}) ([]webhook.Webhook, error) {
+	rows, err := r.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []webhook.Webhook
+	for rows.Next() {
+		var hook webhook.Webhook
+		var eventTypes string
+		if err := rows.Scan(&hook.ID, &hook.Home, &hook.URL, &eventTypes, &hook.Secret, &hook.CreatedBy, &hook.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(eventTypes), &hook.Events); err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+func (r *WebhookRepository) Enqueue(ctx context.Context, deliveries []webhook.Delivery) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for _, delivery := range deliveries {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO webhook_deliveries (id, webhook_id, event, payload, status, next_attempt_at, attempt_count, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				delivery.ID.String(), delivery.Webhook.String(), delivery.Event, []byte(delivery.Payload),
+				delivery.Status, nextAttempt(delivery.NextAttemptAt), delivery.AttemptCount, timestamp(delivery.CreatedAt),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *WebhookRepository) Due(ctx context.Context, now time.Time, limit int) ([]webhook.Delivery, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT d.id, d.webhook_id, d.event, d.payload, d.status, d.next_attempt_at, d.attempt_count, d.created_at,
+			w.url, w.secret
+		FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = ? AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at
+		LIMIT ?`,
+		webhook.Pending, timestamp(now), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []webhook.Delivery
+	for rows.Next() {
+		var delivery webhook.Delivery
+		var nextAttemptAt sql.NullTime
+		err := rows.Scan(&delivery.ID, &delivery.Webhook, &delivery.Event, &delivery.Payload, &delivery.Status,
+			&nextAttemptAt, &delivery.AttemptCount, &delivery.CreatedAt, &delivery.URL, &delivery.Secret)
+		if err != nil {
+			return nil, err
+		}
+		if nextAttemptAt.Valid {
+			delivery.NextAttemptAt = &nextAttemptAt.Time
+		}
+		due = append(due, delivery)
+	}
+	return due, rows.Err()
+}
+
+func (r *WebhookRepository) Record(ctx context.Context, delivery webhook.Delivery, attempt webhook.Attempt) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx,
+			`UPDATE webhook_deliveries SET status = ?, next_attempt_at = ?, attempt_count = ? WHERE id = ?`,
+			delivery.Status, nextAttempt(delivery.NextAttemptAt), delivery.AttemptCount, delivery.ID.String(),
+		)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return repository.ErrNotFound
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO webhook_attempts (delivery_id, number, at, status_code, error, duration_ms)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			delivery.ID.String(), attempt.Number, timestamp(attempt.At), attempt.StatusCode, nullable(attempt.Error), attempt.DurationMs,
+		)
+		return err
+	})
+}
+
+func (r *WebhookRepository) Deliveries(ctx context.Context, id string, limit int) ([]webhook.Delivery, error) {
+	rows, err := r.db.DB.QueryContext(ctx,
+		`SELECT d.id, d.webhook_id, d.event, d.payload, d.status, d.next_attempt_at, d.attempt_count, d.created_at,
+			a.number, a.at, a.status_code, a.error, a.duration_ms
+		FROM (
+			SELECT *, rowid AS seq FROM webhook_deliveries WHERE webhook_id = ? ORDER BY created_at DESC, rowid DESC LIMIT ?
+		) d
+		LEFT JOIN webhook_attempts a ON a.delivery_id = d.id
+		ORDER BY d.created_at DESC, d.seq DESC, a.number`,
+		id, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []webhook.Delivery
+	for rows.Next() {
+		var delivery webhook.Delivery
+		var nextAttemptAt sql.NullTime
+		var number, statusCode, durationMs sql.NullInt64
+		var at sql.NullTime
+		var attemptError sql.NullString
+		err := rows.Scan(&delivery.ID, &delivery.Webhook, &delivery.Event, &delivery.Payload, &delivery.Status,
+			&nextAttemptAt, &delivery.AttemptCount, &delivery.CreatedAt,
+			&number, &at, &statusCode, &attemptError, &durationMs)
+		if err != nil {
+			return nil, err
+		}
+
+		// As tentativas vêm em linhas seguidas, uma por tentativa da mesma entrega
+		if last := len(deliveries) - 1; last < 0 || deliveries[last].ID != delivery.ID {
+			if nextAttemptAt.Valid {
+				delivery.NextAttemptAt = &nextAttemptAt.Time
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		if number.Valid {
+			last := &deliveries[len(deliveries)-1]
+			last.Attempts = append(last.Attempts, webhook.Attempt{
+				Number:     int(number.Int64),
+				At:         at.Time,
+				StatusCode: int(statusCode.Int64),
+				Error:      attemptError.String,
+				DurationMs: durationMs.Int64,
+			})
+		}
+	}
+	return deliveries, rows.Err()
+}
+
+func nextAttempt(at *time.Time) interface{} {
+	if at == nil {
+		return nil
+	}
+	return timestamp(*at)
+}
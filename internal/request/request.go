@@ -0,0 +1,26 @@
+// Package request lê os dados comuns às rotas antigas e às de /api/v1, para que
+// o mesmo handler atenda às duas enquanto as antigas não forem removidas.
+package request
+
+import "github.com/gin-gonic/gin"
+
+// UserHeader identifica o usuário nas rotas de /api/v1
+const UserHeader = "X-User-Email"
+
+// User retorna o email do usuário que faz a requisição, do cabeçalho X-User-Email
+// ou, nas rotas antigas, do parâmetro ?user=, já normalizado
+func User(c *gin.Context) string {
+	if email := c.GetHeader(UserHeader); email != "" {
+		return NormalizeEmail(email)
+	}
+	return NormalizeEmail(c.Query("user"))
+}
+
+// Param retorna o parâmetro do caminho, como em /homes/:id, ou o parâmetro de
+// consulta de mesmo nome usado pelas rotas antigas, como em /home?id=
+func Param(c *gin.Context, name string) string {
+	if value := c.Param(name); value != "" {
+		return value
+	}
+	return c.Query(name)
+}
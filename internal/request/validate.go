@@ -0,0 +1,195 @@
+package request
+
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"gopkg.in/validator.v2"
+)
+
+// Normalizer é implementado pelos modelos que ajustam os dados recebidos antes
+// da validação, como o email em minúsculas e sem espaços
+type Normalizer interface {
+	Normalize()
+}
+
+// Regras disponíveis na tag validate dos modelos, além das do validator.v2:
+//
+//	nonzero          campo obrigatório; para datas, diferente do instante zero
+//	email            endereço de email sem nome de exibição
+//	url              URL absoluta http ou https
+//	oneof=a|b        um dos valores listados, ou vazio
+//	minlen, maxlen   número de caracteres de textos; vazio só é recusado por nonzero
+//	nonnegative      números a partir de zero
+//	positive         números maiores que zero
+//
+// Em números, min e max do validator.v2 limitam o valor.
+var rules = validator.NewValidator().WithPrintJSON(true)
+
+var (
+	errEmail       = errors.New("email inválido")
+	errURL         = errors.New("URL inválida")
+	errNotAllowed  = errors.New("valor fora das opções permitidas")
+	errTooShort    = errors.New("texto curto demais")
+	errTooLong     = errors.New("texto longo demais")
+	errNegative    = errors.New("valor negativo")
+	errNotPositive = errors.New("valor não positivo")
+)
+
+// codes associa os erros das regras aos códigos de apierror
+var codes = map[error]apierror.Code{
+	validator.ErrZeroValue: apierror.Required,
+	validator.ErrMin:       apierror.BelowMinimum,
+	validator.ErrMax:       apierror.AboveMaximum,
+	validator.ErrRegexp:    apierror.InvalidValue,
+	errEmail:               apierror.InvalidEmail,
+	errURL:                 apierror.InvalidURL,
+	errNotAllowed:          apierror.NotAllowed,
+	errTooShort:            apierror.TooShort,
+	errTooLong:             apierror.TooLong,
+	errNegative:            apierror.Negative,
+	errNotPositive:         apierror.NotPositive,
+}
+
+func init() {
+	rules.SetValidationFunc("nonzero", func(v interface{}, _ string) error {
+		if reflect.ValueOf(v).IsZero() {
+			return validator.ErrZeroValue
+		}
+		return nil
+	})
+	rules.SetValidationFunc("email", func(v interface{}, _ string) error {
+		email := reflect.ValueOf(v).String()
+		if email == "" {
+			return nil
+		}
+		if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+			return errEmail
+		}
+		return nil
+	})
+	rules.SetValidationFunc("url", func(v interface{}, _ string) error {
+		text := reflect.ValueOf(v).String()
+		if text == "" {
+			return nil
+		}
+		if u, err := url.Parse(text); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errURL
+		}
+		return nil
+	})
+	rules.SetValidationFunc("oneof", func(v interface{}, param string) error {
+		value := reflect.ValueOf(v).String()
+		if value == "" {
+			return nil
+		}
+		for _, allowed := range strings.Split(param, "|") {
+			if value == allowed {
+				return nil
+			}
+		}
+		return errNotAllowed
+	})
+	rules.SetValidationFunc("minlen", func(v interface{}, param string) error {
+		limit, err := strconv.Atoi(param)
+		if err != nil {
+			return validator.ErrBadParameter
+		}
+		text := reflect.ValueOf(v).String()
+		if text != "" && utf8.RuneCountInString(text) < limit {
+			return errTooShort
+		}
+		return nil
+	})
+	rules.SetValidationFunc("maxlen", func(v interface{}, param string) error {
+		limit, err := strconv.Atoi(param)
+		if err != nil {
+			return validator.ErrBadParameter
+		}
+		if utf8.RuneCountInString(reflect.ValueOf(v).String()) > limit {
+			return errTooLong
+		}
+		return nil
+	})
+	rules.SetValidationFunc("nonnegative", func(v interface{}, _ string) error {
+		if reflect.ValueOf(v).Int() < 0 {
+			return errNegative
+		}
+		return nil
+	})
+	rules.SetValidationFunc("positive", func(v interface{}, _ string) error {
+		if reflect.ValueOf(v).Int() <= 0 {
+			return errNotPositive
+		}
+		return nil
+	})
+}
+
+// NormalizeEmail deixa o email em minúsculas e sem espaços, a forma em que é guardado
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Bind lê o corpo JSON em v, normaliza e valida. Em caso de erro já responde ao
+// cliente e retorna false, e o handler só precisa retornar.
+func Bind(c *gin.Context, v interface{}) bool {
+	if err := c.ShouldBindJSON(v); err != nil {
+		apierror.Respond(c, apierror.Binding(err))
+		return false
+	}
+	return Validate(c, v)
+}
+
+// Validate normaliza e valida v, para handlers que completam o modelo depois de
+// lê-lo, como com o nome da tarefa vindo do caminho. Responde como Bind.
+func Validate(c *gin.Context, v interface{}) bool {
+	if err := Check(v); err != nil {
+		apierror.Respond(c, err)
+		return false
+	}
+	return true
+}
+
+// Check normaliza e valida v, retornando os campos rejeitados como *apierror.Error
+func Check(v interface{}) error {
+	if normalizer, ok := v.(Normalizer); ok {
+		normalizer.Normalize()
+	}
+
+	err := rules.Validate(v)
+	if err == nil {
+		return nil
+	}
+
+	var errorMap validator.ErrorMap
+	if !errors.As(err, &errorMap) {
+		return err
+	}
+
+	// em ordem alfabética, para que a resposta não mude entre requisições iguais
+	names := make([]string, 0, len(errorMap))
+	for name := range errorMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fields []apierror.FieldError
+	for _, name := range names {
+		for _, fieldErr := range errorMap[name] {
+			code, found := codes[fieldErr]
+			if !found {
+				code = apierror.InvalidValue
+			}
+			fields = append(fields, apierror.Field(name, code))
+		}
+	}
+	return apierror.Invalid(fields...)
+}
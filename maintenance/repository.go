@@ -0,0 +1,32 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+)
+
+// Kind identifica o tipo de nó órfão encontrado pela varredura
+type Kind string
+
+const (
+	Task       Kind = "task"
+	Home       Kind = "home"
+	Reward     Kind = "reward"
+	Redemption Kind = "redemption"
+	ScoreEntry Kind = "score_entry"
+)
+
+// Orphan é um registro que perdeu a ligação com a casa ou o morador que o mantinha
+type Orphan struct {
+	Kind Kind
+	ID   string
+}
+
+type MaintenanceRepository interface {
+	// Orphans lista tarefas sem casa, casas sem moradores, recompensas sem casa,
+	// resgates sem recompensa ou morador e penalidades sem morador
+	Orphans(ctx context.Context) ([]Orphan, error)
+	// Purge remove definitivamente as casas e tarefas que estão na lixeira desde antes de before,
+	// com tudo o que só existia por causa delas, e retorna quantos itens saíram da lixeira
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
@@ -0,0 +1,73 @@
+package maintenance
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// maxReported limita quantos ids de cada tipo aparecem no log
+const maxReported = 10
+
+// ScanOrphans procura periodicamente registros órfãos e os reporta no log, sem removê-los
+func ScanOrphans(ctx context.Context, maintenance MaintenanceRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			orphans, err := maintenance.Orphans(ctx)
+			if err != nil {
+				log.Println(err.Error())
+				continue
+			}
+			report(orphans)
+		}
+	}
+}
+
+func report(orphans []Orphan) {
+	var kinds []Kind
+	byKind := make(map[Kind][]string)
+	for _, o := range orphans {
+		if _, found := byKind[o.Kind]; !found {
+			kinds = append(kinds, o.Kind)
+		}
+		byKind[o.Kind] = append(byKind[o.Kind], o.ID)
+	}
+
+	for _, kind := range kinds {
+		ids := byKind[kind]
+		shown := ids
+		if len(shown) > maxReported {
+			shown = shown[:maxReported]
+		}
+		log.Printf("Encontrados %d registros órfãos do tipo %s: %s", len(ids), kind, strings.Join(shown, ", "))
+	}
+}
+
+// PurgeTrash remove periodicamente o que está na lixeira há mais que retention
+func PurgeTrash(ctx context.Context, maintenance MaintenanceRepository, interval time.Duration, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purged, err := maintenance.Purge(ctx, now.Add(-retention))
+			if err != nil {
+				log.Println(err.Error())
+				continue
+			}
+			if purged > 0 {
+				log.Printf("%d itens da lixeira excluídos definitivamente", purged)
+			}
+		}
+	}
+}
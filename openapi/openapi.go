@@ -0,0 +1,72 @@
+// Package openapi publica a especificação da API, descrita à mão em openapi.yaml,
+// e confere as requisições e respostas do servidor contra ela.
+package openapi
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// Load lê e valida a especificação embutida no binário
+func Load() (*openapi3.T, error) {
+	openapi3.DefineStringFormat("uuid", openapi3.FormatOfStringForUUIDOfRFC4122)
+	// sem o esquema inteiro nas mensagens de erro devolvidas aos clientes
+	openapi3.SchemaErrorDetailsDisabled = true
+
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler a especificação: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("especificação inválida: %w", err)
+	}
+	return doc, nil
+}
+
+// YAMLHandler serve a especificação como foi escrita
+func YAMLHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/yaml", spec)
+	}
+}
+
+// JSONHandler serve a especificação convertida para JSON
+func JSONHandler(doc *openapi3.T) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// DocsHandler serve o Swagger UI apontando para a especificação em specURL. Os
+// arquivos do Swagger UI vêm do unpkg, para não embutir o pacote no binário.
+func DocsHandler(specURL string) gin.HandlerFunc {
+	page := fmt.Sprintf(docsPage, specURL)
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+	}
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+  <meta charset="utf-8">
+  <title>go-to-do-list</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: %q, dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
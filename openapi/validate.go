@@ -0,0 +1,202 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/mergepatch"
+)
+
+// Validate rejeita com 400 as requisições que não seguem a especificação, antes
+// de chegarem aos handlers. Rotas ausentes da especificação passam sem conferência.
+//
+// Com GIN_MODE=test as respostas também são conferidas, e uma resposta fora da
+// especificação vira um 500, para que a divergência apareça nos testes.
+func Validate(doc *openapi3.T) (gin.HandlerFunc, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// a autenticação dos tokens fica com os handlers, a especificação só a documenta
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		MultiError:         true,
+	}
+
+	// Um merge patch é JSON comum para a conferência do corpo
+	openapi3filter.RegisterBodyDecoder(mergepatch.ContentType, openapi3filter.JSONBodyDecoder)
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			apierror.Respond(c, requestError(err))
+			return
+		}
+
+		// As conexões de eventos não terminam, e não há resposta a conferir
+		if gin.Mode() != gin.TestMode || events.IsStream(c.Request) {
+			c.Next()
+			return
+		}
+		validateResponse(c, input, route)
+	}, nil
+}
+
+// validateResponse guarda a resposta do handler e só a envia se ela seguir a especificação
+func validateResponse(c *gin.Context, input *openapi3filter.RequestValidationInput, route *routers.Route) {
+	original := c.Writer
+	recorder := &responseRecorder{ResponseWriter: original, status: http.StatusOK}
+	c.Writer = recorder
+	c.Next()
+	c.Writer = original
+
+	err := openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 recorder.status,
+		Header:                 original.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+		Options:                input.Options,
+	})
+	if err != nil {
+		original.Header().Del("Content-Length")
+		apierror.Respond(c, fmt.Errorf("Resposta de %s fora da especificação: %w", route.Path, err))
+		return
+	}
+
+	original.WriteHeader(recorder.status)
+	if recorder.body.Len() > 0 {
+		original.Write(recorder.body.Bytes())
+	}
+}
+
+// requestError lista os campos rejeitados pela especificação: os parâmetros e
+// as propriedades do corpo. Sem campos, o corpo não pôde ser lido.
+func requestError(err error) *apierror.Error {
+	var fields []apierror.FieldError
+	body := false
+
+	var visit func(err error, name string)
+	visit = func(err error, name string) {
+		switch e := err.(type) {
+		case nil:
+		case openapi3.MultiError:
+			for _, inner := range e {
+				visit(inner, name)
+			}
+		case *openapi3filter.RequestError:
+			if e.RequestBody != nil {
+				body = true
+			}
+			if e.Parameter != nil {
+				name = e.Parameter.Name
+			}
+			if e.Err == nil && name != "" {
+				fields = append(fields, apierror.Field(name, apierror.InvalidValue))
+			}
+			visit(e.Err, name)
+		case *openapi3.SchemaError:
+			if pointer := e.JSONPointer(); len(pointer) > 0 {
+				name = strings.Join(pointer, ".")
+			}
+			if name != "" {
+				fields = append(fields, apierror.Field(name, schemaCode(e)))
+			}
+		default:
+			if name == "" {
+				return
+			}
+			code := apierror.InvalidValue
+			if errors.Is(err, openapi3filter.ErrInvalidRequired) {
+				code = apierror.Required
+			}
+			fields = append(fields, apierror.Field(name, code))
+		}
+	}
+	visit(err, "")
+
+	switch {
+	case len(fields) > 0:
+		return apierror.Invalid(fields...)
+	case body:
+		return apierror.New(apierror.InvalidBody)
+	default:
+		return apierror.New(apierror.InvalidRequest)
+	}
+}
+
+func schemaCode(err *openapi3.SchemaError) apierror.Code {
+	switch err.SchemaField {
+	case "required":
+		return apierror.Required
+	case "type":
+		return apierror.InvalidType
+	case "enum":
+		return apierror.NotAllowed
+	default:
+		return apierror.InvalidValue
+	}
+}
+
+// responseRecorder retém o status e o corpo escritos pelo handler
+type responseRecorder struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *responseRecorder) WriteHeaderNow() {
+	r.written = true
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.written = true
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.written = true
+	return r.body.WriteString(s)
+}
+
+func (r *responseRecorder) Status() int {
+	return r.status
+}
+
+func (r *responseRecorder) Size() int {
+	if !r.written {
+		return -1
+	}
+	return r.body.Len()
+}
+
+func (r *responseRecorder) Written() bool {
+	return r.written
+}
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/backup"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/reward"
+	"github.com/nsbnroque/go-to-do-list/score"
+	syncchannel "github.com/nsbnroque/go-to-do-list/sync_channel"
+	"github.com/nsbnroque/go-to-do-list/task"
+	"github.com/nsbnroque/go-to-do-list/user"
+)
+
+// registerLegacyRoutes mantém as rotas anteriores a /api/v1, com os mesmos
+// handlers, até que os clientes migrem. O usuário vem do parâmetro ?user=.
+func registerLegacyRoutes(r *gin.RouterGroup, store *storage, syncChannel syncchannel.SyncChannel) {
+	r.POST("/users", user.CreateUserHandler(store.users))
+	r.GET("/users", user.FindAllUsersHandler(store.users))
+	r.GET("/users/find", user.FindByEmailHandler(store.users, store.achievements))
+	r.PUT("/users", user.UpdateUserHandler(store.users))
+	r.POST("/tasks", task.CreateTaskHandler(store.tasks, syncChannel.Events))
+	r.PUT("/tasks", task.ChangeTaskHandler(store.tasks, syncChannel.Events))
+	r.DELETE("/tasks", task.DeleteTaskHandler(store.tasks, syncChannel.Events))
+	r.POST("/tasks/complete", syncchannel.CompleteTaskHandler(store.completions, syncChannel))
+	r.POST("/tasks/assign", syncchannel.AssignTaskHandler(store.completions, syncChannel))
+	r.POST("/tasks/reject", syncchannel.RejectTaskHandler(store.completions, syncChannel))
+	r.GET("/tasks", task.GetTasksForUserHandler(store.tasks))
+	r.POST("/home", home.CreateHomeHandler(store.homes))
+	r.PATCH("/home", home.AddResidentToHomeHandler(store.homes, syncChannel.Events))
+	r.GET("/home", home.GetHomeHandler(store.homes))
+	r.GET("/home/feed", home.GetHomeFeedHandler(store.homes))
+	r.GET("/home/settings", home.GetHomeSettingsHandler(store.homes))
+	r.PUT("/home/settings", home.UpdateHomeSettingsHandler(store.homes))
+	r.DELETE("/home/:id", home.DeleteHomeHandler(store.homes))
+	r.GET("/home/:id/trash", home.GetTrashHandler(store.homes))
+	r.POST("/home/:id/restore", home.RestoreHandler(store.homes))
+	r.POST("/rewards", reward.CreateRewardHandler(store.rewards))
+	r.GET("/rewards", reward.GetRewardsHandler(store.rewards))
+	r.POST("/rewards/:reward/redeem", reward.RedeemRewardHandler(store.rewards, syncChannel.Events))
+	r.GET("/rewards/redemptions", reward.GetRedemptionsHandler(store.rewards))
+	r.PATCH("/rewards/redemptions/:redemption", reward.FulfillRedemptionHandler(store.rewards))
+	r.GET("/score/history", score.GetScoreHistoryHandler(store.scores))
+	r.POST("/score/:entry/waive", score.WaivePenaltyHandler(store.scores, syncChannel.Events))
+
+	admin := r.Group("/admin", backup.RequireToken(os.Getenv("BACKUP_TOKEN")))
+	admin.GET("/backup", backup.ExportHandler(store.backups))
+	admin.POST("/restore", backup.RestoreHandler(store.backups))
+}
+
+// deprecated anuncia nas respostas das rotas antigas que elas serão removidas
+// e aponta para /api/v1
+func deprecated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", `</api/v1>; rel="successor-version"`)
+		c.Next()
+	}
+}
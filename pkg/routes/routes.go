@@ -1,42 +1,182 @@
 package routes
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/idempotency"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
 	"github.com/nsbnroque/go-to-do-list/internal/database"
-	"github.com/nsbnroque/go-to-do-list/task"
-	"github.com/nsbnroque/go-to-do-list/user"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+	"github.com/nsbnroque/go-to-do-list/maintenance"
+	"github.com/nsbnroque/go-to-do-list/openapi"
+	syncchannel "github.com/nsbnroque/go-to-do-list/sync_channel"
+	"github.com/nsbnroque/go-to-do-list/webhook"
 )
 
 func HandleRequests() {
-	dbHandler, err := database.NewDatabaseHandler()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store, err := openStorage(ctx)
 	if err != nil {
-		log.Fatalf("Falha ao obter o handler do banco de dados: %v", err)
+		log.Fatalf("Falha ao abrir o armazenamento: %v", err)
+	}
+
+	syncChannel := syncchannel.NewSyncChannel()
+	go syncchannel.SyncTasks(ctx, store.achievements, syncChannel)
+	hub := events.NewHub()
+	dispatcher := webhook.NewDispatcher(store.webhooks)
+	go syncchannel.PublishEvents(ctx, store.users, syncChannel, hub, dispatcher)
+	go dispatcher.Run(ctx, webhookRetryInterval())
+	go syncchannel.SweepOverdue(ctx, store.completions, sweepInterval())
+	go maintenance.ScanOrphans(ctx, store.maintenance, orphanScanInterval())
+	go maintenance.PurgeTrash(ctx, store.maintenance, purgeInterval(), trashRetention())
+	go idempotency.PurgeExpired(ctx, store.idempotency, purgeInterval())
+
+	doc, err := openapi.Load()
+	if err != nil {
+		log.Fatalf("Falha ao carregar a especificação OpenAPI: %v", err)
+	}
+	validate, err := openapi.Validate(doc)
+	if err != nil {
+		log.Fatalf("Falha ao carregar a especificação OpenAPI: %v", err)
 	}
 
 	r := gin.Default()
 
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"*"}
-	config.AllowMethods = []string{"GET", "POST", "PATCH", "DELETE"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	config.AllowHeaders = append(config.AllowHeaders, request.UserHeader, "If-Match", "If-None-Match", idempotency.KeyHeader)
+	config.ExposeHeaders = []string{"Deprecation", "Link", "ETag", idempotency.ReplayedHeader}
 	r.Use(cors.New(config))
+	r.Use(validate)
 
-	r.POST("/users", user.CreateUserHandler)
-	r.GET("/users", user.FindAllUsersHandler)
-	r.GET("/users/find", user.FindByEmailHandler)
-	r.PUT("/users", user.UpdateUserHandler)
-	r.POST("/tasks", task.CreateTaskHandler)
-	r.PUT("/tasks", task.ChangeTaskHandler)
-	r.DELETE("/tasks", task.DeleteTaskHandler)
-	r.GET("/tasks", func(c *gin.Context) {
-		task.GetTasksForUserHandler(dbHandler.Ctx, dbHandler.Driver, dbHandler.Config.Database)(c.Writer, c.Request)
+	r.GET("/health", func(c *gin.Context) {
+		if err := store.ping(c.Request.Context()); err != nil {
+			apierror.Respond(c, apierror.New(apierror.DatabaseUnavailable))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
-	r.POST("/home", home.CreateHomeHandler)
-	r.PATCH("/home", home.AddResidentToHomeHandler)
-	r.GET("/home", home.GetHomeHandler)
-	r.DELETE("/home/:id", home.DeleteHomeHandler)
-	r.Run()
+
+	r.GET("/api/v1/openapi.yaml", openapi.YAMLHandler())
+	r.GET("/api/v1/openapi.json", openapi.JSONHandler(doc))
+	r.GET("/api/v1/docs", openapi.DocsHandler("/api/v1/openapi.json"))
+
+	idempotent := idempotency.Require(store.idempotency, idempotencyWindow())
+	registerV1Routes(r.Group("/api/v1", idempotent), store, syncChannel, hub, dispatcher)
+	registerLegacyRoutes(r.Group("", deprecated(), idempotent), store, syncChannel)
+
+	server := &http.Server{
+		Addr:    ":" + lookupPort(),
+		Handler: r,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Falha ao iniciar o servidor: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Encerrando o servidor...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Falha ao encerrar o servidor: %v", err)
+	}
+	if err := store.close(shutdownCtx); err != nil {
+		log.Printf("Falha ao fechar o armazenamento: %v", err)
+	}
+}
+
+// causalSession associa a requisição à sessão do usuário que a faz, para que
+// com NEO4J_CLUSTER=true ele leia as próprias escritas mesmo num seguidor. Vem
+// depois de home.RequireResident, para que só moradores de verdade abram sessões;
+// as demais requisições usam os bookmarks compartilhados do driver.
+func causalSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if email := request.User(c); email != "" {
+			c.Request = c.Request.WithContext(database.WithSession(c.Request.Context(), email))
+		}
+		c.Next()
+	}
+}
+
+// lookupPort mantém o comportamento do gin, que escuta na porta 8080 quando PORT não está definida
+func lookupPort() string {
+	if port := os.Getenv("PORT"); port != "" {
+		return port
+	}
+	return "8080"
+}
+
+// sweepInterval lê o intervalo da varredura de tarefas vencidas, uma hora por padrão
+func sweepInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("OVERDUE_SWEEP_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Hour
+	}
+	return interval
+}
+
+// orphanScanInterval lê o intervalo da varredura de registros órfãos, um dia por padrão
+func orphanScanInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("ORPHAN_SCAN_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 24 * time.Hour
+	}
+	return interval
+}
+
+// purgeInterval lê o intervalo do expurgo da lixeira e das chaves de
+// idempotência expiradas, uma hora por padrão
+func purgeInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("TRASH_PURGE_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Hour
+	}
+	return interval
+}
+
+// trashRetention lê por quanto tempo os itens excluídos podem ser restaurados, 30 dias por padrão
+func trashRetention() time.Duration {
+	retention, err := time.ParseDuration(os.Getenv("TRASH_RETENTION"))
+	if err != nil || retention <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return retention
+}
+
+// idempotencyWindow lê por quanto tempo a resposta de uma requisição com
+// Idempotency-Key é repetida para a mesma chave, 24 horas por padrão
+func idempotencyWindow() time.Duration {
+	window, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_WINDOW"))
+	if err != nil || window <= 0 {
+		return 24 * time.Hour
+	}
+	return window
+}
+
+// webhookRetryInterval lê de quanto em quanto tempo as entregas de webhooks que
+// falharam são conferidas para uma nova tentativa, 15 segundos por padrão
+func webhookRetryInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("WEBHOOK_RETRY_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 15 * time.Second
+	}
+	return interval
 }
@@ -0,0 +1,139 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/nsbnroque/go-to-do-list/achievement"
+	"github.com/nsbnroque/go-to-do-list/backup"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/idempotency"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository/graph"
+	"github.com/nsbnroque/go-to-do-list/internal/repository/memory"
+	"github.com/nsbnroque/go-to-do-list/internal/repository/sqlite"
+	"github.com/nsbnroque/go-to-do-list/maintenance"
+	"github.com/nsbnroque/go-to-do-list/reward"
+	"github.com/nsbnroque/go-to-do-list/score"
+	syncchannel "github.com/nsbnroque/go-to-do-list/sync_channel"
+	"github.com/nsbnroque/go-to-do-list/task"
+	"github.com/nsbnroque/go-to-do-list/user"
+	"github.com/nsbnroque/go-to-do-list/webhook"
+)
+
+// storage reúne os repositórios do backend escolhido em STORAGE_BACKEND
+type storage struct {
+	users        user.UserRepository
+	homes        home.HomeRepository
+	tasks        task.TaskRepository
+	rewards      reward.RewardRepository
+	scores       score.ScoreRepository
+	achievements achievement.AchievementRepository
+	completions  syncchannel.CompletionRepository
+	maintenance  maintenance.MaintenanceRepository
+	backups      backup.BackupRepository
+	idempotency  idempotency.IdempotencyRepository
+	webhooks     webhook.WebhookRepository
+
+	ping  func(ctx context.Context) error
+	close func(ctx context.Context) error
+}
+
+func openStorage(ctx context.Context) (*storage, error) {
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "", "neo4j":
+		dbHandler, err := database.NewDatabaseHandler(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Por padrão as migrações pendentes são aplicadas na inicialização,
+		// com NEO4J_MIGRATE_ON_START=false ficam a cargo do comando migrate
+		migrator := graph.NewMigrator(dbHandler)
+		if os.Getenv("NEO4J_MIGRATE_ON_START") == "false" {
+			err = migrator.Check(ctx)
+		} else {
+			err = migrator.Up(ctx)
+		}
+		if err != nil {
+			dbHandler.Close(ctx)
+			return nil, err
+		}
+
+		return &storage{
+			users:        graph.NewUserRepository(dbHandler),
+			homes:        graph.NewHomeRepository(dbHandler),
+			tasks:        graph.NewTaskRepository(dbHandler),
+			rewards:      graph.NewRewardRepository(dbHandler),
+			scores:       graph.NewScoreRepository(dbHandler),
+			achievements: graph.NewAchievementRepository(dbHandler),
+			completions:  graph.NewCompletionRepository(dbHandler),
+			maintenance:  graph.NewMaintenanceRepository(dbHandler),
+			backups:      graph.NewBackupRepository(dbHandler),
+			idempotency:  graph.NewIdempotencyRepository(dbHandler),
+			webhooks:     graph.NewWebhookRepository(dbHandler),
+			ping:         dbHandler.Ping,
+			close:        dbHandler.Close,
+		}, nil
+
+	case "sqlite":
+		sqliteHandler, err := database.NewSQLiteHandler(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := sqlite.CreateSchema(ctx, sqliteHandler); err != nil {
+			sqliteHandler.Close(ctx)
+			return nil, fmt.Errorf("Falha ao criar as tabelas do banco de dados: %v", err)
+		}
+
+		return &storage{
+			users:        sqlite.NewUserRepository(sqliteHandler),
+			homes:        sqlite.NewHomeRepository(sqliteHandler),
+			tasks:        sqlite.NewTaskRepository(sqliteHandler),
+			rewards:      sqlite.NewRewardRepository(sqliteHandler),
+			scores:       sqlite.NewScoreRepository(sqliteHandler),
+			achievements: sqlite.NewAchievementRepository(sqliteHandler),
+			completions:  sqlite.NewCompletionRepository(sqliteHandler),
+			maintenance:  sqlite.NewMaintenanceRepository(sqliteHandler),
+			backups:      sqlite.NewBackupRepository(sqliteHandler),
+			idempotency:  sqlite.NewIdempotencyRepository(sqliteHandler),
+			webhooks:     sqlite.NewWebhookRepository(sqliteHandler),
+			ping:         sqliteHandler.Ping,
+			close:        sqliteHandler.Close,
+		}, nil
+
+	case "memory":
+		store := memory.NewStore()
+
+		return &storage{
+			users:        memory.NewUserRepository(store),
+			homes:        memory.NewHomeRepository(store),
+			tasks:        memory.NewTaskRepository(store),
+			rewards:      memory.NewRewardRepository(store),
+			scores:       memory.NewScoreRepository(store),
+			achievements: memory.NewAchievementRepository(store),
+			completions:  memory.NewCompletionRepository(store),
+			maintenance:  memory.NewMaintenanceRepository(store),
+			backups:      memory.NewBackupRepository(store),
+			idempotency:  memory.NewIdempotencyRepository(store),
+			webhooks:     memory.NewWebhookRepository(store),
+			ping:         func(context.Context) error { return nil },
+			close:        func(context.Context) error { return nil },
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("Backend de armazenamento desconhecido: %s", backend)
+	}
+}
+
+// OpenBackups abre o backend de STORAGE_BACKEND para os comandos backup e
+// restore, que não precisam do servidor
+func OpenBackups(ctx context.Context) (backup.BackupRepository, func(ctx context.Context) error, error) {
+	store, err := openStorage(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.backups, store.close, nil
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/backup"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/reward"
+	"github.com/nsbnroque/go-to-do-list/score"
+	syncchannel "github.com/nsbnroque/go-to-do-list/sync_channel"
+	"github.com/nsbnroque/go-to-do-list/task"
+	"github.com/nsbnroque/go-to-do-list/user"
+	"github.com/nsbnroque/go-to-do-list/webhook"
+)
+
+// registerV1Routes monta a API em /api/v1. O usuário que faz a requisição vem do
+// cabeçalho X-User-Email e os recursos de cada casa ficam aninhados em /homes/:id,
+// acessíveis apenas aos moradores dela.
+func registerV1Routes(api *gin.RouterGroup, store *storage, syncChannel syncchannel.SyncChannel, hub *events.Hub, dispatcher *webhook.Dispatcher) {
+	api.POST("/users", user.CreateUserHandler(store.users))
+	api.GET("/users", user.FindAllUsersHandler(store.users))
+	api.GET("/users/:email", user.FindByEmailHandler(store.users, store.achievements))
+
+	me := api.Group("/me")
+	me.GET("", user.GetCurrentUserHandler(store.users, store.achievements))
+	me.PUT("", user.UpdateUserHandler(store.users))
+	me.PATCH("", user.PatchUserHandler(store.users))
+	me.GET("/tasks", task.GetTasksForUserHandler(store.tasks))
+	me.GET("/score-entries", score.GetScoreHistoryHandler(store.scores))
+
+	api.POST("/homes", home.CreateHomeHandler(store.homes))
+
+	// A lixeira também atende casas excluídas, então verifica os moradores por conta própria
+	api.GET("/homes/:id/trash", home.GetTrashHandler(store.homes))
+	api.POST("/homes/:id/restore", home.RestoreHandler(store.homes))
+
+	homes := api.Group("/homes/:id", home.RequireResident(store.homes), causalSession())
+	homes.GET("", home.GetHomeHandler(store.homes))
+	homes.PATCH("", home.PatchHomeHandler(store.homes))
+	homes.DELETE("", home.DeleteHomeHandler(store.homes))
+	homes.GET("/feed", home.GetHomeFeedHandler(store.homes))
+	homes.GET("/events", home.StreamEventsHandler(hub))
+	homes.GET("/settings", home.GetHomeSettingsHandler(store.homes))
+	homes.PUT("/settings", home.UpdateHomeSettingsHandler(store.homes))
+	homes.PATCH("/settings", home.PatchHomeSettingsHandler(store.homes))
+	homes.GET("/residents", home.GetResidentsHandler(store.homes))
+	homes.POST("/residents", home.AddResidentToHomeHandler(store.homes, syncChannel.Events))
+
+	homes.GET("/tasks", task.GetTasksForUserHandler(store.tasks))
+	homes.POST("/tasks", task.CreateTaskHandler(store.tasks, syncChannel.Events))
+	homes.PUT("/tasks/:task", task.ChangeTaskHandler(store.tasks, syncChannel.Events))
+	homes.PATCH("/tasks/:task", task.PatchTaskHandler(store.tasks, syncChannel.Events))
+	homes.DELETE("/tasks/:task", task.DeleteTaskHandler(store.tasks, syncChannel.Events))
+	homes.POST("/tasks/:task/completions", syncchannel.CompleteTaskHandler(store.completions, syncChannel))
+	homes.PUT("/tasks/:task/assignment", syncchannel.AssignTaskHandler(store.completions, syncChannel))
+	homes.POST("/tasks/:task/rejections", syncchannel.RejectTaskHandler(store.completions, syncChannel))
+
+	homes.GET("/rewards", reward.GetRewardsHandler(store.rewards))
+	homes.POST("/rewards", reward.CreateRewardHandler(store.rewards))
+	homes.POST("/rewards/:reward/redemptions", reward.RedeemRewardHandler(store.rewards, syncChannel.Events))
+	homes.GET("/redemptions", reward.GetRedemptionsHandler(store.rewards))
+	homes.PATCH("/redemptions/:redemption", reward.FulfillRedemptionHandler(store.rewards))
+
+	homes.POST("/score-entries/:entry/waiver", score.WaivePenaltyHandler(store.scores, syncChannel.Events))
+
+	homes.GET("/webhooks", webhook.GetWebhooksHandler(store.webhooks))
+	homes.POST("/webhooks", webhook.CreateWebhookHandler(store.webhooks))
+	homes.DELETE("/webhooks/:webhook", webhook.DeleteWebhookHandler(store.webhooks))
+	homes.GET("/webhooks/:webhook/deliveries", webhook.GetDeliveriesHandler(store.webhooks))
+	homes.POST("/webhooks/:webhook/pings", webhook.TestWebhookHandler(store.webhooks, dispatcher))
+
+	admin := api.Group("/admin", backup.RequireToken(os.Getenv("BACKUP_TOKEN")))
+	admin.GET("/backup", backup.ExportHandler(store.backups))
+	admin.POST("/restore", backup.RestoreHandler(store.backups))
+}
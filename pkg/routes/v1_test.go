@@ -0,0 +1,293 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+	"github.com/nsbnroque/go-to-do-list/openapi"
+	syncchannel "github.com/nsbnroque/go-to-do-list/sync_channel"
+	"github.com/nsbnroque/go-to-do-list/webhook"
+)
+
+// newTestAPI monta as rotas de /api/v1 sobre o armazenamento em memória, com a
+// conferência da especificação que, em modo de teste, também recusa respostas
+// fora dela. As rotinas de fundo não rodam; os eventos ficam no canal.
+func newTestAPI(t *testing.T) http.Handler {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("STORAGE_BACKEND", "memory")
+
+	store, err := openStorage(context.Background())
+	if err != nil {
+		t.Fatalf("openStorage: %v", err)
+	}
+
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("openapi.Load: %v", err)
+	}
+	validate, err := openapi.Validate(doc)
+	if err != nil {
+		t.Fatalf("openapi.Validate: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(validate)
+	registerV1Routes(r.Group("/api/v1"), store, syncchannel.NewSyncChannel(), events.NewHub(), webhook.NewDispatcher(store.webhooks))
+	return r
+}
+
+type apiClient struct {
+	t       *testing.T
+	handler http.Handler
+}
+
+// do envia a requisição como user e decodifica a resposta JSON em out, quando informado
+func (c apiClient) do(method string, path string, user string, body string, out interface{}) *httptest.ResponseRecorder {
+	c.t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user != "" {
+		req.Header.Set(request.UserHeader, user)
+	}
+
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, req)
+
+	if out != nil && rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			c.t.Fatalf("%s %s: resposta inválida %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec
+}
+
+// expect confere o status e, para erros, o código da resposta
+func (c apiClient) expect(method string, path string, user string, body string, status int, code string) {
+	c.t.Helper()
+
+	var apiErr struct {
+		Code string `json:"code"`
+	}
+	rec := c.do(method, path, user, body, nil)
+	if rec.Code != status {
+		c.t.Fatalf("%s %s: status %d, esperava %d: %s", method, path, rec.Code, status, rec.Body.String())
+	}
+	if code == "" {
+		return
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil || apiErr.Code != code {
+		c.t.Fatalf("%s %s: resposta %s, esperava o código %s", method, path, rec.Body.String(), code)
+	}
+}
+
+func (c apiClient) createUser(email string) {
+	c.t.Helper()
+	c.expect(http.MethodPost, "/api/v1/users", "", `{"name":"`+email+`","email":"`+email+`","password":"12345678"}`, http.StatusCreated, "")
+}
+
+func (c apiClient) createHome(user string, name string) string {
+	c.t.Helper()
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	rec := c.do(http.MethodPost, "/api/v1/homes", user, `{"name":"`+name+`"}`, &created)
+	if rec.Code != http.StatusCreated || created.ID == "" {
+		c.t.Fatalf("criar casa: status %d: %s", rec.Code, rec.Body.String())
+	}
+	return created.ID
+}
+
+// taskNames lista os nomes das tarefas da casa vistas por user
+func (c apiClient) taskNames(user string, home string) []string {
+	c.t.Helper()
+
+	var tasks []struct {
+		Name string `json:"name"`
+	}
+	rec := c.do(http.MethodGet, "/api/v1/homes/"+home+"/tasks", user, "", &tasks)
+	if rec.Code != http.StatusOK {
+		c.t.Fatalf("listar tarefas: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	return names
+}
+
+func TestV1HomeTaskFlow(t *testing.T) {
+	api := apiClient{t, newTestAPI(t)}
+	api.createUser("a@x")
+	home := api.createHome("a@x", "Casa")
+	tasks := "/api/v1/homes/" + home + "/tasks"
+
+	api.expect(http.MethodPost, tasks, "a@x", `{"name":"lixo","reward":5}`, http.StatusCreated, "")
+	if names := api.taskNames("a@x", home); len(names) != 1 || names[0] != "lixo" {
+		t.Fatalf("tarefas %v, esperava [lixo]", names)
+	}
+
+	api.expect(http.MethodPatch, tasks+"/lixo", "a@x", `{"reward":7}`, http.StatusOK, "")
+
+	var completed struct {
+		Status          string `json:"status"`
+		EffectiveReward int64  `json:"effectiveReward"`
+	}
+	rec := api.do(http.MethodPost, tasks+"/lixo/completions", "a@x", "", &completed)
+	if rec.Code != http.StatusOK || completed.Status != "finished" || completed.EffectiveReward != 7 {
+		t.Fatalf("concluir: status %d: %s", rec.Code, rec.Body.String())
+	}
+	api.expect(http.MethodPost, tasks+"/lixo/completions", "a@x", "", http.StatusNotFound, "task_not_pending")
+
+	var me struct {
+		Score int64 `json:"score"`
+	}
+	if rec := api.do(http.MethodGet, "/api/v1/me", "a@x", "", &me); rec.Code != http.StatusOK || me.Score != 7 {
+		t.Fatalf("pontuação após concluir: status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	api.expect(http.MethodDelete, tasks+"/lixo", "a@x", "", http.StatusOK, "")
+	if names := api.taskNames("a@x", home); len(names) != 0 {
+		t.Fatalf("tarefas %v após excluir, esperava nenhuma", names)
+	}
+}
+
+func TestV1PatchTaskStatus(t *testing.T) {
+	api := apiClient{t, newTestAPI(t)}
+	api.createUser("a@x")
+	tasks := "/api/v1/homes/" + api.createHome("a@x", "Casa") + "/tasks"
+	api.expect(http.MethodPost, tasks, "a@x", `{"name":"lixo","reward":5}`, http.StatusCreated, "")
+
+	tests := []struct {
+		name   string
+		patch  string
+		status int
+		want   string
+	}{
+		{name: "concluída", patch: `{"status":"finished"}`, status: http.StatusOK, want: "finished"},
+		{name: "null volta a pendente", patch: `{"status":null}`, status: http.StatusOK, want: "pending"},
+		{name: "status desconhecido", patch: `{"status":"done"}`, status: http.StatusBadRequest},
+		{name: "status vazio", patch: `{"status":""}`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patched struct {
+				Status string `json:"status"`
+			}
+			rec := api.do(http.MethodPatch, tasks+"/lixo", "a@x", tt.patch, &patched)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, esperava %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.want != "" && patched.Status != tt.want {
+				t.Errorf("tarefa com status %q, esperava %q", patched.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestV1HomesAreIsolated(t *testing.T) {
+	api := apiClient{t, newTestAPI(t)}
+	api.createUser("a@x")
+	homeA := api.createHome("a@x", "A")
+	homeB := api.createHome("a@x", "B")
+	a := "/api/v1/homes/" + homeA
+	b := "/api/v1/homes/" + homeB
+
+	api.expect(http.MethodPost, a+"/tasks", "a@x", `{"name":"lixo","reward":5}`, http.StatusCreated, "")
+	if names := api.taskNames("a@x", homeB); len(names) != 0 {
+		t.Errorf("tarefas de A aparecem em B: %v", names)
+	}
+
+	// Tarefas de A não são alteradas, concluídas nem excluídas pelas rotas de B
+	api.expect(http.MethodPatch, b+"/tasks/lixo", "a@x", `{"reward":9}`, http.StatusNotFound, "task_not_found")
+	api.expect(http.MethodPost, b+"/tasks/lixo/completions", "a@x", "", http.StatusNotFound, "task_not_pending")
+	api.expect(http.MethodDelete, b+"/tasks/lixo", "a@x", "", http.StatusNotFound, "task_not_found")
+	if names := api.taskNames("a@x", homeA); len(names) != 1 {
+		t.Fatalf("tarefas de A %v, esperava [lixo]", names)
+	}
+	api.expect(http.MethodPost, a+"/tasks/lixo/completions", "a@x", "", http.StatusOK, "")
+
+	// Configurações alteradas em A não mudam as de B
+	api.expect(http.MethodPatch, a+"/settings", "a@x", `{"graceDays":5}`, http.StatusOK, "")
+	var settings struct {
+		GraceDays int64 `json:"graceDays"`
+	}
+	api.do(http.MethodGet, b+"/settings", "a@x", "", &settings)
+	if settings.GraceDays == 5 {
+		t.Errorf("configurações de A aplicadas em B")
+	}
+
+	// Recompensas de A não aparecem nem são resgatadas em B
+	var reward struct {
+		ID string `json:"id"`
+	}
+	rec := api.do(http.MethodPost, a+"/rewards", "a@x", `{"name":"sorvete","cost":1,"stock":3}`, &reward)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("criar recompensa: status %d: %s", rec.Code, rec.Body.String())
+	}
+	var rewards []interface{}
+	api.do(http.MethodGet, b+"/rewards", "a@x", "", &rewards)
+	if len(rewards) != 0 {
+		t.Errorf("recompensas de A aparecem em B: %v", rewards)
+	}
+	if rec := api.do(http.MethodPost, b+"/rewards/"+reward.ID+"/redemptions", "a@x", "", nil); rec.Code < 400 {
+		t.Errorf("recompensa de A resgatada por B: status %d", rec.Code)
+	}
+}
+
+func TestV1RequireResident(t *testing.T) {
+	api := apiClient{t, newTestAPI(t)}
+	api.createUser("a@x")
+	api.createUser("b@x")
+	home := api.createHome("a@x", "Casa")
+
+	api.expect(http.MethodGet, "/api/v1/homes/"+home+"/tasks", "b@x", "", http.StatusNotFound, "not_resident")
+	api.expect(http.MethodGet, "/api/v1/homes/00000000-0000-4000-8000-000000000000/tasks", "a@x", "", http.StatusNotFound, "home_not_found")
+
+	api.expect(http.MethodPost, "/api/v1/homes/"+home+"/residents", "a@x", `{"email":"b@x"}`, http.StatusCreated, "")
+	api.expect(http.MethodGet, "/api/v1/homes/"+home+"/tasks", "b@x", "", http.StatusOK, "")
+}
+
+func TestV1DeleteHomeRequiresAdmin(t *testing.T) {
+	api := apiClient{t, newTestAPI(t)}
+	api.createUser("a@x")
+	api.createUser("b@x")
+	home := "/api/v1/homes/" + api.createHome("a@x", "Casa")
+	api.expect(http.MethodPost, home+"/residents", "a@x", `{"email":"b@x"}`, http.StatusCreated, "")
+
+	api.expect(http.MethodDelete, home, "b@x", "", http.StatusForbidden, "admin_required")
+	api.expect(http.MethodGet, home, "b@x", "", http.StatusOK, "")
+
+	api.expect(http.MethodDelete, home, "a@x", "", http.StatusOK, "")
+	api.expect(http.MethodGet, home, "a@x", "", http.StatusNotFound, "home_not_found")
+}
+
+func TestV1RestoreHomeRequiresAdmin(t *testing.T) {
+	api := apiClient{t, newTestAPI(t)}
+	api.createUser("a@x")
+	api.createUser("b@x")
+	api.createUser("c@x")
+	home := "/api/v1/homes/" + api.createHome("a@x", "Casa")
+	api.expect(http.MethodPost, home+"/residents", "a@x", `{"email":"b@x"}`, http.StatusCreated, "")
+	api.expect(http.MethodDelete, home, "a@x", "", http.StatusOK, "")
+
+	api.expect(http.MethodPost, home+"/restore", "c@x", `{"kind":"home"}`, http.StatusNotFound, "trash_item_not_found")
+	api.expect(http.MethodPost, home+"/restore", "b@x", `{"kind":"home"}`, http.StatusForbidden, "admin_required")
+	api.expect(http.MethodGet, home, "a@x", "", http.StatusNotFound, "home_not_found")
+
+	api.expect(http.MethodPost, home+"/restore", "a@x", `{"kind":"home"}`, http.StatusOK, "")
+	api.expect(http.MethodGet, home, "b@x", "", http.StatusOK, "")
+}
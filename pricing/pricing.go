@@ -0,0 +1,80 @@
+package pricing
+
+import (
+	"math"
+	"time"
+)
+
+type Mode string
+
+const (
+	Fixed   Mode = "fixed"
+	Dynamic Mode = "dynamic"
+)
+
+type Curve string
+
+const (
+	Linear      Curve = "linear"
+	Exponential Curve = "exponential"
+)
+
+// Pricing define como a recompensa de uma tarefa pendente cresce com o tempo.
+// Rate é o percentual de aumento por dia e Cap o valor máximo, em percentual da recompensa base.
+type Pricing struct {
+	Mode  Mode  `json:"mode" validate:"nonzero,oneof=fixed|dynamic"`
+	Curve Curve `json:"curve" validate:"nonzero,oneof=linear|exponential"`
+	Rate  int64 `json:"rate" validate:"nonnegative"`
+	Cap   int64 `json:"cap" validate:"min=100"`
+}
+
+func Default() Pricing {
+	return Pricing{
+		Mode:  Fixed,
+		Curve: Linear,
+		Rate:  10,
+		Cap:   300,
+	}
+}
+
+// FromProps lê a precificação das propriedades do nó Home, usando o padrão para as ausentes
+func FromProps(props map[string]interface{}) Pricing {
+	pricing := Default()
+	if value, ok := props["pricing_mode"].(string); ok {
+		pricing.Mode = Mode(value)
+	}
+	if value, ok := props["pricing_curve"].(string); ok {
+		pricing.Curve = Curve(value)
+	}
+	if value, ok := props["pricing_rate"].(int64); ok {
+		pricing.Rate = value
+	}
+	if value, ok := props["pricing_cap"].(int64); ok {
+		pricing.Cap = value
+	}
+	return pricing
+}
+
+// Effective calcula a recompensa de uma tarefa pendente desde pendingSince
+func (p Pricing) Effective(base int64, pendingSince time.Time, now time.Time) int64 {
+	if p.Mode != Dynamic || base <= 0 || pendingSince.IsZero() || !now.After(pendingSince) {
+		return base
+	}
+
+	days := now.Sub(pendingSince).Hours() / 24
+	rate := float64(p.Rate) / 100
+
+	var multiplier float64
+	switch p.Curve {
+	case Exponential:
+		multiplier = math.Pow(1+rate, days)
+	default:
+		multiplier = 1 + rate*days
+	}
+
+	if limit := float64(p.Cap) / 100; multiplier > limit {
+		multiplier = limit
+	}
+
+	return int64(math.Floor(float64(base) * multiplier))
+}
@@ -0,0 +1,132 @@
+package reward
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
+func CreateRewardHandler(rewards RewardRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+
+		var rewardData Reward
+		if !request.Bind(c, &rewardData) {
+			return
+		}
+
+		// Gera um novo UUID para a recompensa
+		rewardData.ID = uuid.New()
+
+		// Apenas administradores da residência podem cadastrar recompensas
+		err := rewards.Create(c.Request.Context(), userEmail, request.Param(c, "id"), rewardData)
+
+		if errors.Is(err, repository.ErrForbidden) {
+			apierror.Respond(c, apierror.New(apierror.AdminRequired))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao criar recompensa: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusCreated, rewardData)
+	}
+}
+
+func GetRewardsHandler(rewards RewardRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		homeRewards, err := rewards.FindByUser(c.Request.Context(), request.User(c), request.Param(c, "id"))
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao obter recompensas: %w", err))
+			return
+		}
+
+		if homeRewards == nil {
+			homeRewards = []Reward{}
+		}
+
+		c.JSON(http.StatusOK, homeRewards)
+	}
+}
+
+func RedeemRewardHandler(rewards RewardRepository, published chan<- events.Event) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+		rewardID := c.Param("reward")
+
+		redemption := Redemption{
+			ID:         uuid.New(),
+			User:       userEmail,
+			RedeemedAt: time.Now(),
+		}
+
+		// O saldo e o estoque são debitados juntos para evitar resgates além do permitido
+		redemption, err := rewards.Redeem(c.Request.Context(), userEmail, request.Param(c, "id"), rewardID, redemption)
+
+		if errors.Is(err, repository.ErrConflict) {
+			apierror.Respond(c, apierror.New(apierror.RewardUnavailable))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao resgatar recompensa: %w", err))
+			return
+		}
+
+		leaderboard := events.From(c, events.LeaderboardChanged, nil)
+		leaderboard.Resident = userEmail
+		events.Publish(published, leaderboard)
+
+		c.JSON(http.StatusCreated, redemption)
+	}
+}
+
+func GetRedemptionsHandler(rewards RewardRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Histórico de resgates de todos os moradores da residência
+		redemptions, err := rewards.Redemptions(c.Request.Context(), request.User(c), request.Param(c, "id"))
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao obter histórico de resgates: %w", err))
+			return
+		}
+
+		if redemptions == nil {
+			redemptions = []Redemption{}
+		}
+
+		c.JSON(http.StatusOK, redemptions)
+	}
+}
+
+func FulfillRedemptionHandler(rewards RewardRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+		id := c.Param("redemption")
+
+		err := rewards.Fulfill(c.Request.Context(), userEmail, request.Param(c, "id"), id, time.Now())
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.RedemptionNotFound))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao atualizar resgate: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Resgate %s marcado como entregue!", id),
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package reward
+
+import (
+	"context"
+	"time"
+)
+
+// RewardRepository atua na casa home do usuário ou, com home vazio, em todas as
+// suas casas, como nas rotas antigas
+type RewardRepository interface {
+	// Create cadastra a recompensa na casa administrada por adminEmail
+	Create(ctx context.Context, adminEmail string, home string, reward Reward) error
+	FindByUser(ctx context.Context, email string, home string) ([]Reward, error)
+	// Redeem debita o custo do saldo do usuário e uma unidade do estoque, ou retorna repository.ErrConflict
+	Redeem(ctx context.Context, email string, home string, rewardID string, redemption Redemption) (Redemption, error)
+	Redemptions(ctx context.Context, email string, home string) ([]Redemption, error)
+	Fulfill(ctx context.Context, adminEmail string, home string, id string, at time.Time) error
+}
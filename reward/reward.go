@@ -0,0 +1,28 @@
+package reward
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Reward struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name" validate:"nonzero,maxlen=100"`
+	Cost  int64     `json:"cost" validate:"positive"`
+	Stock int64     `json:"stock" validate:"nonnegative"`
+}
+
+func (r *Reward) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+}
+
+type Redemption struct {
+	ID         uuid.UUID `json:"id"`
+	Reward     string    `json:"reward"`
+	User       string    `json:"user"`
+	Cost       int64     `json:"cost"`
+	RedeemedAt time.Time `json:"redeemedAt"`
+	Fulfilled  bool      `json:"fulfilled"`
+}
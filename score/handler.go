@@ -0,0 +1,67 @@
+package score
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
+func GetScoreHistoryHandler(scores ScoreRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := database.ParseLimit(c.Request)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest))
+			return
+		}
+
+		entries, err := scores.History(c.Request.Context(), request.User(c), limit)
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao obter histórico de pontuação: %w", err))
+			return
+		}
+
+		if entries == nil {
+			entries = []Entry{}
+		}
+
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+func WaivePenaltyHandler(scores ScoreRepository, published chan<- events.Event) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+		id := c.Param("entry")
+
+		var waiver Waiver
+		if !request.Bind(c, &waiver) {
+			return
+		}
+
+		// Apenas administradores da casa onde a penalidade foi aplicada podem perdoá-la
+		entry, err := scores.Waive(c.Request.Context(), userEmail, request.Param(c, "id"), id, waiver, time.Now())
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.PenaltyNotFound))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao perdoar penalidade: %w", err))
+			return
+		}
+
+		// A penalidade não guarda o morador, então o evento só avisa que o placar mudou
+		events.Publish(published, events.From(c, events.LeaderboardChanged, nil))
+
+		c.JSON(http.StatusOK, entry)
+	}
+}
@@ -0,0 +1,13 @@
+package score
+
+import (
+	"context"
+	"time"
+)
+
+type ScoreRepository interface {
+	History(ctx context.Context, email string, limit int) ([]Entry, error)
+	// Waive perdoa uma penalidade de um morador da casa home administrada por
+	// adminEmail, ou de qualquer casa dele com home vazio, e devolve os pontos
+	Waive(ctx context.Context, adminEmail string, home string, id string, waiver Waiver, at time.Time) (Entry, error)
+}
@@ -0,0 +1,41 @@
+package score
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	Penalty Kind = "penalty"
+)
+
+type Reason string
+
+const (
+	Overdue  Reason = "overdue"
+	Rejected Reason = "rejected"
+)
+
+type Entry struct {
+	ID          uuid.UUID `json:"id"`
+	Kind        Kind      `json:"kind"`
+	Reason      Reason    `json:"reason"`
+	Task        string    `json:"task"`
+	Amount      int64     `json:"amount"`
+	At          time.Time `json:"at"`
+	Waived      bool      `json:"waived"`
+	WaivedBy    string    `json:"waivedBy,omitempty"`
+	WaiveReason string    `json:"waiveReason,omitempty"`
+}
+
+type Waiver struct {
+	Reason string `json:"reason" validate:"nonzero,maxlen=500"`
+}
+
+func (w *Waiver) Normalize() {
+	w.Reason = strings.TrimSpace(w.Reason)
+}
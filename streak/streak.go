@@ -0,0 +1,66 @@
+package streak
+
+import "time"
+
+type Streak struct {
+	Current int64
+	LastAt  time.Time
+}
+
+// period retorna o índice do período (dia ou semana iniciada na segunda) que contém t
+func period(t time.Time, days int) int64 {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+	if days == 7 {
+		// 01/01/1970 foi uma quinta-feira, desloca para as semanas começarem na segunda
+		day += 3
+	}
+	return day / int64(days)
+}
+
+// deadline é o fim do período seguinte ao da última conclusão, somado aos dias de tolerância
+func deadline(s Streak, days int, graceDays int64) time.Time {
+	next := period(s.LastAt, days) + 2
+	if days == 7 {
+		return time.Unix((next*7-3)*86400, 0).UTC().AddDate(0, 0, int(graceDays))
+	}
+	return time.Unix(next*86400, 0).UTC().AddDate(0, 0, int(graceDays))
+}
+
+// SamePeriod indica se a e b estão no mesmo dia (days = 1) ou na mesma semana (days = 7)
+func SamePeriod(a, b time.Time, days int) bool {
+	return period(a, days) == period(b, days)
+}
+
+// Next registra uma conclusão em now e devolve a sequência atualizada
+func Next(s Streak, days int, graceDays int64, now time.Time) Streak {
+	switch {
+	case s.LastAt.IsZero() || s.Current == 0:
+		s.Current = 1
+	case SamePeriod(now, s.LastAt, days):
+		// Já contabilizado neste período
+	case now.Before(deadline(s, days, graceDays)):
+		s.Current++
+	default:
+		s.Current = 1
+	}
+	s.LastAt = now
+	return s
+}
+
+// Broken indica se a sequência expirou sem uma nova conclusão
+func Broken(s Streak, days int, graceDays int64, now time.Time) bool {
+	return s.Current > 0 && !s.LastAt.IsZero() && !now.Before(deadline(s, days, graceDays))
+}
+
+// Bonus calcula os pontos extras de uma sequência: perStep para cada período além do primeiro, limitado a max
+func Bonus(current int64, perStep int64, max int64) int64 {
+	if current <= 1 || perStep <= 0 {
+		return 0
+	}
+	bonus := (current - 1) * perStep
+	if max > 0 && bonus > max {
+		return max
+	}
+	return bonus
+}
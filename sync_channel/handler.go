@@ -0,0 +1,160 @@
+package syncchannel
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+	"github.com/nsbnroque/go-to-do-list/score"
+	"github.com/nsbnroque/go-to-do-list/streak"
+	t "github.com/nsbnroque/go-to-do-list/task"
+	u "github.com/nsbnroque/go-to-do-list/user"
+)
+
+func CompleteTaskHandler(completions CompletionRepository, syncChannel SyncChannel) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		userEmail := request.User(c)
+		homeID := request.Param(c, "id")
+		taskName := request.Param(c, "task")
+
+		var task t.Task
+
+		// Calcula sequências e recompensa a partir do estado lido na própria
+		// transação que marca a tarefa como concluída e credita a recompensa
+		user, err := completions.Complete(ctx, userEmail, homeID, taskName, func(state CompletionState) Completion {
+			settings := state.Settings
+			now := time.Now()
+
+			task = t.Task{
+				Name:       taskName,
+				Status:     t.Finished,
+				Reward:     state.Reward,
+				Recurrence: state.Recurrence,
+			}
+
+			completion := Completion{
+				At:     now,
+				Daily:  streak.Next(state.Daily, 1, settings.GraceDays, now).Current,
+				Weekly: streak.Next(state.Weekly, 7, settings.GraceDays, now).Current,
+			}
+
+			// Tarefas recorrentes usam a sequência da própria tarefa, as avulsas a sequência diária do morador
+			bonusStreak := completion.Daily
+			if days := task.Recurrence.Days(); days > 0 {
+				chore := streak.Next(state.Chore, days, settings.GraceDays, now)
+				task.Streak = chore.Current
+				bonusStreak = chore.Current
+				completion.ChoreStreak = &chore.Current
+			}
+
+			// Na precificação dinâmica a recompensa acumulada enquanto pendente é creditada e volta à base
+			completion.Reward = settings.Pricing.Effective(state.Reward, state.PendingSince, now)
+			completion.Bonus = streak.Bonus(bonusStreak, settings.StreakBonus, settings.StreakBonusCap)
+			task.EffectiveReward = completion.Reward + completion.Bonus
+
+			return completion
+		})
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.TaskNotPending))
+			return
+		}
+
+		if errors.Is(err, repository.ErrConflict) {
+			apierror.Respond(c, apierror.New(apierror.TaskAlreadyCompleted))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao concluir tarefa: %w", err))
+			return
+		}
+
+		completeTask(task, user, syncChannel)
+
+		response := t.NewTaskResponse(task)
+		events.Publish(syncChannel.Events, events.From(c, events.TaskCompleted, response))
+		leaderboard := events.From(c, events.LeaderboardChanged, u.NewUserResponse(user))
+		leaderboard.Resident = user.Email
+		events.Publish(syncChannel.Events, leaderboard)
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func AssignTaskHandler(completions CompletionRepository, syncChannel SyncChannel) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+		taskName := request.Param(c, "task")
+
+		var assignment t.Assignment
+		if !request.Bind(c, &assignment) {
+			return
+		}
+
+		// Apenas administradores atribuem tarefas, e somente a moradores da mesma casa
+		task, err := completions.Assign(c.Request.Context(), userEmail, request.Param(c, "id"), taskName, assignment)
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.AssignmentNotAllowed))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao atribuir tarefa: %w", err))
+			return
+		}
+
+		response := t.NewTaskResponse(task)
+		events.Publish(syncChannel.Events, events.From(c, events.TaskUpdated, response))
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func RejectTaskHandler(completions CompletionRepository, syncChannel SyncChannel) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+		taskName := request.Param(c, "task")
+
+		penalty := score.Entry{
+			ID:     uuid.New(),
+			Kind:   score.Penalty,
+			Reason: score.Rejected,
+			Task:   taskName,
+			At:     time.Now(),
+		}
+
+		// Reabre a tarefa e penaliza o último morador que a concluiu, conforme a regra da casa
+		resident, amount, err := completions.Reject(c.Request.Context(), userEmail, request.Param(c, "id"), taskName, penalty)
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.RejectionNotAllowed))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao rejeitar tarefa: %w", err))
+			return
+		}
+
+		// A tarefa volta a ficar pendente; o placar do morador é completado ao entregar o evento
+		events.Publish(syncChannel.Events, events.From(c, events.TaskUpdated, gin.H{"name": taskName, "status": t.Pending}))
+		leaderboard := events.From(c, events.LeaderboardChanged, nil)
+		leaderboard.Resident = resident
+		events.Publish(syncChannel.Events, leaderboard)
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Tarefa %s reaberta, %v pontos descontados de %v", taskName, amount, resident),
+		})
+	}
+}
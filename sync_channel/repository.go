@@ -0,0 +1,80 @@
+package syncchannel
+
+import (
+	"context"
+	"time"
+
+	h "github.com/nsbnroque/go-to-do-list/home"
+	"github.com/nsbnroque/go-to-do-list/score"
+	"github.com/nsbnroque/go-to-do-list/streak"
+	t "github.com/nsbnroque/go-to-do-list/task"
+	u "github.com/nsbnroque/go-to-do-list/user"
+)
+
+// CompletionState reúne o que é preciso para calcular sequências e recompensa de uma conclusão
+type CompletionState struct {
+	Settings     h.Settings
+	Reward       int64
+	Recurrence   t.Recurrence
+	PendingSince time.Time
+	Chore        streak.Streak
+	Daily        streak.Streak
+	Weekly       streak.Streak
+}
+
+// Completion é o resultado de uma conclusão a ser gravado.
+// ChoreStreak é nil para tarefas avulsas.
+type Completion struct {
+	At          time.Time
+	Reward      int64
+	Bonus       int64
+	ChoreStreak *int64
+	Daily       int64
+	Weekly      int64
+}
+
+// Chore é uma tarefa recorrente já concluída ao menos uma vez, avaliada pela varredura
+type Chore struct {
+	Home      string
+	Task      string
+	Days      int
+	Status    t.Status
+	Streak    streak.Streak
+	GraceDays int64
+}
+
+type ChoreUpdate struct {
+	Home   string
+	Task   string
+	Status t.Status
+	Streak int64
+	Reopen bool
+}
+
+// ResidentStreak traz as sequências de um morador e a maior tolerância entre suas casas
+type ResidentStreak struct {
+	Email     string
+	Daily     streak.Streak
+	Weekly    streak.Streak
+	GraceDays int64
+}
+
+// CompletionRepository atua na casa home do usuário ou, com home vazio, na
+// primeira de suas casas em que a tarefa exista, como nas rotas antigas
+type CompletionRepository interface {
+	// Complete lê o estado da tarefa e grava a conclusão calculada por complete na
+	// mesma transação, retornando repository.ErrNotFound se a tarefa não existir na
+	// casa do usuário ou já estiver concluída, e repository.ErrConflict se outra
+	// requisição a concluir antes da gravação
+	Complete(ctx context.Context, email string, home string, task string, complete func(CompletionState) Completion) (u.User, error)
+	Assign(ctx context.Context, adminEmail string, home string, task string, assignment t.Assignment) (t.Task, error)
+	// Reject reabre a tarefa e penaliza o último morador que a concluiu, retornando seu email e os pontos descontados
+	Reject(ctx context.Context, adminEmail string, home string, task string, penalty score.Entry) (string, int64, error)
+
+	RecurringChores(ctx context.Context) ([]Chore, error)
+	UpdateChores(ctx context.Context, updates []ChoreUpdate, now time.Time) error
+	ResidentStreaks(ctx context.Context) ([]ResidentStreak, error)
+	UpdateResidentStreaks(ctx context.Context, streaks []ResidentStreak) error
+	// PenalizeOverdue desconta, uma única vez, os pontos dos responsáveis por tarefas atribuídas vencidas
+	PenalizeOverdue(ctx context.Context, now time.Time) error
+}
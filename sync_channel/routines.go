@@ -1,31 +1,168 @@
 package syncchannel
 
 import (
-	h "github.com/nsbnroque/go-to-do-list/home"
+	"context"
+	"log"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/achievement"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/streak"
 	t "github.com/nsbnroque/go-to-do-list/task"
 	u "github.com/nsbnroque/go-to-do-list/user"
+	"github.com/nsbnroque/go-to-do-list/webhook"
 )
 
-func SyncTasks(home *h.Home, syncChannel SyncChannel) {
+func SyncTasks(ctx context.Context, achievements achievement.AchievementRepository, syncChannel SyncChannel) {
 	for {
 		select {
+		case <-ctx.Done():
+			return
 		case completedTask := <-syncChannel.CompleteTask:
-			// Lógica para atualizar pontuação do morador
-			updateRanking(completedTask.User, completedTask.Task)
-			//u.UpdateScore(completedTask.User.Email, completedTask.Task.Name)
+			// A pontuação já é creditada ao concluir a tarefa, aqui avaliamos as conquistas
+			checkAchievements(ctx, achievements, completedTask.User)
+		}
+	}
+}
+
+// PublishEvents entrega ao hub e aos webhooks os eventos publicados pelos
+// handlers, até o desligamento, quando encerra as conexões abertas. Os eventos de
+// placar sem os dados do morador são completados com a pontuação atual dele.
+func PublishEvents(ctx context.Context, users u.UserRepository, syncChannel SyncChannel, hub *events.Hub, dispatcher *webhook.Dispatcher) {
+	defer hub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-syncChannel.Events:
+			if event.Type == events.LeaderboardChanged && event.Data == nil && event.Resident != "" {
+				user, err := users.FindByEmail(ctx, event.Resident)
+				if err != nil {
+					log.Println(err.Error())
+				} else {
+					event.Data = u.NewUserResponse(user)
+				}
+			}
+			hub.Deliver(event)
+			if err := dispatcher.Enqueue(ctx, event); err != nil {
+				log.Printf("Erro ao agendar as entregas de webhooks do evento %s: %v", event.Type, err)
+			}
 		}
 	}
 }
 
-func updateRanking(user u.User, task t.Task) {
-	//TO-DO
+func checkAchievements(ctx context.Context, achievements achievement.AchievementRepository, user u.User) {
+	unlocked, err := achievement.Check(ctx, achievements, user.Email)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	for _, a := range unlocked {
+		log.Printf("Conquista %s desbloqueada por %s", a.ID, user.Email)
+	}
 }
 
+// completeTask agenda a avaliação das conquistas sem bloquear o handler. Com a
+// fila cheia, ou com SyncTasks já encerrado, a conclusão é descartada e as
+// conquistas ficam para a próxima tarefa concluída.
 func completeTask(task t.Task, user u.User, syncChannel SyncChannel) {
-	// Lógica para marcar tarefa como concluída no banco de dados
 	completedTask := CompletedTask{
 		Task: task,
 		User: user,
 	}
-	syncChannel.CompleteTask <- completedTask
+	select {
+	case syncChannel.CompleteTask <- completedTask:
+	default:
+		log.Printf("Fila de conclusões cheia, conquistas de %s não avaliadas para a tarefa %s", user.Email, task.Name)
+	}
+}
+
+// SweepOverdue reabre as tarefas recorrentes de períodos anteriores e encerra as sequências vencidas
+func SweepOverdue(ctx context.Context, completions CompletionRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := sweepChores(ctx, completions, now); err != nil {
+				log.Println(err.Error())
+			}
+			if err := sweepResidents(ctx, completions, now); err != nil {
+				log.Println(err.Error())
+			}
+			// Penaliza uma única vez os responsáveis por tarefas atribuídas que passaram do prazo
+			if err := completions.PenalizeOverdue(ctx, now); err != nil {
+				log.Println(err.Error())
+			}
+		}
+	}
+}
+
+func sweepChores(ctx context.Context, completions CompletionRepository, now time.Time) error {
+	chores, err := completions.RecurringChores(ctx)
+	if err != nil {
+		return err
+	}
+
+	var updates []ChoreUpdate
+	for _, chore := range chores {
+		update := ChoreUpdate{
+			Home:   chore.Home,
+			Task:   chore.Task,
+			Status: chore.Status,
+			Streak: chore.Streak.Current,
+		}
+
+		if chore.Status == t.Finished && !streak.SamePeriod(chore.Streak.LastAt, now, chore.Days) {
+			// Um novo período começou, a tarefa volta a ficar pendente
+			update.Status = t.Pending
+			update.Reopen = true
+		}
+		if streak.Broken(chore.Streak, chore.Days, chore.GraceDays, now) {
+			update.Streak = 0
+		}
+
+		if update.Reopen || update.Streak != chore.Streak.Current {
+			updates = append(updates, update)
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return completions.UpdateChores(ctx, updates, now)
+}
+
+func sweepResidents(ctx context.Context, completions CompletionRepository, now time.Time) error {
+	residents, err := completions.ResidentStreaks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var updates []ResidentStreak
+	for _, resident := range residents {
+		update := resident
+		if streak.Broken(resident.Daily, 1, resident.GraceDays, now) {
+			update.Daily.Current = 0
+		}
+		if streak.Broken(resident.Weekly, 7, resident.GraceDays, now) {
+			update.Weekly.Current = 0
+		}
+
+		if update.Daily.Current != resident.Daily.Current || update.Weekly.Current != resident.Weekly.Current {
+			updates = append(updates, update)
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return completions.UpdateResidentStreaks(ctx, updates)
 }
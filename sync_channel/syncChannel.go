@@ -1,15 +1,25 @@
 package syncchannel
 
 import (
+	"github.com/nsbnroque/go-to-do-list/events"
 	t "github.com/nsbnroque/go-to-do-list/task"
 	u "github.com/nsbnroque/go-to-do-list/user"
 )
 
 type SyncChannel struct {
 	CompleteTask chan CompletedTask
+	// Events leva aos moradores conectados o que os handlers alteraram nas casas
+	Events chan events.Event
 }
 
 type CompletedTask struct {
 	Task t.Task
 	User u.User
 }
+
+func NewSyncChannel() SyncChannel {
+	return SyncChannel{
+		CompleteTask: make(chan CompletedTask, 100),
+		Events:       make(chan events.Event, 100),
+	}
+}
@@ -0,0 +1,71 @@
+package task
+
+import (
+	"strings"
+	"time"
+)
+
+// TaskRequest é o corpo aceito na criação e na alteração da tarefa
+type TaskRequest struct {
+	Name       string     `json:"name" validate:"nonzero,maxlen=100"`
+	Status     Status     `json:"status" validate:"oneof=pending|finished"`
+	Reward     int64      `json:"reward" validate:"nonnegative"`
+	Recurrence Recurrence `json:"recurrence,omitempty" validate:"oneof=daily|weekly"`
+}
+
+func (r *TaskRequest) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+}
+
+func (r TaskRequest) Task() Task {
+	return Task{
+		Name:       r.Name,
+		Status:     r.Status,
+		Reward:     r.Reward,
+		Recurrence: r.Recurrence,
+	}
+}
+
+// TaskPatch é o documento de PATCH da tarefa, em JSON Merge Patch, com os campos
+// que TaskRepository.Update altera
+type TaskPatch struct {
+	Status Status `json:"status,omitempty" validate:"nonzero,oneof=pending|finished"`
+	Reward int64  `json:"reward" validate:"nonnegative"`
+}
+
+// TaskResponse é a tarefa como enviada aos clientes, com a recompensa efetiva
+// calculada pela precificação da casa
+type TaskResponse struct {
+	Name       string     `json:"name"`
+	Status     Status     `json:"status"`
+	Reward     int64      `json:"reward"`
+	Recurrence Recurrence `json:"recurrence,omitempty"`
+	Streak     int64      `json:"streak,omitempty"`
+	Version    int64      `json:"version,omitempty"`
+
+	EffectiveReward int64      `json:"effectiveReward,omitempty"`
+	Assignee        string     `json:"assignee,omitempty"`
+	DueAt           *time.Time `json:"dueAt,omitempty"`
+}
+
+func NewTaskResponse(t Task) TaskResponse {
+	return TaskResponse{
+		Name:            t.Name,
+		Status:          t.Status,
+		Reward:          t.Reward,
+		Recurrence:      t.Recurrence,
+		Streak:          t.Streak,
+		Version:         t.Version,
+		EffectiveReward: t.EffectiveReward,
+		Assignee:        t.Assignee,
+		DueAt:           t.DueAt,
+	}
+}
+
+func NewTaskResponses(tasks []Task) []TaskResponse {
+	responses := make([]TaskResponse, 0, len(tasks))
+	for _, t := range tasks {
+		responses = append(responses, NewTaskResponse(t))
+	}
+	return responses
+}
@@ -1,231 +1,224 @@
 package task
 
 import (
-	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
-	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/events"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/etag"
+	"github.com/nsbnroque/go-to-do-list/internal/mergepatch"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
 )
 
-func CreateTaskHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
+func CreateTaskHandler(tasks TaskRepository, published chan<- events.Event) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
-		return
-	}
+		var taskRequest TaskRequest
+		if !request.Bind(c, &taskRequest) {
+			return
+		}
 
-	userEmail := c.Query("user")
+		task, err := tasks.Save(c.Request.Context(), userEmail, request.Param(c, "id"), taskRequest.Task())
 
-	var taskData Task
-	if err := c.ShouldBindJSON(&taskData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Erro ao decodificar dados da requisição: %v", err),
-		})
-		log.Println(err.Error())
-		return
-	}
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.NoHome))
+			return
+		}
 
-	// Execute query
-	result, err := neo4j.ExecuteQuery(c.Request.Context(), dbHandler.Driver,
-		`MATCH (u:User {email: $email})-[:LIVES_IN]->(h:Home)
-		MERGE (t:Task {name: $name})
-		SET t.reward = $reward
-		MERGE (h)-[r:HAS_TASK]->(t)
-		ON MATCH SET r.status = $status
-		RETURN t.name as name, t.reward as reward, r.status as status;				
-		`,
-		map[string]interface{}{
-			"name":   taskData.Name,
-			"status": "pending",
-			"email":  userEmail,
-			"reward": taskData.Reward,
-		},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Erro ao criar task: %v", err),
-		})
-		return
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao criar task: %w", err))
+			return
+		}
+
+		response := NewTaskResponse(task)
+		events.Publish(published, events.From(c, events.TaskCreated, response))
+
+		etag.Set(c, task.Version)
+		c.JSON(http.StatusCreated, response)
 	}
+}
+
+func ChangeTaskHandler(tasks TaskRepository, published chan<- events.Event) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
 
-	for _, record := range result.Records {
-		name, _ := record.Get("name")
-		reward, _ := record.Get("reward")
-		task := Task{
-			Name:   name.(string),
-			Reward: reward.(int64),
+		var taskRequest TaskRequest
+		if err := c.ShouldBindJSON(&taskRequest); err != nil {
+			apierror.Respond(c, apierror.Binding(err))
+			return
+		}
+
+		// Em /api/v1 a tarefa é identificada pelo caminho
+		if name := c.Param("task"); name != "" {
+			taskRequest.Name = name
+		}
+
+		if !request.Validate(c, &taskRequest) {
+			return
 		}
-		// Enviar a lista de usuários como resposta
-		c.JSON(http.StatusOK, task)
+
+		version, err := etag.IfMatch(c)
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
+
+		changes := taskRequest.Task()
+		changes.Version = version
+		task, err := tasks.Update(c.Request.Context(), userEmail, request.Param(c, "id"), changes)
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.TaskNotFound))
+			return
+		}
+
+		if errors.Is(err, repository.ErrStaleVersion) {
+			apierror.Respond(c, apierror.New(apierror.PreconditionFailed))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao alterar task: %w", err))
+			return
+		}
+
+		response := NewTaskResponse(task)
+		events.Publish(published, events.From(c, events.TaskUpdated, response))
+
+		etag.Set(c, task.Version)
+		c.JSON(http.StatusOK, response)
 	}
 }
 
-func ChangeTaskHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
+// PatchTaskHandler altera o status e a recompensa da tarefa em :task
+func PatchTaskHandler(tasks TaskRepository, published chan<- events.Event) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+		taskName := request.Param(c, "task")
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
-		return
-	}
+		version, err := etag.IfMatch(c)
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
 
-	_, driver := dbHandler.Ctx, dbHandler.Driver
-	userEmail := c.Query("user")
+		entries, err := tasks.FindByUser(c.Request.Context(), userEmail, request.Param(c, "id"))
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao encontrar tarefa: %w", err))
+			return
+		}
 
-	var taskData Task
-	if err := c.ShouldBindJSON(&taskData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Erro ao decodificar dados da requisição: %v", err),
-		})
-		log.Println(err.Error())
-		return
-	}
+		var current *Task
+		for i := range entries {
+			if entries[i].Task.Name == taskName {
+				current = &entries[i].Task
+			}
+		}
+		if current == nil {
+			apierror.Respond(c, apierror.New(apierror.TaskNotFound))
+			return
+		}
 
-	// Execute query
-	result, err := neo4j.ExecuteQuery(c.Request.Context(), driver,
-		`MATCH (u:User {email: $email})
-		MATCH (t:Task {name: $name})
-			SET t.reward = $reward
-		MATCH (u)-[h:LIVES_IN]->(h)-[r:HAS_TASK]->(t)
-			SET r.status = $status
-		RETURN t.name as name, t.reward as reward, r.status as status;			
-		`,
-		map[string]interface{}{
-			"name":   taskData.Name,
-			"status": taskData.Status,
-			"reward": taskData.Reward,
-			"email":  userEmail,
-		},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Erro ao criar task: %v", err),
-		})
-		return
-	}
+		// Sem status gravado, ou com "status": null no patch, a tarefa fica pendente
+		original := TaskPatch{Status: current.Status, Reward: current.Reward}
+		if original.Status == "" {
+			original.Status = Pending
+		}
+		patched := TaskPatch{Status: Pending}
+		if !mergepatch.Bind(c, original, &patched) {
+			return
+		}
+
+		changes := Task{Name: current.Name, Status: patched.Status, Reward: patched.Reward, Version: current.Version}
+		if version != 0 {
+			changes.Version = version
+		}
+		task, err := tasks.Update(c.Request.Context(), userEmail, request.Param(c, "id"), changes)
 
-	for _, record := range result.Records {
-		name, _ := record.Get("name")
-		status, _ := record.Get("status")
-		reward, _ := record.Get("reward")
-		taskStatus := Status(status.(string))
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.TaskNotFound))
+			return
+		}
+
+		if errors.Is(err, repository.ErrStaleVersion) {
+			apierror.Respond(c, apierror.New(apierror.PreconditionFailed))
+			return
+		}
 
-		task := Task{
-			Name:   name.(string),
-			Reward: reward.(int64),
-			Status: taskStatus,
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao alterar task: %w", err))
+			return
 		}
-		// Enviar a lista de usuários como resposta
-		c.JSON(http.StatusOK, task)
+
+		response := NewTaskResponse(task)
+		events.Publish(published, events.From(c, events.TaskUpdated, response))
+
+		etag.Set(c, task.Version)
+		c.JSON(http.StatusOK, response)
 	}
 }
 
-func DeleteTaskHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
+func DeleteTaskHandler(tasks TaskRepository, published chan<- events.Event) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+		taskName := request.Param(c, "task")
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
-		return
-	}
+		version, err := etag.IfMatch(c)
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
 
-	userEmail := c.Query("user")
-	taskName := c.Query("task")
-
-	// Execute query
-	_, err = neo4j.ExecuteQuery(c.Request.Context(), dbHandler.Driver,
-		`MATCH (u:User {email: $email})
-		MATCH (t:Task {name: $taskName})
-		MATCH (u)-[r:HAS_TASK]->(t)
-		DETACH DELETE t;			
-		`,
-		map[string]interface{}{
-			"taskName": taskName,
-			"email":    userEmail,
-		},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Erro ao excluir a tarefa: %v", err),
+		if err := tasks.Delete(c.Request.Context(), userEmail, request.Param(c, "id"), taskName, version, time.Now()); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				apierror.Respond(c, apierror.New(apierror.TaskNotFound))
+				return
+			}
+			if errors.Is(err, repository.ErrStaleVersion) {
+				apierror.Respond(c, apierror.New(apierror.PreconditionFailed))
+				return
+			}
+			apierror.Respond(c, fmt.Errorf("Erro ao excluir a tarefa: %w", err))
+			return
+		}
+
+		events.Publish(published, events.From(c, events.TaskDeleted, gin.H{"name": taskName}))
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Tarefa %s movida para a lixeira!", taskName),
 		})
-		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Tarefa %s excluída com sucesso!", taskName),
-	})
 }
 
-func GetTasksForUserHandler(ctx context.Context, driver neo4j.DriverWithContext, database string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userEmail := r.URL.Query().Get("user")
-
-		// Execute query to get tasks for the user
-		result, err := neo4j.ExecuteQuery(ctx, driver,
-			`MATCH (u:User {email: $email})-[:HAS_TASK]->(t:Task)
-			RETURN t.name as name, t.reward as reward, t.status as status;`,
-			map[string]interface{}{
-				"email": userEmail,
-			},
-			neo4j.EagerResultTransformer,
-			neo4j.ExecuteQueryWithDatabase(database),
-		)
-
+func GetTasksForUserHandler(tasks TaskRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entries, err := tasks.FindByUser(c.Request.Context(), request.User(c), request.Param(c, "id"))
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Erro ao obter as tarefas do usuário: %v", err), http.StatusInternalServerError)
+			apierror.Respond(c, fmt.Errorf("Erro ao obter as tarefas do usuário: %w", err))
 			return
 		}
 
-		var tasks []Task
-		for _, record := range result.Records {
-			task := Task{}
+		now := time.Now()
 
-			// Verificar e atribuir o nome
-			if name, found := record.Get("name"); found {
-				task.Name = name.(string)
-			}
-
-			// Verificar e atribuir a recompensa
-			if reward, found := record.Get("reward"); found && reward != nil {
-				if rewardInt, ok := reward.(int64); ok {
-					task.Reward = rewardInt
-				} else {
-					task.Reward = 0
-				}
-			}
+		var userTasks []Task
+		for _, entry := range entries {
+			task := entry.Task
 
-			// Verificar e atribuir o status
-			if status, found := record.Get("status"); found && status != nil {
-				if statusStr, ok := status.(string); ok {
-					task.Status = Status(statusStr)
-				} else {
-					log.Println("Erro ao converter status para string")
-				}
+			// Calcular a recompensa efetiva conforme a precificação da casa
+			task.EffectiveReward = task.Reward
+			if task.Status != Finished {
+				task.EffectiveReward = entry.Pricing.Effective(task.Reward, entry.PendingSince, now)
 			}
-			tasks = append(tasks, task)
+			userTasks = append(userTasks, task)
 		}
 
 		// Enviar a lista de tarefas como resposta
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(tasks)
+		etag.JSON(c, NewTaskResponses(userTasks))
 	}
 }
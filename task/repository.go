@@ -0,0 +1,31 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/pricing"
+)
+
+// Entry é uma tarefa da casa acompanhada do necessário para calcular sua recompensa efetiva
+type Entry struct {
+	Task         Task
+	PendingSince time.Time
+	Pricing      pricing.Pricing
+}
+
+// TaskRepository atua nas casas do usuário. Com home informado, apenas na casa
+// home, se o usuário morar nela; vazio, em todas elas, como nas rotas antigas.
+type TaskRepository interface {
+	// Save cria a tarefa na casa do usuário ou, se ela já existir, atualiza a recompensa e a reabre.
+	// Uma tarefa de mesmo nome na lixeira da casa é descartada.
+	Save(ctx context.Context, email string, home string, task Task) (Task, error)
+	// Update altera a recompensa e o status de uma tarefa da casa do usuário. Com
+	// task.Version diferente de zero, só grava se a tarefa ainda estiver nessa
+	// versão, retornando repository.ErrStaleVersion caso contrário.
+	Update(ctx context.Context, email string, home string, task Task) (Task, error)
+	// Delete move a tarefa das casas do usuário para a lixeira; com version
+	// diferente de zero, apenas se ainda estiver nessa versão
+	Delete(ctx context.Context, email string, home string, name string, version int64, at time.Time) error
+	FindByUser(ctx context.Context, email string, home string) ([]Entry, error)
+}
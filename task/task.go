@@ -1,5 +1,11 @@
 package task
 
+import (
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
 type Status string
 
 const (
@@ -18,10 +24,48 @@ func (s *Status) String() string {
 	}
 }
 
+type Recurrence string
+
+const (
+	Daily  Recurrence = "daily"
+	Weekly Recurrence = "weekly"
+)
+
+// Days retorna a duração do período de recorrência, ou 0 para tarefas avulsas
+func (r Recurrence) Days() int {
+	switch r {
+	case Daily:
+		return 1
+	case Weekly:
+		return 7
+	default:
+		return 0
+	}
+}
+
+// Task é a tarefa como guardada nos repositórios. As requisições usam
+// TaskRequest e as respostas TaskResponse.
 type Task struct {
-	Name   string `json:"name"`
-	Status Status `json:"status"`
-	Reward int64  `json:"reward"`
+	Name       string
+	Status     Status
+	Reward     int64
+	Recurrence Recurrence
+	Streak     int64
+	// Version aumenta a cada gravação da tarefa e é enviada como ETag
+	Version int64
+
+	EffectiveReward int64
+	Assignee        string
+	DueAt           *time.Time
+}
+
+type Assignment struct {
+	Assignee string    `json:"assignee" validate:"nonzero,email"`
+	DueAt    time.Time `json:"dueAt" validate:"nonzero"`
+}
+
+func (a *Assignment) Normalize() {
+	a.Assignee = request.NormalizeEmail(a.Assignee)
 }
 
 type TaskList struct {
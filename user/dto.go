@@ -0,0 +1,75 @@
+package user
+
+import (
+	"strings"
+
+	"github.com/nsbnroque/go-to-do-list/achievement"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
+// UserRequest é o corpo aceito na criação e na atualização do usuário. O
+// bcrypt considera apenas os primeiros 72 bytes da senha, daí o limite.
+type UserRequest struct {
+	Name     string `json:"name" validate:"nonzero,maxlen=100"`
+	Email    string `json:"email" validate:"nonzero,email"`
+	Password string `json:"password" validate:"nonzero,minlen=8,maxlen=72"`
+}
+
+func (r *UserRequest) Normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Email = request.NormalizeEmail(r.Email)
+}
+
+// User converte a requisição no modelo guardado, com a senha já transformada em hash
+func (r UserRequest) User(passwordHash string) User {
+	return User{
+		Name:     r.Name,
+		Email:    r.Email,
+		Password: passwordHash,
+	}
+}
+
+// UserPatch é o documento de PATCH /me, em JSON Merge Patch. A senha não é
+// lida de volta, então só é alterada quando o patch traz uma nova.
+type UserPatch struct {
+	Name     string `json:"name" validate:"nonzero,maxlen=100"`
+	Password string `json:"password,omitempty" validate:"minlen=8,maxlen=72"`
+}
+
+func (p *UserPatch) Normalize() {
+	p.Name = strings.TrimSpace(p.Name)
+}
+
+// UserResponse é o usuário como enviado aos clientes, sem a senha
+type UserResponse struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Score int64  `json:"score"`
+	// Version é a mesma versão enviada na ETag, para uso em If-Match
+	Version int64 `json:"version,omitempty"`
+
+	DailyStreak  int64 `json:"dailyStreak"`
+	WeeklyStreak int64 `json:"weeklyStreak"`
+
+	Achievements []achievement.Achievement `json:"achievements,omitempty"`
+}
+
+func NewUserResponse(u User) UserResponse {
+	return UserResponse{
+		Name:         u.Name,
+		Email:        u.Email,
+		Score:        u.Score,
+		DailyStreak:  u.DailyStreak,
+		WeeklyStreak: u.WeeklyStreak,
+		Version:      u.Version,
+		Achievements: u.Achievements,
+	}
+}
+
+func NewUserResponses(users []User) []UserResponse {
+	responses := make([]UserResponse, 0, len(users))
+	for _, u := range users {
+		responses = append(responses, NewUserResponse(u))
+	}
+	return responses
+}
@@ -1,285 +1,238 @@
 package user
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
-	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/achievement"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/etag"
+	"github.com/nsbnroque/go-to-do-list/internal/mergepatch"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
 )
 
-func CreateUserHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
-		return
-	}
+func CreateUserHandler(users UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userData UserRequest
+		if !request.Bind(c, &userData) {
+			return
+		}
 
-	ctx, driver := dbHandler.Ctx, dbHandler.Driver
+		hashedPassword, err := HashPassword(userData.Password)
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao gerar hash da senha: %w", err))
+			return
+		}
 
-	var userData User
-	if err := c.ShouldBindJSON(&userData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Erro ao decodificar dados da requisição",
-		})
-		return
-	}
+		if err := users.Save(c.Request.Context(), userData.User(hashedPassword)); err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao criar usuário: %w", err))
+			return
+		}
 
-	hashedPassword, err := HashPassword(userData.Password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Erro ao gerar hash da senha",
+		// Envie uma resposta de sucesso
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Usuário criado com sucesso!",
 		})
-		return
 	}
-	userData.Password = hashedPassword
-	// Execute query
-	result, err := neo4j.ExecuteQuery(ctx, driver,
-		`MERGE (u:User {email: $email})
-				SET
-					u.name = $name,
-					u.password = $password
-				RETURN u;
-			`,
-		map[string]interface{}{
-			"name":     userData.Name,
-			"password": userData.Password,
-			"email":    userData.Email,
-		},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
+}
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Erro ao criar usuário",
-		})
-		return
-	}
+func UpdateUserHandler(users UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userData UserRequest
+		if err := c.ShouldBindJSON(&userData); err != nil {
+			apierror.Respond(c, apierror.Binding(err))
+			return
+		}
 
-	// Envie uma resposta de sucesso
-	c.JSON(http.StatusCreated, gin.H{
-		"message": fmt.Sprintf("Usuário criado com sucesso! %v nodes criados em %+v.\n",
-			result.Summary.Counters().NodesCreated(),
-			result.Summary.ResultAvailableAfter(),
-		),
-	})
+		// Em /api/v1/me o usuário vem do cabeçalho, não do corpo
+		if email := request.User(c); email != "" {
+			userData.Email = email
+		}
 
-}
+		if !request.Validate(c, &userData) {
+			return
+		}
 
-func UpdateUserHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
+		version, err := etag.IfMatch(c)
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
-		return
-	}
+		hashedPassword, err := HashPassword(userData.Password)
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao gerar hash da senha: %w", err))
+			return
+		}
 
-	ctx, driver := dbHandler.Ctx, dbHandler.Driver
+		changes := userData.User(hashedPassword)
+		changes.Version = version
+		updated, err := users.Update(c.Request.Context(), changes)
 
-	var userData User
-	if err := c.ShouldBindJSON(&userData); err != nil {
-		log.Println("Error: Failed to bind JSON", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
-		return
-	}
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.UserNotFound))
+			return
+		}
 
-	hashedPassword, _ := HashPassword(userData.Password)
-	userData.Password = hashedPassword
-
-	// Execute query
-	result, err := neo4j.ExecuteQuery(ctx, driver,
-		`MATCH (u:User {email: $email})
-			SET
-				u.name = $name,
-				u.password = $password
-			RETURN u;
-		`,
-		map[string]interface{}{
-			"name":     userData.Name,
-			"password": userData.Password,
-			"email":    userData.Email,
-		},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
+		if errors.Is(err, repository.ErrStaleVersion) {
+			apierror.Respond(c, apierror.New(apierror.PreconditionFailed))
+			return
+		}
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Erro ao atualizar usuário",
-		})
-		return
-	}
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao atualizar usuário: %w", err))
+			return
+		}
 
-	if len(result.Records) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Usuário não encontrado",
+		// Envie uma resposta de sucesso
+		etag.Set(c, updated.Version)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Usuário atualizado com sucesso!",
 		})
-		return
 	}
-
-	// Envie uma resposta de sucesso
-	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Usuário atualizado com sucesso! %v nodes criados em %+v.\n",
-			result.Summary.Counters().NodesCreated(),
-			result.Summary.ResultAvailableAfter(),
-		),
-	})
 }
 
-func FindAllUsersHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
-
-	if dbHandler == nil || err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
-		return
-	}
+// PatchUserHandler altera o nome e a senha do usuário que faz a requisição
+func PatchUserHandler(users UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
 
-	ctx, driver := dbHandler.Ctx, dbHandler.Driver
+		version, err := etag.IfMatch(c)
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
 
-	// Execute query
-	result, err := neo4j.ExecuteQuery(ctx, driver,
-		"MATCH (u:User) RETURN u.name AS name, u.email AS email, u.password AS password",
-		nil,
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Erro ao executar a consulta: %v", err),
-		})
-		return
-	}
+		current, err := users.FindByEmail(c.Request.Context(), userEmail)
 
-	// Processar os resultados
-	var users []User
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.UserNotFound))
+			return
+		}
 
-	for _, record := range result.Records {
-		name, _ := record.Get("name")
-		email, _ := record.Get("email")
-		password, _ := record.Get("password")
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao encontrar usuário: %w", err))
+			return
+		}
 
-		user := User{
-			Name:     name.(string),
-			Email:    email.(string),
-			Password: password.(string),
+		var patched UserPatch
+		if !mergepatch.Bind(c, UserPatch{Name: current.Name}, &patched) {
+			return
 		}
 
-		users = append(users, user)
-	}
+		changes := User{Name: patched.Name, Email: current.Email, Version: current.Version}
+		if version != 0 {
+			changes.Version = version
+		}
+		if patched.Password != "" {
+			changes.Password, err = HashPassword(patched.Password)
+			if err != nil {
+				apierror.Respond(c, fmt.Errorf("Erro ao gerar hash da senha: %w", err))
+				return
+			}
+		}
 
-	// Enviar a lista de usuários como resposta
-	c.JSON(http.StatusOK, users)
-}
+		updated, err := users.Update(c.Request.Context(), changes)
 
-func FindByEmailHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.UserNotFound))
+			return
+		}
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
-		return
-	}
+		if errors.Is(err, repository.ErrStaleVersion) {
+			apierror.Respond(c, apierror.New(apierror.PreconditionFailed))
+			return
+		}
 
-	ctx, driver := dbHandler.Ctx, dbHandler.Driver
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao atualizar usuário: %w", err))
+			return
+		}
 
-	email := c.Query("email")
-	result, err := neo4j.ExecuteQuery(ctx, driver,
-		"MATCH (u:User{email: $email}) RETURN u.name AS name, u.email AS email",
-		map[string]interface{}{"email": email},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Erro ao executar a consulta: %v", err),
+		etag.Set(c, updated.Version)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Usuário atualizado com sucesso!",
 		})
-		return
 	}
+}
 
-	for _, record := range result.Records {
-		name, _ := record.Get("name")
-		email, _ := record.Get("email")
-
-		user := User{
-			Name:  name.(string),
-			Email: email.(string),
+func FindAllUsersHandler(users UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allUsers, err := users.FindAll(c.Request.Context())
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao listar usuários: %w", err))
+			return
 		}
 
 		// Enviar a lista de usuários como resposta
-		c.JSON(http.StatusOK, user)
+		etag.JSON(c, NewUserResponses(allUsers))
 	}
 }
 
-func DeleteByEmailHandler(c *gin.Context) {
-	dbHandler, err := database.NewDatabaseHandler()
+func FindByEmailHandler(users UserRepository, achievements achievement.AchievementRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		respondWithUser(c, users, achievements, request.NormalizeEmail(request.Param(c, "email")))
+	}
+}
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Falha de conexão com o banco de dados",
-		})
+// GetCurrentUserHandler responde com o usuário que faz a requisição, em /api/v1/me
+func GetCurrentUserHandler(users UserRepository, achievements achievement.AchievementRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		respondWithUser(c, users, achievements, request.User(c))
+	}
+}
+
+func respondWithUser(c *gin.Context, users UserRepository, achievements achievement.AchievementRepository, email string) {
+	ctx := c.Request.Context()
+
+	user, err := users.FindByEmail(ctx, email)
+
+	if errors.Is(err, repository.ErrNotFound) {
+		apierror.Respond(c, apierror.New(apierror.UserNotFound))
 		return
 	}
 
-	ctx, driver := dbHandler.Ctx, dbHandler.Driver
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("Erro ao buscar usuário: %w", err))
+		return
+	}
 
-	email := c.Query("email")
-	_, err = neo4j.ExecuteQuery(ctx, driver,
-		`MATCH (u:User{email: $email}) 
-		DETACH DELETE u`,
-		map[string]interface{}{"email": email},
-		neo4j.EagerResultTransformer,
-		neo4j.ExecuteQueryWithDatabase(dbHandler.Config.Database),
-	)
+	user.Achievements, err = achievements.FindByUser(ctx, user.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Erro ao excluir usuário: %v", err),
-		})
+		apierror.Respond(c, fmt.Errorf("Erro ao obter conquistas: %w", err))
 		return
 	}
 
-	// Envie uma resposta de sucesso
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Usuário excluído com sucesso!",
-	})
+	etag.Set(c, user.Version)
+	c.JSON(http.StatusOK, NewUserResponse(user))
 }
 
-func UpdateScore(ctx context.Context, driver neo4j.DriverWithContext, database string, email, task string) error {
-	query := `
-		MATCH (u:User {email: $email})-[:HAS_HOME]->(h:Home)-[:HAS_TASK]->(t:Task {nome: $task})
-		ON MATCH SET u.score = u.score + t.reward
-		RETURN t.reward AS reward, u AS user
-	`
-
-	_, err := neo4j.ExecuteQuery(ctx, driver, query,
-		map[string]interface{}{"email": email, "task": task},
-		neo4j.EagerResultTransformer, neo4j.ExecuteQueryWithDatabase(database),
-	)
-
-	if err != nil {
-		return fmt.Errorf("Erro ao atualizar pontuação: %v", err)
-	}
+func DeleteByEmailHandler(users UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := etag.IfMatch(c)
+		if err != nil {
+			apierror.Respond(c, err)
+			return
+		}
 
-	//	for _, record := range result.Records {
-	//	user, _ := record.Get("user")
+		err = users.DeleteByEmail(c.Request.Context(), request.NormalizeEmail(c.Query("email")), version)
 
-	// Lógica para processar o usuário, como enviar para um canal ou realizar outra ação
-	// ...
+		if errors.Is(err, repository.ErrStaleVersion) {
+			apierror.Respond(c, apierror.New(apierror.PreconditionFailed))
+			return
+		}
 
-	//}
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao excluir usuário: %w", err))
+			return
+		}
 
-	return nil
+		// Envie uma resposta de sucesso
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Usuário excluído com sucesso!",
+		})
+	}
 }
@@ -0,0 +1,18 @@
+package user
+
+import "context"
+
+type UserRepository interface {
+	// Save cria o usuário ou, se o email já existir, atualiza nome e senha
+	Save(ctx context.Context, user User) error
+	// Update altera o nome de um usuário existente e, se user.Password não for vazia, a senha,
+	// retornando repository.ErrNotFound caso o usuário não exista.
+	// Com user.Version diferente de zero, só grava se o usuário ainda estiver nessa
+	// versão, retornando repository.ErrStaleVersion caso contrário.
+	Update(ctx context.Context, user User) (User, error)
+	FindAll(ctx context.Context) ([]User, error)
+	FindByEmail(ctx context.Context, email string) (User, error)
+	// DeleteByEmail exclui o usuário; com version diferente de zero, apenas se
+	// ainda estiver nessa versão
+	DeleteByEmail(ctx context.Context, email string, version int64) error
+}
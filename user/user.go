@@ -1,25 +1,25 @@
 package user
 
 import (
-	"log"
-
+	"github.com/nsbnroque/go-to-do-list/achievement"
 	"golang.org/x/crypto/bcrypt"
-	"gopkg.in/validator.v2"
 )
 
+// User é o usuário como guardado nos repositórios, com o hash da senha. As
+// respostas usam UserResponse; a tag impede que o hash seja serializado mesmo
+// que um User chegue a c.JSON por engano.
 type User struct {
-	Name     string `json:"name" validate:"nonzero"`
-	Email    string `json:"email" validate:"nonzero"`
-	Password string `json:"password" validate:"nonzero,min=8"`
-	Score    int64  `json:"score"`
-}
+	Name     string
+	Email    string
+	Password string `json:"-"`
+	Score    int64
+	// Version aumenta a cada gravação do usuário e é enviada como ETag
+	Version int64
 
-func ValidateUser(user *User) error {
-	if err := validator.Validate(user); err != nil {
-		log.Println(err.Error())
-		return err
-	}
-	return nil
+	DailyStreak  int64
+	WeeklyStreak int64
+
+	Achievements []achievement.Achievement
 }
 
 func HashPassword(password string) (string, error) {
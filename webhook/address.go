@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+)
+
+// AllowPrivateEnv libera webhooks para endereços locais e de redes privadas,
+// para testes e desenvolvimento
+const AllowPrivateEnv = "WEBHOOK_ALLOW_PRIVATE"
+
+var errBlockedAddress = errors.New("endereço de webhook não permitido")
+
+// blocked indica se ip é de loopback, de rede privada ou link-local, endereços
+// que um webhook não pode alcançar sem a liberação de AllowPrivateEnv
+func blocked(ip net.IP) bool {
+	if os.Getenv(AllowPrivateEnv) == "true" {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
+
+// checkURL resolve o host de rawURL e recusa os endereços bloqueados
+func checkURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if blocked(addr.IP) {
+			return errBlockedAddress
+		}
+	}
+	return nil
+}
+
+// dialControl confere o endereço já resolvido de cada conexão, o que também
+// barra hosts cujo DNS mudou depois do cadastro
+func dialControl(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || blocked(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
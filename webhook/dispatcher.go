@@ -0,0 +1,246 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/events"
+)
+
+const (
+	// maxAttempts com retryDelay dobrando a cada falha dá cerca de uma hora de tentativas
+	maxAttempts = 8
+	retryDelay  = 30 * time.Second
+	batchSize   = 50
+	userAgent   = "go-to-do-list-webhooks"
+	// failedRequest é o erro registrado quando não há resposta do destino
+	failedRequest = "falha ao enviar a requisição"
+)
+
+// Dispatcher grava as entregas dos eventos e as envia, repetindo as que falham
+type Dispatcher struct {
+	hooks  WebhookRepository
+	client *http.Client
+	// wake avisa Run de novas entregas, sem esperar o próximo intervalo
+	wake chan struct{}
+}
+
+func NewDispatcher(hooks WebhookRepository) *Dispatcher {
+	return &Dispatcher{
+		hooks: hooks,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			// Sem proxy, para que dialControl veja o endereço de destino
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{
+					Timeout:   5 * time.Second,
+					KeepAlive: 30 * time.Second,
+					Control:   dialControl,
+				}).DialContext,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 5 * time.Second,
+			},
+			// Um redirecionamento transformaria o POST num GET sem corpo, então conta como falha
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Enqueue grava uma entrega do evento para cada webhook que o recebe
+func (d *Dispatcher) Enqueue(ctx context.Context, event events.Event) error {
+	hooks, err := d.hooks.Subscribed(ctx, event.Home, event.Type)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]Delivery, 0, len(hooks))
+	for _, hook := range hooks {
+		delivery, err := newDelivery(hook, event, now)
+		if err != nil {
+			return err
+		}
+		delivery.NextAttemptAt = &now
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := d.hooks.Enqueue(ctx, deliveries); err != nil {
+		return err
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run envia as entregas pendentes quando Enqueue grava novas e, a cada interval,
+// as que chegaram à hora de uma nova tentativa
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		d.deliverDue(ctx)
+	}
+}
+
+// Test envia um evento ping ao webhook e retorna a entrega, que não é repetida se falhar
+func (d *Dispatcher) Test(ctx context.Context, hook Webhook, actor string) (Delivery, error) {
+	now := time.Now()
+	delivery, err := newDelivery(hook, events.Event{Type: Ping, Home: hook.Home, Actor: actor, At: now}, now)
+	if err != nil {
+		return Delivery{}, err
+	}
+	delivery.URL = hook.URL
+	delivery.Secret = hook.Secret
+
+	// Sem NextAttemptAt a entrega fica fora de Due enquanto é enviada aqui
+	if err := d.hooks.Enqueue(ctx, []Delivery{delivery}); err != nil {
+		return Delivery{}, err
+	}
+
+	attempt := d.send(ctx, delivery)
+	delivery = advance(delivery, attempt)
+	if err := d.hooks.Record(context.WithoutCancel(ctx), delivery, attempt); err != nil {
+		return Delivery{}, err
+	}
+
+	delivery.Attempts = []Attempt{attempt}
+	return delivery, nil
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for {
+		due, err := d.hooks.Due(ctx, time.Now(), batchSize)
+		if err != nil {
+			log.Printf("Erro ao buscar as entregas de webhooks pendentes: %v", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		failed := false
+		for _, delivery := range due {
+			wg.Add(1)
+			go func(delivery Delivery) {
+				defer wg.Done()
+				attempt := d.send(ctx, delivery)
+				// No desligamento a tentativa interrompida não conta, e a entrega
+				// é refeita quando o servidor voltar
+				if ctx.Err() != nil {
+					return
+				}
+				if err := d.hooks.Record(ctx, advance(delivery, attempt), attempt); err != nil {
+					log.Printf("Erro ao registrar a entrega %s do webhook %s: %v", delivery.ID, delivery.Webhook, err)
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}(delivery)
+		}
+		wg.Wait()
+
+		// Com um lote cheio pode haver mais entregas vencidas; se algum registro
+		// falhou, as mesmas voltariam, então espera o próximo intervalo
+		if len(due) < batchSize || failed || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// send faz uma tentativa de entrega
+func (d *Dispatcher) send(ctx context.Context, delivery Delivery) Attempt {
+	started := time.Now()
+	attempt := Attempt{Number: delivery.AttemptCount + 1, At: started}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		log.Printf("Erro ao montar a entrega %s do webhook %s: %v", delivery.ID, delivery.Webhook, err)
+		attempt.Error = failedRequest
+		return attempt
+	}
+
+	timestamp := started.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Webhook-Id", delivery.Webhook.String())
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	// O erro de conexão fica no log; a entrega, visível aos administradores da
+	// casa, registra só a falha, sem revelar o que há na rede do servidor
+	resp, err := d.client.Do(req)
+	attempt.DurationMs = time.Since(started).Milliseconds()
+	if err != nil {
+		log.Printf("Erro ao enviar a entrega %s do webhook %s: %v", delivery.ID, delivery.Webhook, err)
+		attempt.Error = failedRequest
+		return attempt
+	}
+	// A resposta é descartada, mas lida para que a conexão seja reaproveitada
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("resposta %s", resp.Status)
+	}
+	return attempt
+}
+
+func newDelivery(hook Webhook, event events.Event, now time.Time) (Delivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	return Delivery{
+		ID:        uuid.New(),
+		Webhook:   hook.ID,
+		Event:     event.Type,
+		Payload:   payload,
+		Status:    Pending,
+		CreatedAt: now,
+	}, nil
+}
+
+// advance aplica o resultado da tentativa à entrega: concluída com sucesso,
+// falha definitiva ou uma nova tentativa com o intervalo dobrado
+func advance(delivery Delivery, attempt Attempt) Delivery {
+	delivery.AttemptCount = attempt.Number
+	delivery.NextAttemptAt = nil
+
+	switch {
+	case attempt.Error == "":
+		delivery.Status = Delivered
+	case attempt.Number >= maxAttempts || delivery.Event == Ping:
+		delivery.Status = Failed
+	default:
+		next := attempt.At.Add(retryDelay << (attempt.Number - 1))
+		delivery.NextAttemptAt = &next
+	}
+	return delivery
+}
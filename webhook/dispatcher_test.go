@@ -0,0 +1,300 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/events"
+)
+
+// fakeRepository guarda as entregas em memória; os métodos de cadastro não são
+// usados pelo Dispatcher e ficam com a interface nula
+type fakeRepository struct {
+	WebhookRepository
+
+	mu         sync.Mutex
+	hooks      []Webhook
+	deliveries map[uuid.UUID]*Delivery
+	attempts   map[uuid.UUID][]Attempt
+}
+
+func newFakeRepository(hooks ...Webhook) *fakeRepository {
+	return &fakeRepository{
+		hooks:      hooks,
+		deliveries: map[uuid.UUID]*Delivery{},
+		attempts:   map[uuid.UUID][]Attempt{},
+	}
+}
+
+func (r *fakeRepository) Subscribed(ctx context.Context, home string, eventType events.Type) ([]Webhook, error) {
+	var subscribed []Webhook
+	for _, hook := range r.hooks {
+		if hook.Home == home && hook.Subscribes(eventType) {
+			subscribed = append(subscribed, hook)
+		}
+	}
+	return subscribed, nil
+}
+
+func (r *fakeRepository) Enqueue(ctx context.Context, deliveries []Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		delivery := delivery
+		r.deliveries[delivery.ID] = &delivery
+	}
+	return nil
+}
+
+func (r *fakeRepository) Due(ctx context.Context, now time.Time, limit int) ([]Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []Delivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status != Pending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		for _, hook := range r.hooks {
+			if hook.ID == delivery.Webhook {
+				found := *delivery
+				found.URL = hook.URL
+				found.Secret = hook.Secret
+				due = append(due, found)
+			}
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeRepository) Record(ctx context.Context, delivery Delivery, attempt Attempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries[delivery.ID] = &delivery
+	r.attempts[delivery.ID] = append(r.attempts[delivery.ID], attempt)
+	return nil
+}
+
+// only retorna a única entrega gravada
+func (r *fakeRepository) only(t *testing.T) Delivery {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.deliveries) != 1 {
+		t.Fatalf("%d entregas gravadas, esperava 1", len(r.deliveries))
+	}
+	for _, delivery := range r.deliveries {
+		return *delivery
+	}
+	return Delivery{}
+}
+
+// expire antecipa a próxima tentativa das entregas pendentes, como se o
+// intervalo entre tentativas tivesse passado
+func (r *fakeRepository) expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	past := time.Now().Add(-time.Second)
+	for _, delivery := range r.deliveries {
+		if delivery.NextAttemptAt != nil {
+			delivery.NextAttemptAt = &past
+		}
+	}
+}
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func TestDispatcherSignsAndRetries(t *testing.T) {
+	// O servidor de teste escuta em 127.0.0.1
+	t.Setenv(AllowPrivateEnv, "true")
+
+	var mu sync.Mutex
+	var requests []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, received{header: r.Header.Clone(), body: body})
+
+		// A primeira entrega falha com 5xx, as seguintes são aceitas
+		if len(requests) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sent := func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]received(nil), requests...)
+	}
+
+	hook := Webhook{
+		ID:     uuid.New(),
+		Home:   "casa",
+		URL:    server.URL,
+		Events: []events.Type{events.TaskCreated},
+		Secret: "segredo",
+	}
+	repo := newFakeRepository(hook)
+	dispatcher := NewDispatcher(repo)
+	ctx := context.Background()
+
+	event := events.Event{Type: events.TaskCreated, Home: "casa", Actor: "a@x", At: time.Now()}
+	if err := dispatcher.Enqueue(ctx, event); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// Primeira tentativa: 503, a entrega continua pendente com nova tentativa marcada
+	dispatcher.deliverDue(ctx)
+	delivery := repo.only(t)
+	if delivery.Status != Pending || delivery.AttemptCount != 1 || delivery.NextAttemptAt == nil {
+		t.Fatalf("após 503: status %s, %d tentativas, próxima %v", delivery.Status, delivery.AttemptCount, delivery.NextAttemptAt)
+	}
+	if wait := time.Until(*delivery.NextAttemptAt); wait < retryDelay-time.Second || wait > retryDelay {
+		t.Errorf("próxima tentativa em %v, esperava cerca de %v", wait, retryDelay)
+	}
+	if attempt := repo.attempts[delivery.ID][0]; attempt.StatusCode != http.StatusServiceUnavailable || attempt.Error == "" {
+		t.Errorf("tentativa registrada %+v, esperava falha com 503", attempt)
+	}
+
+	// Antes do intervalo nada é reenviado
+	dispatcher.deliverDue(ctx)
+	if n := len(sent()); n != 1 {
+		t.Fatalf("%d requisições antes do intervalo, esperava 1", n)
+	}
+
+	// Passado o intervalo, a entrega é repetida e aceita
+	repo.expire()
+	dispatcher.deliverDue(ctx)
+	delivery = repo.only(t)
+	if delivery.Status != Delivered || delivery.AttemptCount != 2 || delivery.NextAttemptAt != nil {
+		t.Fatalf("após 204: status %s, %d tentativas, próxima %v", delivery.Status, delivery.AttemptCount, delivery.NextAttemptAt)
+	}
+	if n := len(sent()); n != 2 {
+		t.Fatalf("%d requisições, esperava 2", n)
+	}
+
+	for i, req := range sent() {
+		if got := req.header.Get("X-Webhook-Delivery"); got != delivery.ID.String() {
+			t.Errorf("requisição %d: X-Webhook-Delivery = %q, esperava %s", i, got, delivery.ID)
+		}
+		if got := req.header.Get("X-Webhook-Id"); got != hook.ID.String() {
+			t.Errorf("requisição %d: X-Webhook-Id = %q, esperava %s", i, got, hook.ID)
+		}
+		if got := req.header.Get("X-Webhook-Event"); got != string(events.TaskCreated) {
+			t.Errorf("requisição %d: X-Webhook-Event = %q", i, got)
+		}
+
+		timestamp, err := strconv.ParseInt(req.header.Get("X-Webhook-Timestamp"), 10, 64)
+		if err != nil || time.Since(time.Unix(timestamp, 0)) > time.Minute {
+			t.Errorf("requisição %d: X-Webhook-Timestamp = %q", i, req.header.Get("X-Webhook-Timestamp"))
+		}
+
+		// A assinatura é conferida como faria o destinatário, sem usar Sign
+		mac := hmac.New(sha256.New, []byte(hook.Secret))
+		mac.Write([]byte(req.header.Get("X-Webhook-Timestamp") + "."))
+		mac.Write(req.body)
+		want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if got := req.header.Get("X-Webhook-Signature"); !hmac.Equal([]byte(got), []byte(want)) {
+			t.Errorf("requisição %d: X-Webhook-Signature = %q, esperava %q", i, got, want)
+		}
+	}
+}
+
+func TestDispatcherBlocksPrivateAddresses(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	hook := Webhook{ID: uuid.New(), Home: "casa", URL: server.URL, Events: []events.Type{events.TaskCreated}}
+	if err := checkURL(context.Background(), hook.URL); err == nil {
+		t.Errorf("checkURL(%q) aceitou um endereço de loopback", hook.URL)
+	}
+
+	attempt := NewDispatcher(newFakeRepository(hook)).send(context.Background(), Delivery{ID: uuid.New(), Webhook: hook.ID, URL: hook.URL})
+	if requests != 0 {
+		t.Fatalf("%d requisições ao endereço de loopback, esperava nenhuma", requests)
+	}
+	if attempt.Error != failedRequest || attempt.StatusCode != 0 {
+		t.Errorf("tentativa registrada %+v, esperava a falha genérica", attempt)
+	}
+}
+
+func TestDispatcherIgnoresUnsubscribedEvents(t *testing.T) {
+	hook := Webhook{ID: uuid.New(), Home: "casa", URL: "http://127.0.0.1:0", Events: []events.Type{events.TaskCreated}}
+	repo := newFakeRepository(hook)
+	dispatcher := NewDispatcher(repo)
+
+	for _, event := range []events.Event{
+		{Type: events.TaskDeleted, Home: "casa"},
+		{Type: events.TaskCreated, Home: "outra"},
+	} {
+		if err := dispatcher.Enqueue(context.Background(), event); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	if len(repo.deliveries) != 0 {
+		t.Errorf("%d entregas gravadas, esperava nenhuma", len(repo.deliveries))
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	at := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		event   events.Type
+		attempt Attempt
+		status  Status
+		next    time.Duration
+	}{
+		{name: "sucesso", event: events.TaskCreated, attempt: Attempt{Number: 1, At: at, StatusCode: 200}, status: Delivered},
+		{name: "primeira falha", event: events.TaskCreated, attempt: Attempt{Number: 1, At: at, Error: "x"}, status: Pending, next: retryDelay},
+		{name: "terceira falha", event: events.TaskCreated, attempt: Attempt{Number: 3, At: at, Error: "x"}, status: Pending, next: 4 * retryDelay},
+		{name: "última tentativa", event: events.TaskCreated, attempt: Attempt{Number: maxAttempts, At: at, Error: "x"}, status: Failed},
+		{name: "ping não é repetido", event: Ping, attempt: Attempt{Number: 1, At: at, Error: "x"}, status: Failed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := advance(Delivery{Event: tt.event, Status: Pending}, tt.attempt)
+
+			if got.Status != tt.status || got.AttemptCount != tt.attempt.Number {
+				t.Errorf("status %s com %d tentativas, esperava %s com %d", got.Status, got.AttemptCount, tt.status, tt.attempt.Number)
+			}
+			if tt.next == 0 {
+				if got.NextAttemptAt != nil {
+					t.Errorf("próxima tentativa %v, esperava nenhuma", got.NextAttemptAt)
+				}
+				return
+			}
+			if got.NextAttemptAt == nil || !got.NextAttemptAt.Equal(at.Add(tt.next)) {
+				t.Errorf("próxima tentativa %v, esperava %v", got.NextAttemptAt, at.Add(tt.next))
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/events"
+)
+
+type WebhookRequest struct {
+	URL    string        `json:"url" validate:"nonzero,url,maxlen=2000"`
+	Events []events.Type `json:"events" validate:"nonzero"`
+}
+
+func (r *WebhookRequest) Normalize() {
+	r.URL = strings.TrimSpace(r.URL)
+}
+
+// unknownEvents indica se algum dos eventos pedidos não existe
+func (r WebhookRequest) unknownEvents() bool {
+	known := Webhook{Events: events.Types}
+	for _, eventType := range r.Events {
+		if !known.Subscribes(eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook monta o webhook da casa com um novo segredo
+func (r WebhookRequest) Webhook(home string, createdBy string) (Webhook, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return Webhook{}, err
+	}
+
+	return Webhook{
+		ID:        uuid.New(),
+		Home:      home,
+		URL:       r.URL,
+		Events:    r.Events,
+		Secret:    "whsec_" + hex.EncodeToString(secret),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+type WebhookResponse struct {
+	ID     uuid.UUID     `json:"id"`
+	URL    string        `json:"url"`
+	Events []events.Type `json:"events"`
+	// Secret só é enviado na resposta do cadastro
+	Secret    string    `json:"secret,omitempty"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewWebhookResponse(w Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    w.Events,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func NewWebhookResponses(hooks []Webhook) []WebhookResponse {
+	responses := make([]WebhookResponse, 0, len(hooks))
+	for _, w := range hooks {
+		responses = append(responses, NewWebhookResponse(w))
+	}
+	return responses
+}
@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nsbnroque/go-to-do-list/internal/apierror"
+	"github.com/nsbnroque/go-to-do-list/internal/database"
+	"github.com/nsbnroque/go-to-do-list/internal/repository"
+	"github.com/nsbnroque/go-to-do-list/internal/request"
+)
+
+// CreateWebhookHandler cadastra um webhook na casa em :id. O segredo das
+// assinaturas só aparece nesta resposta.
+func CreateWebhookHandler(hooks WebhookRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := request.User(c)
+
+		var webhookRequest WebhookRequest
+		if !request.Bind(c, &webhookRequest) {
+			return
+		}
+		if webhookRequest.unknownEvents() {
+			apierror.Respond(c, apierror.Invalid(apierror.Field("events", apierror.NotAllowed)))
+			return
+		}
+		if checkURL(c.Request.Context(), webhookRequest.URL) != nil {
+			apierror.Respond(c, apierror.Invalid(apierror.Field("url", apierror.NotAllowed)))
+			return
+		}
+
+		hook, err := webhookRequest.Webhook(request.Param(c, "id"), userEmail)
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao gerar o segredo do webhook: %w", err))
+			return
+		}
+
+		// Apenas administradores da casa cadastram webhooks
+		err = hooks.Create(c.Request.Context(), userEmail, hook)
+
+		if errors.Is(err, repository.ErrForbidden) {
+			apierror.Respond(c, apierror.New(apierror.AdminRequired))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao cadastrar webhook: %w", err))
+			return
+		}
+
+		response := NewWebhookResponse(hook)
+		response.Secret = hook.Secret
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+func GetWebhooksHandler(hooks WebhookRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		homeHooks, err := hooks.FindByHome(c.Request.Context(), request.User(c), request.Param(c, "id"))
+
+		if errors.Is(err, repository.ErrForbidden) {
+			apierror.Respond(c, apierror.New(apierror.AdminRequired))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao obter webhooks: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, NewWebhookResponses(homeHooks))
+	}
+}
+
+func DeleteWebhookHandler(hooks WebhookRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := request.Param(c, "webhook")
+
+		err := hooks.Delete(c.Request.Context(), request.User(c), request.Param(c, "id"), id)
+
+		if errors.Is(err, repository.ErrForbidden) {
+			apierror.Respond(c, apierror.New(apierror.AdminRequired))
+			return
+		}
+
+		if errors.Is(err, repository.ErrNotFound) {
+			apierror.Respond(c, apierror.New(apierror.WebhookNotFound))
+			return
+		}
+
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao excluir webhook: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Webhook %s excluído!", id),
+		})
+	}
+}
+
+// GetDeliveriesHandler lista as últimas entregas do webhook, com cada tentativa
+func GetDeliveriesHandler(hooks WebhookRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		hook, ok := findWebhook(c, hooks)
+		if !ok {
+			return
+		}
+
+		limit, err := database.ParseLimit(c.Request)
+		if err != nil {
+			apierror.Respond(c, apierror.New(apierror.InvalidRequest))
+			return
+		}
+
+		deliveries, err := hooks.Deliveries(ctx, hook.ID.String(), limit)
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao obter as entregas do webhook: %w", err))
+			return
+		}
+
+		if deliveries == nil {
+			deliveries = []Delivery{}
+		}
+
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+// TestWebhookHandler envia na hora um evento ping ao webhook e responde com o
+// resultado da entrega, mesmo que ela falhe
+func TestWebhookHandler(hooks WebhookRepository, dispatcher *Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hook, ok := findWebhook(c, hooks)
+		if !ok {
+			return
+		}
+
+		delivery, err := dispatcher.Test(c.Request.Context(), hook, request.User(c))
+		if err != nil {
+			apierror.Respond(c, fmt.Errorf("Erro ao testar webhook: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusCreated, delivery)
+	}
+}
+
+// findWebhook busca o webhook do caminho, que só os administradores da casa
+// veem. Em caso de erro já responde ao cliente e retorna false.
+func findWebhook(c *gin.Context, hooks WebhookRepository) (Webhook, bool) {
+	hook, err := hooks.FindByID(c.Request.Context(), request.User(c), request.Param(c, "id"), request.Param(c, "webhook"))
+
+	if errors.Is(err, repository.ErrForbidden) {
+		apierror.Respond(c, apierror.New(apierror.AdminRequired))
+		return Webhook{}, false
+	}
+
+	if errors.Is(err, repository.ErrNotFound) {
+		apierror.Respond(c, apierror.New(apierror.WebhookNotFound))
+		return Webhook{}, false
+	}
+
+	if err != nil {
+		apierror.Respond(c, fmt.Errorf("Erro ao encontrar webhook: %w", err))
+		return Webhook{}, false
+	}
+
+	return hook, true
+}
@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsbnroque/go-to-do-list/events"
+)
+
+type WebhookRepository interface {
+	// Create cadastra o webhook na casa webhook.Home, retornando
+	// repository.ErrForbidden se adminEmail não a administrar
+	Create(ctx context.Context, adminEmail string, webhook Webhook) error
+	// FindByHome lista os webhooks da casa, retornando repository.ErrForbidden se
+	// adminEmail não a administrar
+	FindByHome(ctx context.Context, adminEmail string, home string) ([]Webhook, error)
+	// FindByID retorna o webhook da casa, ou repository.ErrNotFound, e
+	// repository.ErrForbidden se adminEmail não a administrar
+	FindByID(ctx context.Context, adminEmail string, home string, id string) (Webhook, error)
+	// Delete remove o webhook e suas entregas, com os mesmos erros de FindByID
+	Delete(ctx context.Context, adminEmail string, home string, id string) error
+	// Subscribed retorna os webhooks da casa que recebem eventType
+	Subscribed(ctx context.Context, home string, eventType events.Type) ([]Webhook, error)
+
+	// Enqueue grava as entregas, ainda sem tentativas
+	Enqueue(ctx context.Context, deliveries []Delivery) error
+	// Due retorna até limit entregas pendentes cuja próxima tentativa venceu até
+	// now, com a URL e o segredo do webhook
+	Due(ctx context.Context, now time.Time, limit int) ([]Delivery, error)
+	// Record grava a tentativa junto com o novo estado e a próxima tentativa da entrega
+	Record(ctx context.Context, delivery Delivery, attempt Attempt) error
+	// Deliveries lista as últimas entregas do webhook, das mais recentes para as
+	// mais antigas, com as tentativas
+	Deliveries(ctx context.Context, webhook string, limit int) ([]Delivery, error)
+}
@@ -0,0 +1,97 @@
+// Package webhook entrega os eventos das casas às URLs cadastradas pelos
+// administradores, para integrá-las a automações externas. Cada entrega é um
+// POST com o events.Event em JSON e os cabeçalhos:
+//
+//	X-Webhook-Id         webhook que recebe a entrega
+//	X-Webhook-Delivery   entrega, repetido nas novas tentativas
+//	X-Webhook-Event      tipo do evento
+//	X-Webhook-Timestamp  segundos desde 1970 no momento do envio
+//	X-Webhook-Signature  sha256= e o HMAC-SHA256 em hexadecimal de timestamp, ponto e corpo
+//
+// O HMAC usa o segredo mostrado ao cadastrar o webhook. O destinatário deve
+// conferir a assinatura, recusar timestamps antigos e ignorar entregas repetidas,
+// pois uma entrega pode chegar mais de uma vez. Respostas fora da faixa 2xx são
+// repetidas com intervalos crescentes até maxAttempts tentativas.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsbnroque/go-to-do-list/events"
+)
+
+// Ping é o evento das entregas de teste, que não fica disponível para assinatura
+const Ping events.Type = "ping"
+
+type Webhook struct {
+	ID     uuid.UUID
+	Home   string
+	URL    string
+	Events []events.Type
+	// Secret assina as entregas e só é mostrado ao cadastrar o webhook
+	Secret    string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// Subscribes indica se o webhook recebe os eventos do tipo
+func (w Webhook) Subscribes(eventType events.Type) bool {
+	for _, subscribed := range w.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Delivered Status = "delivered"
+	Failed    Status = "failed"
+)
+
+// Delivery é o envio de um evento a um webhook, com as tentativas já feitas
+type Delivery struct {
+	ID      uuid.UUID       `json:"id"`
+	Webhook uuid.UUID       `json:"webhook"`
+	Event   events.Type     `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+	Status  Status          `json:"status"`
+	// NextAttemptAt fica nulo quando a entrega termina, com sucesso ou não
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	AttemptCount  int        `json:"attemptCount"`
+	// Attempts só é preenchido ao listar as entregas
+	Attempts []Attempt `json:"attempts,omitempty"`
+
+	// Destino da entrega, preenchido pelo repositório em Due
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// Attempt é uma tentativa de entrega. StatusCode fica zero quando não houve
+// resposta, e Error traz o motivo da falha.
+type Attempt struct {
+	Number     int       `json:"number"`
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// Sign calcula a assinatura enviada em X-Webhook-Signature
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}